@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/bayneri/margin/internal/analyze"
+	"github.com/bayneri/margin/internal/planner"
 	"github.com/bayneri/margin/internal/report"
 )
 
@@ -28,6 +29,24 @@ type analyzeOptions struct {
 	maxSLOs       int
 	only          string
 	failOnPartial bool
+	step          string
+	top           int
+	period        bool
+	alerts        bool
+	breakdown     string
+	charts        string
+	concurrency   int
+	sloTimeout    time.Duration
+	services      string
+	allServices   bool
+	file          string
+	backtest      bool
+	record        string
+	replay        string
+	exclude       string
+	events        string
+	revisions     string
+	changeLag     time.Duration
 }
 
 func runAnalyze(args []string) error {
@@ -37,16 +56,34 @@ func runAnalyze(args []string) error {
 	opts := &analyzeOptions{}
 	fs.StringVar(&opts.project, "project", "", "GCP project ID")
 	fs.StringVar(&opts.service, "service", "", "Monitoring service ID or resource name")
+	fs.StringVar(&opts.services, "services", "", "comma-separated Monitoring service IDs to analyze in one run")
+	fs.BoolVar(&opts.allServices, "all-services", false, "analyze every Monitoring service in --project")
+	fs.StringVar(&opts.file, "f", "", "spec file to backtest from raw metrics, or directory of specs whose services to analyze")
+	fs.BoolVar(&opts.backtest, "backtest", false, "with -f specs/, evaluate each spec's SLIs from raw metrics instead of the applied SLOs")
 	fs.StringVar(&opts.start, "start", "", "RFC3339 start time")
 	fs.StringVar(&opts.end, "end", "", "RFC3339 end time")
 	fs.StringVar(&opts.last, "last", "", "relative lookback duration (e.g. 90m, 6h)")
 	fs.StringVar(&opts.out, "out", "", "output directory")
-	fs.StringVar(&opts.format, "format", "md,json", "comma-separated output formats")
+	fs.StringVar(&opts.format, "format", "md,json", "comma-separated output formats: md, json, html")
 	fs.BoolVar(&opts.explain, "explain", false, "include formulas and query notes")
 	fs.StringVar(&opts.timezone, "timezone", "UTC", "IANA timezone for reports")
 	fs.IntVar(&opts.maxSLOs, "max-slos", 50, "maximum number of SLOs to analyze")
 	fs.StringVar(&opts.only, "only", "", "regex to filter SLO display names or ids")
 	fs.BoolVar(&opts.failOnPartial, "fail-on-partial", false, "exit non-zero if any SLO cannot be analyzed")
+	fs.StringVar(&opts.step, "step", "auto", "burndown step: a duration of at least 1m, auto, or off")
+	fs.BoolVar(&opts.period, "period", true, "also report the budget of each SLO's own rolling or calendar period")
+	fs.StringVar(&opts.breakdown, "breakdown", "", "comma-separated label keys to break bad events down by (e.g. metric.label.response_code,resource.label.revision_name)")
+	fs.BoolVar(&opts.alerts, "alerts", true, "correlate the window with margin's burn-rate alerts and their incidents")
+	fs.IntVar(&opts.top, "top", 5, "number of worst intervals to list per SLO in summary.md")
+	fs.StringVar(&opts.charts, "charts", "svg", "comma-separated burndown chart formats (svg, png) or none")
+	fs.IntVar(&opts.concurrency, "concurrency", analyze.DefaultConcurrency, "number of SLOs to evaluate at once")
+	fs.DurationVar(&opts.sloTimeout, "slo-timeout", 2*time.Minute, "time limit for the queries of each SLO (0 for none)")
+	fs.StringVar(&opts.record, "record", "", "save the SLO list and every Monitoring response to this directory")
+	fs.StringVar(&opts.replay, "replay", "", "analyze from a directory written by --record instead of Monitoring")
+	fs.StringVar(&opts.events, "events", "", "JSON or CSV file of deploys, config pushes, and flag flips to overlay on the burndown")
+	fs.StringVar(&opts.revisions, "revisions", "", "read Cloud Run revisions as deploys: LOCATION/SERVICE, or LOCATION with a cloud-run spec")
+	fs.DurationVar(&opts.changeLag, "change-lag", analyze.DefaultChangeLag, "how long before a burn spike a change counts as preceding it")
+	fs.StringVar(&opts.exclude, "exclude", "", "comma-separated intervals to leave out of the adjusted budget, as start/end or start/end=reason (RFC3339)")
 
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -56,6 +93,38 @@ func runAnalyze(args []string) error {
 	if err != nil {
 		return err
 	}
+	if opts.concurrency < 1 {
+		return fmt.Errorf("invalid --concurrency %d (want at least 1)", opts.concurrency)
+	}
+	if opts.sloTimeout < 0 {
+		return fmt.Errorf("invalid --slo-timeout %s", opts.sloTimeout)
+	}
+	step, burndown, err := analyze.ParseStep(opts.step)
+	if err != nil {
+		return err
+	}
+	breakdown, err := analyze.ParseBreakdown(opts.breakdown)
+	if err != nil {
+		return err
+	}
+	chartFormats, err := parseChartFormats(opts.charts)
+	if err != nil {
+		return err
+	}
+	exclusions, err := analyze.ParseExclusions(splitCSV(opts.exclude))
+	if err != nil {
+		return fmt.Errorf("invalid --exclude: %w", err)
+	}
+	if opts.changeLag <= 0 {
+		return fmt.Errorf("invalid --change-lag %s", opts.changeLag)
+	}
+	var changes []analyze.ChangeEvent
+	if opts.events != "" {
+		changes, err = analyze.LoadChangeEvents(opts.events)
+		if err != nil {
+			return err
+		}
+	}
 	loc, err := time.LoadLocation(opts.timezone)
 	if err != nil {
 		return fmt.Errorf("invalid timezone: %w", err)
@@ -69,36 +138,310 @@ func runAnalyze(args []string) error {
 		}
 	}
 
-	reader, err := analyze.NewGCPReader(context.Background())
+	modes := 0
+	for _, set := range []bool{opts.service != "", opts.services != "", opts.allServices, opts.file != ""} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return errors.New("use only one of --service, --services, --all-services, and -f")
+	}
+	if opts.backtest && opts.file == "" {
+		return errors.New("--backtest needs -f")
+	}
+	if opts.revisions != "" && (opts.services != "" || opts.allServices) {
+		return errors.New("--revisions needs --service or -f")
+	}
+
+	if opts.record != "" && opts.replay != "" {
+		return errors.New("use only one of --record and --replay")
+	}
+	// --record and --replay fix the time of analysis so relative windows
+	// resolve the same on replay.
+	var reader analyze.Source
+	var now time.Time
+	if opts.replay != "" {
+		replay, err := analyze.OpenReplay(opts.replay)
+		if err != nil {
+			return err
+		}
+		reader = replay
+		now = replay.RecordedAt()
+	} else {
+		live, err := analyze.NewGCPReader(context.Background())
+		if err != nil {
+			return err
+		}
+		defer live.Close()
+		reader = live
+		if opts.record != "" {
+			now = time.Now().UTC()
+			reader, err = analyze.NewRecorder(opts.record, live, analyze.Recording{RecordedAt: now, Args: args})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	var alerts analyze.AlertReader
+	if opts.alerts {
+		alerts = reader
+	}
+	analyzeOpts := analyze.Options{
+		Project:     opts.project,
+		Service:     opts.service,
+		Start:       opts.start,
+		End:         opts.end,
+		Last:        lastDuration,
+		OutDir:      opts.out,
+		Format:      parseFormat(opts.format),
+		Explain:     opts.explain,
+		Timezone:    loc,
+		MaxSLOs:     opts.maxSLOs,
+		Only:        only,
+		Burndown:    burndown,
+		Step:        step,
+		Period:      opts.period,
+		Alerts:      alerts,
+		Breakdown:   breakdown,
+		Concurrency: opts.concurrency,
+		SLOTimeout:  opts.sloTimeout,
+		Now:         now,
+		Exclusions:  exclusions,
+		Changes:     changes,
+		ChangeLag:   opts.changeLag,
+	}
+
+	// A spec file, or a directory with --backtest, evaluates the specs' own
+	// SLIs, so alerts on the applied SLOs may not match what is being
+	// analyzed. A directory otherwise analyzes the applied SLOs of each
+	// spec's service.
+	var source analyze.Reader = reader
+	var plans []specPlan
+	if opts.file != "" {
+		var single bool
+		plans, single, err = loadSpecPlans(opts.file, opts.project)
+		if err != nil {
+			return err
+		}
+		if single || opts.backtest {
+			specReader, err := analyze.NewSpecReader(reader, planList(plans))
+			if err != nil {
+				return err
+			}
+			source = specReader
+			analyzeOpts.Alerts = nil
+		}
+		if single {
+			analyzeOpts.Project = plans[0].Project
+			analyzeOpts.Service = plans[0].ServiceID
+			analyzeOpts.Exclusions = append(analyzeOpts.Exclusions, plans[0].maintenance...)
+		}
+		for i := range plans {
+			if plans[i].revisions, err = revisionSource(opts.revisions, reader, &plans[i], single); err != nil {
+				return err
+			}
+		}
+		if single {
+			analyzeOpts.Revisions = plans[0].revisions
+		}
+	} else if analyzeOpts.Revisions, err = revisionSource(opts.revisions, reader, nil, true); err != nil {
+		return err
+	}
+	if analyzeOpts.Service == "" && modes == 1 {
+		targets, err := fleetTargets(reader, opts, plans)
+		if err != nil {
+			return err
+		}
+		return runAnalyzeFleet(source, targets, opts, analyzeOpts, chartFormats, loc)
+	}
+
+	result, sources, outDir, err := analyze.Run(context.Background(), source, analyzeOpts)
+	if err != nil {
+		return err
+	}
+	if err := writeAnalysis(outDir, result, sources, opts, chartFormats, loc); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "Wrote analysis to %s\n", outDir)
+
+	if len(result.Errors) > 0 {
+		if opts.failOnPartial {
+			return exitError{code: 2, err: errors.New("partial analysis")}
+		}
+		fmt.Fprintf(os.Stderr, "Partial analysis: %d SLO(s) could not be evaluated. See %s\n", len(result.Errors), filepath.Join(outDir, "errors.md"))
+		return nil
+	}
+	return nil
+}
+
+// fleetTargets lists the services named by --services, --all-services, or
+// the specs loaded by -f.
+func fleetTargets(reader analyze.Source, opts *analyzeOptions, plans []specPlan) ([]analyze.Target, error) {
+	var targets []analyze.Target
+	switch {
+	case opts.file != "":
+		for _, plan := range plans {
+			targets = append(targets, analyze.Target{Project: plan.Project, Service: plan.ServiceID, Exclusions: plan.maintenance, Revisions: plan.revisions})
+		}
+	case strings.TrimSpace(opts.project) == "":
+		return nil, errors.New("--project is required with --services and --all-services")
+	case opts.allServices:
+		services, err := reader.ListServices(context.Background(), opts.project)
+		if err != nil {
+			return nil, err
+		}
+		if len(services) == 0 {
+			return nil, fmt.Errorf("no Monitoring services found in project %s", opts.project)
+		}
+		for _, service := range services {
+			targets = append(targets, analyze.Target{Project: opts.project, Service: service})
+		}
+	default:
+		for _, service := range splitCSV(opts.services) {
+			targets = append(targets, analyze.Target{Project: opts.project, Service: service})
+		}
+	}
+	return targets, nil
+}
+
+// runAnalyzeFleet analyzes each target, then aggregates them the way margin
+// report does.
+func runAnalyzeFleet(reader analyze.Reader, targets []analyze.Target, opts *analyzeOptions, analyzeOpts analyze.Options, chartFormats []string, loc *time.Location) error {
+	ctx := context.Background()
+	runs, outDir, err := analyze.RunFleet(ctx, reader, targets, analyzeOpts)
 	if err != nil {
 		return err
 	}
-	defer reader.Close()
-
-	result, sources, outDir, err := analyze.Run(context.Background(), reader, analyze.Options{
-		Project:       opts.project,
-		Service:       opts.service,
-		Start:         opts.start,
-		End:           opts.end,
-		Last:          lastDuration,
-		OutDir:        opts.out,
-		Format:        parseFormat(opts.format),
-		Explain:       opts.explain,
-		Timezone:      loc,
-		MaxSLOs:       opts.maxSLOs,
-		Only:          only,
-	})
+	var results []analyze.Result
+	var inputs []string
+	for _, run := range runs {
+		if run.Err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", run.Result.Errors[0])
+		} else if err := writeAnalysis(run.OutDir, run.Result, run.Sources, opts, chartFormats, loc); err != nil {
+			return err
+		}
+		results = append(results, run.Result)
+		inputs = append(inputs, filepath.Join(run.OutDir, "summary.json"))
+	}
+	agg, err := report.Aggregate(results, inputs)
 	if err != nil {
 		return err
 	}
+	if err := writeAggregateReport(outDir, agg, parseFormat(opts.format), chartFormats); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "Wrote analysis of %d service(s) to %s\n", len(runs), outDir)
+
+	if len(agg.Errors) > 0 {
+		if opts.failOnPartial {
+			return exitError{code: 2, err: errors.New("partial analysis")}
+		}
+		fmt.Fprintf(os.Stderr, "Partial analysis: %d service(s) could not be fully evaluated. See %s\n", len(agg.Errors), filepath.Join(outDir, "summary.md"))
+	}
+	return nil
+}
+
+// specPlan is a planned spec, the maintenance windows it declares, and the
+// Cloud Run service whose revisions --revisions reads for it.
+type specPlan struct {
+	planner.Plan
+	maintenance []analyze.Exclusion
+	revisions   *analyze.RevisionSource
+}
+
+// revisionSource resolves --revisions for one spec, or for --service when
+// plan is nil. LOCATION alone names the Cloud Run service of a cloud-run
+// spec; in a directory of specs, the others are skipped.
+func revisionSource(value string, reader analyze.ChangeReader, plan *specPlan, single bool) (*analyze.RevisionSource, error) {
+	if value == "" {
+		return nil, nil
+	}
+	location, service, _ := strings.Cut(value, "/")
+	if strings.TrimSpace(location) == "" {
+		return nil, fmt.Errorf("invalid --revisions %q (want LOCATION/SERVICE or LOCATION)", value)
+	}
+	switch {
+	case service != "" && !single:
+		return nil, errors.New("--revisions takes only a LOCATION with a directory of specs")
+	case service != "":
+	case plan != nil && plan.Service == "cloud-run":
+		service = plan.ServiceName
+	case plan != nil && !single:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("--revisions %s needs LOCATION/SERVICE unless -f is a cloud-run spec", value)
+	}
+	return &analyze.RevisionSource{Reader: reader, Location: location, Service: service}, nil
+}
+
+// loadSpecPlans plans each spec in path, a file or a directory of .yaml
+// files, the way margin apply does. single reports whether path is a file.
+func loadSpecPlans(path, project string) (plans []specPlan, single bool, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, false, err
+		}
+		files = nil
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+		if len(files) == 0 {
+			return nil, false, fmt.Errorf("no .yaml specs in %s", path)
+		}
+	}
+	for _, file := range files {
+		plan, specDoc, err := buildPlan(&commandOptions{file: file, project: project})
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", file, err)
+		}
+		maintenance, err := analyze.SpecExclusions(specDoc.Maintenance)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", file, err)
+		}
+		plans = append(plans, specPlan{Plan: plan, maintenance: maintenance})
+	}
+	return plans, !info.IsDir(), nil
+}
+
+func planList(plans []specPlan) []planner.Plan {
+	out := make([]planner.Plan, len(plans))
+	for i, plan := range plans {
+		out[i] = plan.Plan
+	}
+	return out
+}
 
+// writeAnalysis writes the reports of one analyzed service into outDir.
+func writeAnalysis(outDir string, result analyze.Result, sources analyze.Sources, opts *analyzeOptions, chartFormats []string, loc *time.Location) error {
 	if err := os.MkdirAll(outDir, 0755); err != nil {
 		return fmt.Errorf("create output directory: %w", err)
 	}
 
 	formats := parseFormat(opts.format)
+	charts, err := report.WriteCharts(outDir, "", result.SLOs, chartFormats, loc)
+	if err != nil {
+		return err
+	}
 	if includesFormat(formats, "md") {
-		if err := report.WriteMarkdownSummary(filepath.Join(outDir, "summary.md"), result, report.Options{Explain: opts.explain, Timezone: loc}); err != nil {
+		if err := report.WriteMarkdownSummary(filepath.Join(outDir, "summary.md"), result, report.Options{Explain: opts.explain, Timezone: loc, TopIntervals: opts.top, Charts: charts}); err != nil {
+			return err
+		}
+	}
+	if includesFormat(formats, "html") {
+		if err := report.WriteHTMLSummary(filepath.Join(outDir, "summary.html"), result, report.Options{Explain: opts.explain, Timezone: loc, TopIntervals: opts.top}); err != nil {
 			return err
 		}
 	}
@@ -115,16 +458,6 @@ func runAnalyze(args []string) error {
 			return err
 		}
 	}
-
-	fmt.Fprintf(os.Stdout, "Wrote analysis to %s\n", outDir)
-
-	if len(result.Errors) > 0 {
-		if opts.failOnPartial {
-			return exitError{code: 2, err: errors.New("partial analysis")}
-		}
-		fmt.Fprintf(os.Stderr, "Partial analysis: %d SLO(s) could not be evaluated. See %s\n", len(result.Errors), filepath.Join(outDir, "errors.md"))
-		return nil
-	}
 	return nil
 }
 
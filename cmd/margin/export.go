@@ -35,6 +35,7 @@ func runExportTerraform(args []string) error {
 	if err != nil {
 		return err
 	}
+	warnDeprecations(opts.file, specDoc)
 	template, err := spec.TemplateForService(specDoc.Metadata.Service)
 	if err != nil {
 		return err
@@ -68,6 +69,7 @@ func runExportMonitoringJSON(args []string) error {
 	if err != nil {
 		return err
 	}
+	warnDeprecations(opts.file, specDoc)
 	template, err := spec.TemplateForService(specDoc.Metadata.Service)
 	if err != nil {
 		return err
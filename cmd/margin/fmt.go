@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bayneri/margin/internal/spec"
+)
+
+func runFmt(args []string) error {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	file := fs.String("f", "", "path to SLO spec (or pass files as arguments)")
+	write := fs.Bool("w", false, "write the result back to the file")
+	check := fs.Bool("check", false, "list files that are not formatted and exit 1 if there are any")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	files := fs.Args()
+	if *file != "" {
+		files = append([]string{*file}, files...)
+	}
+	if len(files) == 0 {
+		return errors.New("-f or at least one spec file is required")
+	}
+	if *write && *check {
+		return errors.New("-w and --check cannot be used together")
+	}
+
+	var unformatted []string
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read spec: %w", err)
+		}
+		formatted, err := spec.Format(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		switch {
+		case *check:
+			if !bytes.Equal(data, formatted) {
+				fmt.Fprintln(os.Stdout, path)
+				unformatted = append(unformatted, path)
+			}
+		case *write:
+			if bytes.Equal(data, formatted) {
+				continue
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(path, formatted, info.Mode().Perm()); err != nil {
+				return err
+			}
+		default:
+			if _, err := os.Stdout.Write(formatted); err != nil {
+				return err
+			}
+		}
+	}
+	if len(unformatted) > 0 {
+		return exitError{code: 1, err: fmt.Errorf("%d file(s) need formatting; run margin fmt -w", len(unformatted))}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/bayneri/margin/internal/analyze"
+	"github.com/bayneri/margin/internal/report"
+	"github.com/bayneri/margin/internal/spec"
+)
+
+func runForecast(args []string) error {
+	fs := flag.NewFlagSet("forecast", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	project := fs.String("project", "", "GCP project ID")
+	service := fs.String("service", "", "Monitoring service ID or resource name")
+	lookback := fs.String("lookback", "7d", "history to fit the models to")
+	step := fs.String("step", "1h", "resolution of the history")
+	horizon := fs.String("horizon", "7d", "how far ahead to forecast rolling-period SLOs")
+	out := fs.String("out", "", "output directory")
+	format := fs.String("format", "md,json", "comma-separated output formats: md, json")
+	timezone := fs.String("timezone", "UTC", "IANA timezone for reports")
+	maxSLOs := fs.Int("max-slos", 50, "maximum number of SLOs to forecast")
+	only := fs.String("only", "", "regex to filter SLO display names or ids")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	lookbackDuration, err := parseForecastWindow("lookback", *lookback)
+	if err != nil {
+		return err
+	}
+	stepDuration, err := parseForecastWindow("step", *step)
+	if err != nil {
+		return err
+	}
+	horizonDuration, err := parseForecastWindow("horizon", *horizon)
+	if err != nil {
+		return err
+	}
+	if stepDuration < time.Minute || stepDuration > lookbackDuration {
+		return fmt.Errorf("--step must be at least 1m and no longer than --lookback")
+	}
+	loc, err := time.LoadLocation(*timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+	var onlyRe *regexp.Regexp
+	if *only != "" {
+		onlyRe, err = regexp.Compile(*only)
+		if err != nil {
+			return fmt.Errorf("invalid --only regex: %w", err)
+		}
+	}
+
+	reader, err := analyze.NewGCPReader(context.Background())
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	result, err := analyze.Forecast(context.Background(), reader, analyze.ForecastOptions{
+		Project:  *project,
+		Service:  *service,
+		Lookback: lookbackDuration,
+		Step:     stepDuration,
+		Horizon:  horizonDuration,
+		MaxSLOs:  *maxSLOs,
+		Only:     onlyRe,
+	})
+	if err != nil {
+		return err
+	}
+
+	outDir := *out
+	if outDir == "" {
+		outDir = filepath.Join("out", "margin-forecast", fmt.Sprintf("%s-%s", result.GeneratedAt.Format("20060102-150405"), result.Service))
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
+	}
+	formats := parseFormat(*format)
+	if includesFormat(formats, "md") {
+		if err := report.WriteForecastMarkdown(filepath.Join(outDir, "forecast.md"), result, loc); err != nil {
+			return err
+		}
+	}
+	if includesFormat(formats, "json") {
+		if err := report.WriteForecastJSON(filepath.Join(outDir, "forecast.json"), result); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stdout, "Wrote forecast to %s (status: %s)\n", outDir, result.Status)
+	if len(result.Errors) > 0 {
+		fmt.Fprintf(os.Stderr, "Partial forecast: %d SLO(s) could not be forecast. See %s\n", len(result.Errors), filepath.Join(outDir, "forecast.md"))
+	}
+	return nil
+}
+
+func parseForecastWindow(name, value string) (time.Duration, error) {
+	d, err := spec.ParseWindow(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --%s: %w", name, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("--%s must be positive", name)
+	}
+	return d, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bayneri/margin/internal/analyze"
+	"github.com/bayneri/margin/internal/policy"
+)
+
+// Exit codes for margin gate. 1 stays reserved for usage and API errors so
+// deploy steps can tell "blocked by policy" apart from "gate failed to run".
+var gateExitCodes = map[string]int{
+	policy.ActionAllow:           0,
+	policy.ActionRequireApproval: 3,
+	policy.ActionFreeze:          4,
+}
+
+func runGate(args []string) error {
+	fs := flag.NewFlagSet("gate", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	opts := &commandOptions{}
+	fs.StringVar(&opts.file, "f", "", "path to SLO spec")
+	fs.StringVar(&opts.project, "project", "", "GCP project ID (overrides metadata.project)")
+	policyPath := fs.String("policy", "", "path to error budget policy")
+	output := fs.String("output", "text", "output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*policyPath) == "" {
+		return errors.New("--policy is required")
+	}
+	if !includesFormat([]string{"text", "json"}, *output) {
+		return fmt.Errorf("unknown --output %q (want text or json)", *output)
+	}
+	pol, err := policy.Load(*policyPath)
+	if err != nil {
+		return err
+	}
+	plan, specDoc, err := buildPlan(opts)
+	if err != nil {
+		return err
+	}
+	warnDeprecations(opts.file, specDoc)
+
+	reader, err := analyze.NewGCPReader(context.Background())
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	budgets, err := policy.Budgets(context.Background(), reader, plan, time.Now())
+	if err != nil {
+		return err
+	}
+	decision := policy.Evaluate(pol, budgets)
+
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(decision); err != nil {
+			return err
+		}
+	} else {
+		policy.Render(os.Stdout, decision)
+	}
+	if code := gateExitCodes[decision.Action]; code != 0 {
+		return exitError{code: code, err: fmt.Errorf("deploy gate: %s", decision.Action)}
+	}
+	return nil
+}
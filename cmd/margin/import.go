@@ -11,7 +11,7 @@ import (
 
 	"github.com/bayneri/margin/internal/importer"
 	"github.com/bayneri/margin/internal/monitoring"
-	"gopkg.in/yaml.v3"
+	"github.com/bayneri/margin/internal/spec"
 )
 
 func runImport(args []string) error {
@@ -53,7 +53,7 @@ func runImport(args []string) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 		return err
 	}
-	data, err := yaml.Marshal(result.Spec)
+	data, err := spec.Marshal(result.Spec)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bayneri/margin/internal/scaffold"
+	"github.com/bayneri/margin/internal/spec"
+)
+
+func runInit(args []string) error {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	service := fs.String("service", "", "margin service type (e.g., cloud-run)")
+	name := fs.String("name", "", "service name (metadata.name)")
+	project := fs.String("project", "", "GCP project ID")
+	runbook := fs.String("runbook", "", "runbook URL for paging alerts")
+	window := fs.String("window", scaffold.DefaultWindow, "compliance window for the starter SLOs")
+	availability := fs.Float64("availability", scaffold.DefaultAvailability, "availability objective in percent")
+	latency := fs.Float64("latency", scaffold.DefaultLatency, "latency objective in percent")
+	threshold := fs.Duration("threshold", 0, "latency threshold (default: template starter threshold)")
+	outPath := fs.String("out", "slo.yaml", "output path for the spec, or - for stdout")
+	force := fs.Bool("force", false, "overwrite an existing file")
+	interactive := fs.Bool("interactive", false, "prompt for service, objectives, and thresholds")
+	fs.BoolVar(interactive, "i", false, "shorthand for --interactive")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	opts := scaffold.Options{
+		Service:      *service,
+		Name:         *name,
+		Project:      *project,
+		Runbook:      *runbook,
+		Window:       *window,
+		Availability: *availability,
+		Latency:      *latency,
+		Threshold:    *threshold,
+	}
+	if *interactive {
+		if err := promptOptions(bufio.NewReader(os.Stdin), os.Stderr, &opts); err != nil {
+			return err
+		}
+	}
+	switch {
+	case strings.TrimSpace(opts.Service) == "":
+		return fmt.Errorf("--service is required (one of %s)", strings.Join(spec.TemplateNames(), ", "))
+	case strings.TrimSpace(opts.Name) == "":
+		return errors.New("--name is required")
+	case strings.TrimSpace(opts.Project) == "":
+		return errors.New("--project is required")
+	}
+
+	data, err := scaffold.Render(opts)
+	if err != nil {
+		return err
+	}
+	if *outPath == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if _, err := os.Stat(*outPath); err == nil && !*force {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", *outPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(*outPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(*outPath, data, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "Wrote %s\n", *outPath)
+	return nil
+}
+
+func promptOptions(in *bufio.Reader, out io.Writer, opts *scaffold.Options) error {
+	var err error
+	ask := func(label, current string) string {
+		if err != nil {
+			return current
+		}
+		if current != "" {
+			fmt.Fprintf(out, "%s [%s]: ", label, current)
+		} else {
+			fmt.Fprintf(out, "%s: ", label)
+		}
+		line, readErr := in.ReadString('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			err = readErr
+			return current
+		}
+		if value := strings.TrimSpace(line); value != "" {
+			return value
+		}
+		return current
+	}
+
+	opts.Service = ask(fmt.Sprintf("Service (%s)", strings.Join(spec.TemplateNames(), ", ")), opts.Service)
+	opts.Name = ask("Service name", opts.Name)
+	opts.Project = ask("GCP project", opts.Project)
+	opts.Runbook = ask("Runbook URL (optional)", opts.Runbook)
+	opts.Window = ask("Window", opts.Window)
+
+	objective := ask("Availability objective (%)", strconv.FormatFloat(opts.Availability, 'f', -1, 64))
+	if opts.Availability, err = parseObjective(objective, err); err != nil {
+		return err
+	}
+	objective = ask("Latency objective (%)", strconv.FormatFloat(opts.Latency, 'f', -1, 64))
+	if opts.Latency, err = parseObjective(objective, err); err != nil {
+		return err
+	}
+
+	current := ""
+	if opts.Threshold > 0 {
+		current = opts.Threshold.String()
+	} else if tpl, tplErr := spec.TemplateForService(opts.Service); tplErr == nil && tpl.LatencyThreshold > 0 {
+		current = tpl.LatencyThreshold.String()
+	}
+	if value := ask("Latency threshold", current); value != "" && err == nil {
+		if opts.Threshold, err = time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid latency threshold %q", value)
+		}
+	}
+	return err
+}
+
+func parseObjective(value string, err error) (float64, error) {
+	if err != nil {
+		return 0, err
+	}
+	objective, parseErr := strconv.ParseFloat(value, 64)
+	if parseErr != nil || objective <= 0 || objective >= 100 {
+		return 0, fmt.Errorf("objective %q must be a number between 0 and 100", value)
+	}
+	return objective, nil
+}
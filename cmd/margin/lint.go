@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bayneri/margin/internal/lint"
+	"github.com/bayneri/margin/internal/report"
+	"github.com/bayneri/margin/internal/spec"
+)
+
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	file := fs.String("f", "", "path to SLO spec")
+	configPath := fs.String("config", "", "path to lint config (default: .marginlint.yaml next to the spec or in the working directory)")
+	output := fs.String("output", "text", "output format: text, json, or sarif")
+	listRules := fs.Bool("list-rules", false, "list available rules and exit")
+	strict := fs.Bool("strict", false, "exit non-zero on warnings too")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *listRules {
+		for _, rule := range lint.Rules() {
+			fmt.Fprintf(os.Stdout, "%-30s %-8s %s\n", rule.ID, rule.Severity, rule.Description)
+		}
+		return nil
+	}
+	if strings.TrimSpace(*file) == "" {
+		return errors.New("-f is required")
+	}
+	if !includesFormat([]string{"text", "json", "sarif"}, *output) {
+		return fmt.Errorf("unknown --output %q (want text, json, or sarif)", *output)
+	}
+	cfg, err := loadLintConfig(*configPath, *file)
+	if err != nil {
+		return err
+	}
+
+	specDoc, err := spec.Load(*file)
+	var problems spec.ValidationErrors
+	if err != nil {
+		if !errors.As(err, &problems) {
+			return err
+		}
+	} else {
+		problems = append(specDoc.Check(), lint.Run(specDoc, cfg)...)
+	}
+
+	if len(problems) == 0 && *output == "text" {
+		fmt.Fprintln(os.Stdout, "No lint findings.")
+		return nil
+	}
+	if err := report.WriteDiagnostics(os.Stdout, problems, report.DiagnosticsOptions{
+		Format:      *output,
+		File:        *file,
+		ToolVersion: version,
+	}); err != nil {
+		return err
+	}
+	if problems.HasErrors() || (*strict && len(problems) > 0) {
+		return exitError{code: 1, err: fmt.Errorf("lint found %d problem(s)", len(problems))}
+	}
+	return nil
+}
+
+func loadLintConfig(path, specPath string) (lint.Config, error) {
+	if path != "" {
+		return lint.LoadConfig(path)
+	}
+	for _, candidate := range []string{filepath.Join(filepath.Dir(specPath), lint.ConfigFile), lint.ConfigFile} {
+		if _, err := os.Stat(candidate); err == nil {
+			return lint.LoadConfig(candidate)
+		}
+	}
+	return lint.Config{}, nil
+}
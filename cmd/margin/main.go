@@ -11,6 +11,7 @@ import (
 	"github.com/bayneri/margin/internal/alerting"
 	"github.com/bayneri/margin/internal/monitoring"
 	"github.com/bayneri/margin/internal/planner"
+	"github.com/bayneri/margin/internal/report"
 	"github.com/bayneri/margin/internal/spec"
 )
 
@@ -47,6 +48,42 @@ func main() {
 		if err := runValidate(os.Args[2:]); err != nil {
 			fail(err)
 		}
+	case "init":
+		if err := runInit(os.Args[2:]); err != nil {
+			fail(err)
+		}
+	case "lint":
+		if err := runLint(os.Args[2:]); err != nil {
+			fail(err)
+		}
+	case "fmt":
+		if err := runFmt(os.Args[2:]); err != nil {
+			fail(err)
+		}
+	case "migrate":
+		if err := runMigrate(os.Args[2:]); err != nil {
+			fail(err)
+		}
+	case "schema":
+		if err := runSchema(os.Args[2:]); err != nil {
+			fail(err)
+		}
+	case "forecast":
+		if err := runForecast(os.Args[2:]); err != nil {
+			fail(err)
+		}
+	case "recommend":
+		if err := runRecommend(os.Args[2:]); err != nil {
+			fail(err)
+		}
+	case "simulate":
+		if err := runSimulate(os.Args[2:]); err != nil {
+			fail(err)
+		}
+	case "gate":
+		if err := runGate(os.Args[2:]); err != nil {
+			fail(err)
+		}
 	case "explain":
 		if err := runExplain(os.Args[2:]); err != nil {
 			fail(err)
@@ -83,14 +120,29 @@ func usage() {
 	fmt.Fprintln(os.Stderr, "margin - opinionated SLOs for Google Cloud")
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "Usage:")
+	fmt.Fprintln(os.Stderr, "  margin init   --service cloud-run --name checkout-api --project my-gcp-project [--interactive]")
 	fmt.Fprintln(os.Stderr, "  margin apply   -f slo.yaml")
 	fmt.Fprintln(os.Stderr, "  margin analyze --project my-gcp-project --service checkout-api --last 90m")
+	fmt.Fprintln(os.Stderr, "  margin analyze --project my-gcp-project --all-services|--services a,b --last 90m")
+	fmt.Fprintln(os.Stderr, "  margin analyze -f slo.yaml|specs/ [--backtest] --last 7d")
+	fmt.Fprintln(os.Stderr, "  margin analyze ... --record dir/ | --replay dir/")
+	fmt.Fprintln(os.Stderr, "  margin analyze ... --events changes.csv | --revisions us-central1[/checkout-api] [--change-lag 30m]")
+	fmt.Fprintln(os.Stderr, "  margin analyze ... --exclude 2025-01-04T02:00:00Z/2025-01-04T04:00:00Z=maintenance")
 	fmt.Fprintln(os.Stderr, "  margin plan    -f slo.yaml")
-	fmt.Fprintln(os.Stderr, "  margin validate -f slo.yaml")
+	fmt.Fprintln(os.Stderr, "  margin validate -f slo.yaml [--output text|json|sarif]")
+	fmt.Fprintln(os.Stderr, "  margin lint  -f slo.yaml [--config .marginlint.yaml] [--output text|json|sarif] [--strict]")
+	fmt.Fprintln(os.Stderr, "  margin fmt   [-w|--check] slo.yaml ...")
+	fmt.Fprintln(os.Stderr, "  margin migrate -f slo.yaml [--to margin/v2] [-w]")
+	fmt.Fprintln(os.Stderr, "  margin schema [--api-version margin/v2] [--out schema/margin-v2.schema.json]")
 	fmt.Fprintln(os.Stderr, "  margin export terraform -f slo.yaml --out out/terraform")
 	fmt.Fprintln(os.Stderr, "  margin export monitoring-json -f slo.yaml --out out/monitoring-json")
 	fmt.Fprintln(os.Stderr, "  margin import --project my-gcp-project --service checkout-api --out out/import/checkout-api.yaml")
+	fmt.Fprintln(os.Stderr, "  margin forecast --project my-gcp-project --service checkout-api [--lookback 7d] [--horizon 7d]")
+	fmt.Fprintln(os.Stderr, "  margin recommend -f slo.yaml [--lookback 90d] [--target 90] [-w]")
+	fmt.Fprintln(os.Stderr, "  margin simulate -f slo.yaml --scenario step:2%:20m|--series series.json|--record series.json")
+	fmt.Fprintln(os.Stderr, "  margin gate   -f slo.yaml --policy policy.yaml [--output text|json]")
 	fmt.Fprintln(os.Stderr, "  margin report --inputs out/a/summary.json,out/b/summary.json --out out/report")
+	fmt.Fprintln(os.Stderr, "  margin report diff [--threshold 10] baseline/summary.json current/summary.json")
 	fmt.Fprintln(os.Stderr, "  margin services list --project my-gcp-project")
 	fmt.Fprintln(os.Stderr, "  margin explain burn-rate")
 	fmt.Fprintln(os.Stderr, "  margin delete  -f slo.yaml")
@@ -118,6 +170,7 @@ func runApply(args []string) error {
 	if err != nil {
 		return err
 	}
+	warnDeprecations(opts.file, specDoc)
 	if opts.dryRun {
 		planner.Render(os.Stdout, plan)
 		return nil
@@ -144,24 +197,55 @@ func runPlan(args []string) error {
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
-	plan, _, err := buildPlan(opts)
+	plan, specDoc, err := buildPlan(opts)
 	if err != nil {
 		return err
 	}
+	warnDeprecations(opts.file, specDoc)
 	planner.Render(os.Stdout, plan)
 	return nil
 }
 
 func runValidate(args []string) error {
 	fs, opts := baseFlags("validate", args)
+	output := fs.String("output", "text", "output format: text, json, or sarif")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
-	_, _, err := buildPlan(opts)
-	if err != nil {
+	if !includesFormat([]string{"text", "json", "sarif"}, *output) {
+		return fmt.Errorf("unknown --output %q (want text, json, or sarif)", *output)
+	}
+	if strings.TrimSpace(opts.file) == "" {
+		return errors.New("-f is required")
+	}
+	// Deprecations come from the loaded spec, so they are listed next to
+	// the validation errors of a v1 spec that is being fixed.
+	var problems spec.ValidationErrors
+	specDoc, err := spec.Load(opts.file)
+	if err == nil {
+		problems = specDoc.Deprecations()
+		_, err = planSpec(opts, specDoc)
+	}
+	var invalid spec.ValidationErrors
+	if err != nil && !errors.As(err, &invalid) {
 		return err
 	}
-	fmt.Fprintln(os.Stdout, "Spec is valid.")
+	problems = append(problems, invalid...)
+	if len(problems) > 0 {
+		if err := report.WriteDiagnostics(os.Stdout, problems, report.DiagnosticsOptions{
+			Format:      *output,
+			File:        opts.file,
+			ToolVersion: version,
+		}); err != nil {
+			return err
+		}
+	}
+	if problems.HasErrors() {
+		return exitError{code: 1, err: fmt.Errorf("spec has %d problem(s)", len(problems))}
+	}
+	if *output == "text" {
+		fmt.Fprintln(os.Stdout, "Spec is valid.")
+	}
 	return nil
 }
 
@@ -181,10 +265,11 @@ func runDelete(args []string) error {
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
-	plan, _, err := buildPlan(opts)
+	plan, specDoc, err := buildPlan(opts)
 	if err != nil {
 		return err
 	}
+	warnDeprecations(opts.file, specDoc)
 	if opts.dryRun {
 		fmt.Fprintf(os.Stdout, "Delete would remove %d SLOs, %d alerts, and 1 dashboard in project %s.\n", len(plan.SLOs), len(plan.Alerts), plan.Project)
 		return nil
@@ -205,29 +290,46 @@ func buildPlan(opts *commandOptions) (planner.Plan, spec.Spec, error) {
 	if strings.TrimSpace(opts.file) == "" {
 		return planner.Plan{}, spec.Spec{}, errors.New("-f is required")
 	}
-	labels, err := spec.ParseLabels(opts.labels)
+	specDoc, err := spec.Load(opts.file)
 	if err != nil {
 		return planner.Plan{}, spec.Spec{}, err
 	}
-	specDoc, err := spec.Load(opts.file)
+	plan, err := planSpec(opts, specDoc)
 	if err != nil {
 		return planner.Plan{}, spec.Spec{}, err
 	}
+	return plan, specDoc, nil
+}
+
+// planSpec validates a loaded spec against the command's options and
+// plans it.
+func planSpec(opts *commandOptions, specDoc spec.Spec) (planner.Plan, error) {
+	labels, err := spec.ParseLabels(opts.labels)
+	if err != nil {
+		return planner.Plan{}, err
+	}
 	if err := specDoc.Validate(); err != nil {
-		return planner.Plan{}, spec.Spec{}, err
+		return planner.Plan{}, err
 	}
 	if strings.TrimSpace(opts.project) == "" && strings.TrimSpace(specDoc.Metadata.Project) == "" {
-		return planner.Plan{}, spec.Spec{}, errors.New("project is required via --project or metadata.project")
+		return planner.Plan{}, errors.New("project is required via --project or metadata.project")
 	}
 	if opts.project != "" && specDoc.Metadata.Project != "" && opts.project != specDoc.Metadata.Project {
-		return planner.Plan{}, spec.Spec{}, fmt.Errorf("--project %q does not match metadata.project %q", opts.project, specDoc.Metadata.Project)
+		return planner.Plan{}, fmt.Errorf("--project %q does not match metadata.project %q", opts.project, specDoc.Metadata.Project)
 	}
 
-	plan := planner.Build(specDoc, planner.Options{
+	return planner.Build(specDoc, planner.Options{
 		ProjectOverride: opts.project,
 		Labels:          labels,
-	})
-	return plan, specDoc, nil
+	}), nil
+}
+
+// warnDeprecations prints a one-line reminder for specs that still use a
+// deprecated apiVersion; margin validate lists the individual fields.
+func warnDeprecations(file string, specDoc spec.Spec) {
+	if deprecated := specDoc.Deprecations(); len(deprecated) > 0 {
+		fmt.Fprintf(os.Stderr, "warning: %s uses deprecated apiVersion %s; run `margin migrate -f %s -w`\n", file, specDoc.APIVersion, file)
+	}
 }
 
 func fail(err error) {
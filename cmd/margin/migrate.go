@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bayneri/margin/internal/spec"
+)
+
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	file := fs.String("f", "", "path to SLO spec (or pass files as arguments)")
+	to := fs.String("to", spec.APIVersionLatest, "target apiVersion")
+	write := fs.Bool("w", false, "write the result back to the file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	files := fs.Args()
+	if *file != "" {
+		files = append([]string{*file}, files...)
+	}
+	if len(files) == 0 {
+		return errors.New("-f or at least one spec file is required")
+	}
+	if len(files) > 1 && !*write {
+		return errors.New("-w is required when migrating more than one file")
+	}
+
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read spec: %w", err)
+		}
+		migrated, err := spec.Migrate(data, *to)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if !*write {
+			_, err := os.Stdout.Write(migrated)
+			return err
+		}
+		if bytes.Equal(data, migrated) {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, migrated, info.Mode().Perm()); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "Migrated %s to %s\n", path, *to)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bayneri/margin/internal/analyze"
+	"github.com/bayneri/margin/internal/report"
+	"github.com/bayneri/margin/internal/spec"
+)
+
+func runRecommend(args []string) error {
+	fs := flag.NewFlagSet("recommend", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	file := fs.String("f", "", "path to SLO spec")
+	project := fs.String("project", "", "GCP project ID (overrides metadata.project)")
+	lookback := fs.String("lookback", "90d", "history to evaluate candidates against")
+	step := fs.String("step", "1h", "resolution of the history")
+	target := fs.Float64("target", 90, "percent of periods a recommended objective must have been met in")
+	out := fs.String("out", "", "output directory")
+	format := fs.String("format", "md,json", "comma-separated output formats: md, json")
+	write := fs.Bool("w", false, "write the recommended objectives and thresholds back to the spec")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *target <= 0 || *target > 100 {
+		return fmt.Errorf("--target must be between 0 and 100")
+	}
+	lookbackDuration, err := parseForecastWindow("lookback", *lookback)
+	if err != nil {
+		return err
+	}
+	stepDuration, err := parseForecastWindow("step", *step)
+	if err != nil {
+		return err
+	}
+	if stepDuration < 0 || stepDuration > lookbackDuration {
+		return errors.New("--step must be no longer than --lookback")
+	}
+
+	plan, _, err := buildPlan(&commandOptions{file: *file, project: *project})
+	if err != nil {
+		return err
+	}
+
+	reader, err := analyze.NewGCPReader(context.Background())
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	result, err := analyze.Recommend(context.Background(), reader, plan, analyze.RecommendOptions{
+		Lookback:      lookbackDuration,
+		Step:          stepDuration,
+		TargetPercent: *target,
+	})
+	if err != nil {
+		return err
+	}
+
+	outDir := *out
+	if outDir == "" {
+		outDir = filepath.Join("out", "margin-recommend", fmt.Sprintf("%s-%s", result.GeneratedAt.Format("20060102-150405"), result.Service))
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
+	}
+	formats := parseFormat(*format)
+	if includesFormat(formats, "md") {
+		if err := report.WriteRecommendMarkdown(filepath.Join(outDir, "recommend.md"), result); err != nil {
+			return err
+		}
+	}
+	if includesFormat(formats, "json") {
+		if err := report.WriteRecommendJSON(filepath.Join(outDir, "recommend.json"), result); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stdout, "Wrote recommendations to %s\n", outDir)
+
+	patches := map[string]spec.SLOPatch{}
+	for _, slo := range result.SLOs {
+		patch := spec.SLOPatch{}
+		if slo.RecommendedObjective != nil && *slo.RecommendedObjective != slo.Objective {
+			patch.Objective = *slo.RecommendedObjective
+		}
+		if slo.RecommendedThreshold != "" && slo.RecommendedThreshold != slo.Threshold {
+			patch.Threshold = slo.RecommendedThreshold
+		}
+		if patch != (spec.SLOPatch{}) {
+			patches[slo.Name] = patch
+		}
+	}
+	if len(patches) == 0 {
+		fmt.Fprintln(os.Stdout, "The spec already matches the recommendations.")
+	} else {
+		data, err := os.ReadFile(*file)
+		if err != nil {
+			return fmt.Errorf("read spec: %w", err)
+		}
+		patched, err := spec.PatchSLOs(data, patches)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(outDir, filepath.Base(*file))
+		mode := os.FileMode(0644)
+		if *write {
+			path = *file
+			if info, err := os.Stat(path); err == nil {
+				mode = info.Mode().Perm()
+			}
+		}
+		if !bytes.Equal(data, patched) {
+			if err := os.WriteFile(path, patched, mode); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stdout, "Wrote recommended spec to %s (%d SLO(s) changed)\n", path, len(patches))
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		fmt.Fprintf(os.Stderr, "Partial recommendation: %d SLO(s) could not be evaluated. See %s\n", len(result.Errors), filepath.Join(outDir, "recommend.md"))
+	}
+	return nil
+}
@@ -7,15 +7,23 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/bayneri/margin/internal/analyze"
 	"github.com/bayneri/margin/internal/report"
 )
 
 func runReport(args []string) error {
+	if len(args) > 0 && args[0] == "diff" {
+		return runReportDiff(args[1:])
+	}
 	fs := flag.NewFlagSet("report", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 	inputs := fs.String("inputs", "", "comma-separated list of analyze summary.json files")
 	outDir := fs.String("out", "out/report", "output directory")
+	format := fs.String("format", "md,json", "comma-separated output formats: md, json, html")
+	charts := fs.String("charts", "svg", "comma-separated chart formats for SLOs with a burndown (svg, png) or none")
+	exclude := fs.String("exclude", "", "comma-separated intervals to leave out of the adjusted budget, as start/end or start/end=reason (RFC3339)")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -23,22 +31,33 @@ func runReport(args []string) error {
 		return errors.New("--inputs is required")
 	}
 
+	chartFormats, err := parseChartFormats(*charts)
+	if err != nil {
+		return err
+	}
+	exclusions, err := analyze.ParseExclusions(splitCSV(*exclude))
+	if err != nil {
+		return fmt.Errorf("invalid --exclude: %w", err)
+	}
+
 	paths := splitCSV(*inputs)
 	results, err := report.ReadResults(paths)
 	if err != nil {
 		return err
 	}
+	// Summaries carry no queries to rerun, so added exclusions are applied
+	// to their burndown steps.
+	if len(exclusions) > 0 {
+		for i := range results {
+			results[i] = analyze.ExcludeFromResult(results[i], exclusions)
+		}
+	}
 	agg, err := report.Aggregate(results, paths)
 	if err != nil {
 		return err
 	}
 
-	jsonPath := filepath.Join(*outDir, "summary.json")
-	if err := report.WriteAggregateJSON(jsonPath, agg); err != nil {
-		return err
-	}
-	mdPath := filepath.Join(*outDir, "summary.md")
-	if err := report.WriteAggregateMarkdown(mdPath, agg); err != nil {
+	if err := writeAggregateReport(*outDir, agg, parseFormat(*format), chartFormats); err != nil {
 		return err
 	}
 	fmt.Fprintf(os.Stdout, "Wrote report to %s\n", *outDir)
@@ -48,6 +67,91 @@ func runReport(args []string) error {
 	return nil
 }
 
+// runReportDiff compares two analyze or report summaries SLO by SLO.
+func runReportDiff(args []string) error {
+	fs := flag.NewFlagSet("report diff", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	outDir := fs.String("out", "out/report-diff", "output directory")
+	format := fs.String("format", "md,json", "comma-separated output formats: md, json")
+	threshold := fs.Float64("threshold", report.DefaultRegressionThreshold, "points of budget consumed an SLO may gain before it counts as a regression")
+	timezone := fs.String("timezone", "UTC", "IANA timezone for reports")
+	failOnRegression := fs.Bool("fail-on-regression", false, "exit non-zero if any SLO regressed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New("usage: margin report diff [flags] baseline/summary.json current/summary.json")
+	}
+	if *threshold < 0 {
+		return fmt.Errorf("invalid --threshold %v", *threshold)
+	}
+	loc, err := time.LoadLocation(*timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+
+	baseline, err := report.ReadDiffInput(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	current, err := report.ReadDiffInput(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	result := report.Diff(baseline, current, *threshold)
+
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
+	}
+	formats := parseFormat(*format)
+	if includesFormat(formats, "md") {
+		if err := report.WriteDiffMarkdown(filepath.Join(*outDir, "diff.md"), result, loc); err != nil {
+			return err
+		}
+	}
+	if includesFormat(formats, "json") {
+		if err := report.WriteDiffJSON(filepath.Join(*outDir, "diff.json"), result); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stdout, "Wrote diff to %s (status: %s)\n", *outDir, result.Status)
+	if n := result.Regressions(); n > 0 && *failOnRegression {
+		return exitError{code: 1, err: fmt.Errorf("%d SLO(s) regressed", n)}
+	}
+	return nil
+}
+
+// writeAggregateReport writes an aggregated report, with the burndown
+// charts of every service, into outDir.
+func writeAggregateReport(outDir string, agg report.AggregateResult, formats, chartFormats []string) error {
+	if includesFormat(formats, "json") {
+		if err := report.WriteAggregateJSON(filepath.Join(outDir, "summary.json"), agg); err != nil {
+			return err
+		}
+	}
+	if includesFormat(formats, "html") {
+		if err := report.WriteAggregateHTML(filepath.Join(outDir, "summary.html"), agg, report.AggregateOptions{}); err != nil {
+			return err
+		}
+	}
+	if includesFormat(formats, "md") {
+		chartPaths := map[string]string{}
+		for _, service := range agg.Services {
+			written, err := report.WriteCharts(outDir, service.Service, service.SLOs, chartFormats, time.UTC)
+			if err != nil {
+				return err
+			}
+			for key, path := range written {
+				chartPaths[key] = path
+			}
+		}
+		if err := report.WriteAggregateMarkdown(filepath.Join(outDir, "summary.md"), agg, report.AggregateOptions{Charts: chartPaths}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func splitCSV(input string) []string {
 	parts := strings.Split(input, ",")
 	var out []string
@@ -59,3 +163,16 @@ func splitCSV(input string) []string {
 	}
 	return out
 }
+
+func parseChartFormats(input string) ([]string, error) {
+	if strings.TrimSpace(input) == "none" {
+		return nil, nil
+	}
+	formats := parseFormat(input)
+	for _, format := range formats {
+		if !includesFormat(report.ChartFormats, format) {
+			return nil, fmt.Errorf("unknown chart format %q (want %s, or none)", format, strings.Join(report.ChartFormats, ", "))
+		}
+	}
+	return formats, nil
+}
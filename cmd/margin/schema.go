@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bayneri/margin/internal/spec"
+)
+
+func runSchema(args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	out := fs.String("out", "", "write the schema to this file instead of stdout")
+	apiVersion := fs.String("api-version", spec.APIVersionLatest, "apiVersion to describe: "+strings.Join(spec.SupportedAPIVersions(), ", "))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	data, err := spec.MarshalJSONSchema(*apiVersion)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(*out), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "Wrote %s\n", *out)
+	return nil
+}
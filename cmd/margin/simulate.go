@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/bayneri/margin/internal/analyze"
+	"github.com/bayneri/margin/internal/report"
+)
+
+func runSimulate(args []string) error {
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	file := fs.String("f", "", "path to SLO spec")
+	project := fs.String("project", "", "GCP project ID (overrides metadata.project)")
+	scenario := fs.String("scenario", "", "synthetic series such as step:2%:20m or ramp:5%:2h")
+	baseline := fs.String("baseline", "0%", "bad fraction around the scenario")
+	series := fs.String("series", "", "replay a series file written by --record")
+	record := fs.String("record", "", "record the spec's SLIs from metrics to this file and replay them")
+	last := fs.String("last", "7d", "history to record")
+	step := fs.String("step", "1m", "resolution of the scenario or recording")
+	only := fs.String("only", "", "regex to filter SLO display names or ids")
+	out := fs.String("out", "", "output directory")
+	format := fs.String("format", "md,json", "comma-separated output formats: md, json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	sources := 0
+	for _, value := range []string{*scenario, *series, *record} {
+		if value != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return errors.New("exactly one of --scenario, --series, or --record is required")
+	}
+	stepDuration, err := parseForecastWindow("step", *step)
+	if err != nil {
+		return err
+	}
+	if stepDuration < time.Minute {
+		return errors.New("--step must be at least 1m")
+	}
+	var onlyRe *regexp.Regexp
+	if *only != "" {
+		onlyRe, err = regexp.Compile(*only)
+		if err != nil {
+			return fmt.Errorf("invalid --only regex: %w", err)
+		}
+	}
+
+	plan, _, err := buildPlan(&commandOptions{file: *file, project: *project})
+	if err != nil {
+		return err
+	}
+
+	opts := analyze.SimulateOptions{Step: stepDuration, Only: onlyRe}
+	switch {
+	case *scenario != "":
+		parsed, err := analyze.ParseScenario(*scenario)
+		if err != nil {
+			return err
+		}
+		opts.Scenario = &parsed
+		if opts.Baseline, err = analyze.ParsePercent(*baseline); err != nil {
+			return fmt.Errorf("invalid --baseline: %w", err)
+		}
+		if opts.Baseline >= parsed.Level {
+			return errors.New("--baseline must be below the scenario's level")
+		}
+	case *series != "":
+		loaded, err := analyze.LoadSeries(*series)
+		if err != nil {
+			return err
+		}
+		opts.Series = &loaded
+	default:
+		lastDuration, err := parseForecastWindow("last", *last)
+		if err != nil {
+			return err
+		}
+		if stepDuration > lastDuration {
+			return errors.New("--step must be no longer than --last")
+		}
+		reader, err := analyze.NewGCPReader(context.Background())
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		end := time.Now().UTC().Truncate(stepDuration)
+		recorded, err := analyze.RecordSeries(context.Background(), reader, plan, end.Add(-lastDuration), end, stepDuration)
+		if err != nil {
+			return err
+		}
+		if dir := filepath.Dir(*record); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("create output directory: %w", err)
+			}
+		}
+		if err := report.WriteJSON(*record, recorded); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "Recorded %s of SLIs to %s\n", *last, *record)
+		opts.Series = &recorded
+	}
+
+	result, err := analyze.Simulate(plan, opts)
+	if err != nil {
+		return err
+	}
+
+	outDir := *out
+	if outDir == "" {
+		outDir = filepath.Join("out", "margin-simulate", fmt.Sprintf("%s-%s", result.GeneratedAt.Format("20060102-150405"), result.Service))
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
+	}
+	formats := parseFormat(*format)
+	if includesFormat(formats, "md") {
+		if err := report.WriteSimulateMarkdown(filepath.Join(outDir, "simulate.md"), result); err != nil {
+			return err
+		}
+	}
+	if includesFormat(formats, "json") {
+		if err := report.WriteSimulateJSON(filepath.Join(outDir, "simulate.json"), result); err != nil {
+			return err
+		}
+	}
+	firings := 0
+	for _, slo := range result.SLOs {
+		for _, policy := range slo.Policies {
+			firings += len(policy.Firings)
+		}
+	}
+	fmt.Fprintf(os.Stdout, "Wrote simulation to %s (%d firing(s))\n", outDir, firings)
+	if len(result.Errors) > 0 {
+		fmt.Fprintf(os.Stderr, "Partial simulation: %d SLO(s) could not be simulated. See %s\n", len(result.Errors), filepath.Join(outDir, "simulate.md"))
+	}
+	return nil
+}
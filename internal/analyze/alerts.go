@@ -0,0 +1,126 @@
+package analyze
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"google.golang.org/api/iterator"
+	monitoringrest "google.golang.org/api/monitoring/v3"
+
+	"github.com/bayneri/margin/internal/spec"
+)
+
+// burnRateFilter matches the condition filters margin writes, for example
+// select_slo_burn_rate("projects/p/services/s/serviceLevelObjectives/o", "1h").
+var burnRateFilter = regexp.MustCompile(`select_slo_burn_rate\(\s*"([^"]+)"\s*,\s*"([^"]+)"\s*\)`)
+
+// incidentLookback bounds how far before the window ListIncidents pages
+// back looking for incidents that were still open when it started.
+const incidentLookback = 7 * 24 * time.Hour
+
+var errStopPaging = errors.New("stop paging")
+
+func (r *GCPReader) ListBurnRateAlerts(ctx context.Context, project, serviceID string) ([]BurnRateAlert, error) {
+	iter := r.alertClient.ListAlertPolicies(ctx, &monitoringpb.ListAlertPoliciesRequest{
+		Name:   fmt.Sprintf("projects/%s", project),
+		Filter: `user_labels."managed-by" = "margin"`,
+	})
+	var out []BurnRateAlert
+	for {
+		policy, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		alert, ok := toBurnRateAlert(policy)
+		if !ok {
+			continue
+		}
+		if policy.GetUserLabels()["service-name"] != serviceID && !strings.Contains(alert.SLOName, "/services/"+serviceID+"/") {
+			continue
+		}
+		out = append(out, alert)
+	}
+	return out, nil
+}
+
+func toBurnRateAlert(policy *monitoringpb.AlertPolicy) (BurnRateAlert, bool) {
+	alert := BurnRateAlert{Policy: policy.GetName(), DisplayName: policy.GetDisplayName()}
+	for _, condition := range policy.GetConditions() {
+		threshold := condition.GetConditionThreshold()
+		if threshold == nil {
+			continue
+		}
+		match := burnRateFilter.FindStringSubmatch(threshold.GetFilter())
+		if match == nil {
+			continue
+		}
+		window, err := spec.ParseWindow(match[2])
+		if err != nil {
+			continue
+		}
+		alert.SLOName = match[1]
+		alert.BurnRate = threshold.GetThresholdValue()
+		alert.Conditions = append(alert.Conditions, AlertCondition{Window: window, Duration: threshold.GetDuration().AsDuration()})
+	}
+	return alert, len(alert.Conditions) > 0
+}
+
+// ListIncidents pages through the project's alerts newest first. The Alerts
+// API only exists in the REST client.
+func (r *GCPReader) ListIncidents(ctx context.Context, project string, start, end time.Time) ([]Incident, error) {
+	var out []Incident
+	err := r.alertsService.Projects.Alerts.List(fmt.Sprintf("projects/%s", project)).
+		OrderBy("open_time desc").
+		Pages(ctx, func(page *monitoringrest.ListAlertsResponse) error {
+			for _, alert := range page.Alerts {
+				open, err := time.Parse(time.RFC3339Nano, alert.OpenTime)
+				if err != nil {
+					continue
+				}
+				if open.Before(start.Add(-incidentLookback)) {
+					return errStopPaging
+				}
+				if alert.Policy == nil || alert.Policy.UserLabels["managed-by"] != "margin" || !open.Before(end) {
+					continue
+				}
+				incident := Incident{Name: alert.Name, Policy: alert.Policy.Name, State: alert.State, OpenTime: open.UTC()}
+				if alert.CloseTime != "" {
+					closed, err := time.Parse(time.RFC3339Nano, alert.CloseTime)
+					if err == nil {
+						if !closed.After(start) {
+							continue
+						}
+						closed = closed.UTC()
+						incident.CloseTime = &closed
+					}
+				}
+				out = append(out, incident)
+			}
+			return nil
+		})
+	if err != nil && !errors.Is(err, errStopPaging) {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *GCPReader) FetchBurnRate(ctx context.Context, project, sloName string, lookback time.Duration, start, end time.Time, step time.Duration) ([]Sample, error) {
+	filter := fmt.Sprintf("select_slo_burn_rate(%q, %q)", sloName, fmt.Sprintf("%ds", int64(lookback.Seconds())))
+	points, err := r.listPoints(ctx, project, filter, start, end, step, monitoringpb.Aggregation_ALIGN_MEAN, monitoringpb.Aggregation_REDUCE_MAX)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Sample, 0, len(points))
+	for at, value := range points {
+		out = append(out, Sample{End: at, BurnRate: value})
+	}
+	return out, nil
+}
@@ -30,21 +30,76 @@ type Options struct {
 	Timezone *time.Location
 	MaxSLOs  int
 	Only     *regexp.Regexp
+	// Burndown fetches a per-step series for each SLO. Step 0 picks one
+	// from the window length; see ResolveStep.
+	Burndown bool
+	Step     time.Duration
+	// Period also queries the SLO's own rolling or calendar period up to
+	// Now to report the period-to-date budget.
+	Period bool
+	// Now overrides the current time; zero means time.Now.
+	Now time.Time
+	// Breakdown groups each SLO's bad events by these label keys.
+	Breakdown []string
+	// Alerts, when set, correlates the window with margin's burn-rate
+	// alerts and their incidents.
+	Alerts AlertReader
+	// Concurrency bounds how many SLOs are evaluated at once; 0 means
+	// DefaultConcurrency.
+	Concurrency int
+	// SLOTimeout bounds the queries of each SLO; 0 means no limit. An SLO
+	// whose compliance query times out is reported with StatusError.
+	SLOTimeout time.Duration
+	// Exclusions are left out of each SLO's adjusted budget, such as planned
+	// maintenance. The raw budget still covers the whole window.
+	Exclusions []Exclusion
+	// Changes are deploys, config pushes, and flag flips to overlay on the
+	// burndown and match with burn spikes.
+	Changes []ChangeEvent
+	// Revisions, when set, adds the revisions of a Cloud Run service to
+	// Changes as deploys.
+	Revisions *RevisionSource
+	// ChangeLag is how long before a burn spike a change still counts as
+	// preceding it; 0 means DefaultChangeLag.
+	ChangeLag time.Duration
 }
 
 type Reader interface {
 	ListServiceLevelObjectives(ctx context.Context, serviceName string, max int) ([]SLO, error)
 	FetchCompliance(ctx context.Context, project string, sloName string, start, end time.Time) (float64, error)
+	FetchSeries(ctx context.Context, project string, sloName string, start, end time.Time, step time.Duration) ([]Sample, error)
+	FetchBreakdown(ctx context.Context, project string, slo SLO, labels []string, start, end time.Time) ([]Group, error)
+}
+
+// Sample is one step of an SLO series. End marks the end of the step and
+// BurnRate is 0 when Monitoring returned no burn-rate point for it.
+type Sample struct {
+	End        time.Time
+	Compliance float64
+	BurnRate   float64
 }
 
 type SLO struct {
-	Name        string
-	DisplayName string
-	Goal        float64
-	RollingDays int64
-	Calendar    *string
-	SLIType     string
-	SLIMethod   string
+	Name          string
+	DisplayName   string
+	Goal          float64
+	RollingDays   int64
+	RollingPeriod time.Duration
+	Calendar      *string
+	SLIType       string
+	SLIMethod     string
+	// WindowPeriod is the window length of windows-based SLIs.
+	WindowPeriod time.Duration
+	// SLI filters of request-based SLOs, used by breakdowns.
+	GoodFilter         string
+	BadFilter          string
+	TotalFilter        string
+	DistributionFilter string
+	RangeMin           float64
+	RangeMax           float64
+	// FromSpec marks SLOs built from a spec by SpecReader rather than read
+	// from Monitoring.
+	FromSpec bool
 }
 
 func Run(ctx context.Context, reader Reader, opts Options) (Result, Sources, string, error) {
@@ -59,7 +114,12 @@ func Run(ctx context.Context, reader Reader, opts Options) (Result, Sources, str
 	if err != nil {
 		return Result{}, Sources{}, "", err
 	}
-	start, end, err := ResolveWindow(opts.Start, opts.End, opts.Last, time.Now().UTC())
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
+	start, end, err := ResolveWindow(opts.Start, opts.End, opts.Last, now)
 	if err != nil {
 		return Result{}, Sources{}, "", err
 	}
@@ -98,72 +158,56 @@ func Run(ctx context.Context, reader Reader, opts Options) (Result, Sources, str
 			End:             end,
 			DurationSeconds: int64(end.Sub(start).Seconds()),
 		},
+		Exclusions: ClipExclusions(opts.Exclusions, start, end),
 	}
 
 	var errorsList []string
-	for _, slo := range slos {
-		item := SLOResult{
-			SLOResourceName:   slo.Name,
-			SLOID:             extractSLOID(slo.Name),
-			DisplayName:       slo.DisplayName,
-			Goal:              round4(slo.Goal),
-			RollingPeriodDays: slo.RollingDays,
-			CalendarPeriod:    slo.Calendar,
+	var alerts []BurnRateAlert
+	var incidents []Incident
+	detecting := false
+	detectionNote := ""
+	if opts.Alerts != nil {
+		alerts, err = opts.Alerts.ListBurnRateAlerts(ctx, opts.Project, serviceID)
+		if err == nil {
+			incidents, err = opts.Alerts.ListIncidents(ctx, opts.Project, start, end)
 		}
-
-		supported, supportNote := supportedSLO(slo)
-		if !supported {
-			item.Status = StatusPartial
-			item.Error = supportNote
-			if opts.Explain {
-				item.Explain = &Explain{
-					Formula: budgetFormula(),
-					Notes:   []string{supportNote},
-				}
-			}
-			result.SLOs = append(result.SLOs, item)
-			errorsList = append(errorsList, fmt.Sprintf("%s: %s", slo.DisplayName, supportNote))
-			continue
-		}
-
-		compliance, err := reader.FetchCompliance(ctx, opts.Project, slo.Name, start, end)
 		if err != nil {
-			item.Status = StatusError
-			item.Error = err.Error()
-			result.SLOs = append(result.SLOs, item)
-			errorsList = append(errorsList, fmt.Sprintf("%s: %s", slo.DisplayName, err.Error()))
-			continue
+			detectionNote = fmt.Sprintf("alert incidents unavailable: %s", err.Error())
+		} else {
+			detecting = true
 		}
-
-		allowedBad, bad, consumed, notes := ComputeBudget(slo.Goal, compliance)
-		item.Compliance = round4(compliance)
-		item.BadFraction = round4(bad)
-		item.AllowedBadFraction = round4(allowedBad)
-		item.ConsumedPercentOfBudget = round4(consumed)
-		item.Status = StatusOK
-
-		if allowedBad <= 0 {
-			item.Status = StatusPartial
-			note := "goal is 100%; cannot compute allowed bad fraction"
-			item.Error = note
-			notes = append(notes, note)
-			errorsList = append(errorsList, fmt.Sprintf("%s: %s", slo.DisplayName, note))
-		}
-		if allowedBad > 0 && consumed > 100 {
-			item.Status = StatusBreach
-			note := "error budget exceeded in window"
-			item.Error = note
-			notes = append(notes, note)
-		}
-
-		if opts.Explain {
-			item.Explain = &Explain{
-				Formula: budgetFormula(),
-				Notes:   notes,
-			}
-		}
-
-		result.SLOs = append(result.SLOs, item)
+	}
+	if opts.ChangeLag <= 0 {
+		opts.ChangeLag = DefaultChangeLag
+	}
+	changes, changeErr := collectChanges(ctx, opts, start, end)
+	if changeErr != nil {
+		errorsList = append(errorsList, fmt.Sprintf("cloud run revisions unavailable: %s", changeErr.Error()))
+	}
+	result.Changes = changes
+	if len(changes) > 0 {
+		result.ChangeLagSeconds = int64(opts.ChangeLag.Seconds())
+	}
+	run := sloRun{
+		reader:        reader,
+		opts:          opts,
+		start:         start,
+		end:           end,
+		now:           now,
+		alerts:        alerts,
+		incidents:     incidents,
+		detecting:     detecting,
+		detectionNote: detectionNote,
+		exclusions:    result.Exclusions,
+		changes:       changes,
+	}
+	items, sloErrors, err := run.evaluateAll(ctx, slos)
+	if err != nil {
+		return Result{}, Sources{}, outDir, err
+	}
+	result.SLOs = items
+	for _, list := range sloErrors {
+		errorsList = append(errorsList, list...)
 	}
 
 	result.Errors = errorsList
@@ -183,6 +227,82 @@ func Run(ctx context.Context, reader Reader, opts Options) (Result, Sources, str
 	return result, sources, outDir, nil
 }
 
+// fetchPeriod measures the compliance period that contains now, plus the
+// trailing BurnRateLookback for the current burn rate.
+func fetchPeriod(ctx context.Context, reader Reader, project string, slo SLO, start, end time.Time, windowBad float64, now time.Time) (Period, error) {
+	periodStart, _, err := PeriodWindow(slo, now)
+	if err != nil {
+		return Period{}, err
+	}
+	compliance, err := reader.FetchCompliance(ctx, project, slo.Name, periodStart, now)
+	if err != nil {
+		return Period{}, err
+	}
+	current, err := reader.FetchCompliance(ctx, project, slo.Name, now.Add(-BurnRateLookback), now)
+	if err != nil {
+		return Period{}, err
+	}
+	overlap := time.Duration(0)
+	if from, to := maxTime(start, periodStart), minTime(end, now); to.After(from) {
+		overlap = to.Sub(from)
+	}
+	return ComputePeriod(PeriodInput{
+		Goal:              slo.Goal,
+		Start:             periodStart,
+		Now:               now,
+		Length:            PeriodLength(slo, periodStart),
+		Compliance:        compliance,
+		CurrentCompliance: current,
+		WindowBad:         windowBad,
+		WindowOverlap:     overlap,
+	}), nil
+}
+
+// collectChanges returns Options.Changes and the revisions of
+// Options.Revisions from the change lag before start to end, oldest first.
+// Revisions that cannot be read are reported without dropping the rest.
+func collectChanges(ctx context.Context, opts Options, start, end time.Time) ([]ChangeEvent, error) {
+	from := start.Add(-opts.ChangeLag)
+	var out []ChangeEvent
+	for _, change := range opts.Changes {
+		if !change.Time.Before(from) && !change.Time.After(end) {
+			out = append(out, change)
+		}
+	}
+	var err error
+	if rev := opts.Revisions; rev != nil {
+		var revisions []ChangeEvent
+		revisions, err = rev.Reader.ListRevisions(ctx, opts.Project, rev.Location, rev.Service, from, end)
+		out = append(out, revisions...)
+	}
+	sortChanges(out)
+	return out, err
+}
+
+func alertsFor(alerts []BurnRateAlert, sloName string) []BurnRateAlert {
+	var out []BurnRateAlert
+	for _, alert := range alerts {
+		if alert.SLOName == sloName {
+			out = append(out, alert)
+		}
+	}
+	return out
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
 func overallStatus(slos []SLOResult, errorsList []string) string {
 	status := StatusOK
 	for _, slo := range slos {
@@ -199,20 +319,65 @@ func overallStatus(slos []SLOResult, errorsList []string) string {
 	return status
 }
 
+// SLI types as reported by Cloud Monitoring, and the methods analyze can
+// evaluate for each.
+const (
+	SLITypeRequestBased = "request-based"
+	SLITypeWindowsBased = "windows-based"
+	SLITypeBasic        = "basic-sli"
+)
+
+var supportedMethods = map[string][]string{
+	SLITypeRequestBased: {"good-total-ratio", "distribution-cut"},
+	SLITypeWindowsBased: {"good-bad-metric-filter", "good-total-ratio-threshold", "metric-mean-in-range", "metric-sum-in-range"},
+	SLITypeBasic:        {"availability", "latency"},
+}
+
 func supportedSLO(slo SLO) (bool, string) {
-	if slo.SLIType != "request-based" {
+	methods, ok := supportedMethods[slo.SLIType]
+	if !ok {
 		return false, fmt.Sprintf("unsupported SLI type %q", slo.SLIType)
 	}
-	if slo.SLIMethod != "good-total-ratio" && slo.SLIMethod != "distribution-cut" {
+	supported := false
+	for _, method := range methods {
+		if slo.SLIMethod == method {
+			supported = true
+		}
+	}
+	if !supported {
 		return false, fmt.Sprintf("unsupported SLI method %q", slo.SLIMethod)
 	}
+	if slo.SLIType == SLITypeWindowsBased && slo.WindowPeriod <= 0 {
+		return false, "windows-based SLI has no window period"
+	}
 	return true, ""
 }
 
-func budgetFormula() string {
+// budgetFormula describes the budget math for the SLI kind. Request-based
+// and basic SLIs count requests; windows-based SLIs count windows.
+func budgetFormula(slo SLO) string {
+	if slo.SLIType == SLITypeWindowsBased {
+		return "windows = duration / windowPeriod; badWindows = (1 - compliance) * windows; allowedBadWindows = (1 - goal) * windows; consumedPercent = (badWindows / allowedBadWindows) * 100"
+	}
 	return "allowedBad = 1 - goal; bad = 1 - compliance; consumedPercent = (bad / allowedBad) * 100"
 }
 
+// sliNote explains what a bad event is for the SLI kind.
+func sliNote(slo SLO) string {
+	switch {
+	case slo.FromSpec:
+		return "computed from the spec's SLI metrics, not from an SLO in Monitoring"
+	case slo.SLIType == SLITypeWindowsBased:
+		return fmt.Sprintf("windows-based SLI (%s): each %s window is good or bad; compliance is the fraction of good windows", slo.SLIMethod, slo.WindowPeriod)
+	case slo.SLIType == SLITypeBasic && slo.SLIMethod == "latency":
+		return "basic latency SLI: requests slower than the threshold are bad"
+	case slo.SLIType == SLITypeBasic:
+		return "basic availability SLI: failed requests are bad"
+	default:
+		return ""
+	}
+}
+
 func filterSLOs(slos []SLO, re *regexp.Regexp) []SLO {
 	if re == nil {
 		return slos
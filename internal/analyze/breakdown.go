@@ -0,0 +1,99 @@
+package analyze
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxContributors caps the groups kept per SLO; the rest are counted in
+// Breakdown.OmittedGroups.
+const maxContributors = 10
+
+// Group is the good and total event counts of one combination of breakdown
+// label values over the window, or over the step ending at End.
+type Group struct {
+	Labels map[string]string
+	End    time.Time
+	Good   float64
+	Total  float64
+}
+
+// Breakdown ranks the label values that contributed most bad events.
+type Breakdown struct {
+	Labels        []string      `json:"labels"`
+	Contributors  []Contributor `json:"contributors"`
+	OmittedGroups int           `json:"omittedGroups,omitempty"`
+}
+
+// Contributor is one group's share of the window. ConsumedPercentOfBudget
+// counts the group's bad events against the budget of all events, so the
+// contributors add up to the SLO's event-weighted consumption.
+type Contributor struct {
+	Labels                  map[string]string `json:"labels"`
+	TotalEvents             float64           `json:"totalEvents"`
+	BadEvents               float64           `json:"badEvents"`
+	BadFraction             float64           `json:"badFraction"`
+	ShareOfBadPercent       float64           `json:"shareOfBadPercent"`
+	ConsumedPercentOfBudget float64           `json:"consumedPercentOfBudget"`
+}
+
+// ParseBreakdown validates a comma-separated list of label keys such as
+// metric.label.response_code,resource.label.revision_name.
+func ParseBreakdown(input string) ([]string, error) {
+	var labels []string
+	for _, part := range strings.Split(input, ",") {
+		label := strings.TrimSpace(part)
+		if label == "" {
+			continue
+		}
+		if !strings.HasPrefix(label, "metric.label.") && !strings.HasPrefix(label, "resource.label.") {
+			return nil, fmt.Errorf("invalid --breakdown label %q (want metric.label.<key> or resource.label.<key>)", label)
+		}
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
+
+// BuildBreakdown ranks groups by bad events, then by bad fraction.
+func BuildBreakdown(groups []Group, labels []string, allowedBad float64) Breakdown {
+	var total, bad float64
+	for _, group := range groups {
+		total += group.Total
+		bad += math.Max(0, group.Total-group.Good)
+	}
+	out := Breakdown{Labels: labels}
+	for _, group := range groups {
+		groupBad := math.Max(0, group.Total-group.Good)
+		if groupBad == 0 {
+			continue
+		}
+		contributor := Contributor{
+			Labels:      group.Labels,
+			TotalEvents: group.Total,
+			BadEvents:   groupBad,
+			BadFraction: round4(groupBad / group.Total),
+		}
+		if bad > 0 {
+			contributor.ShareOfBadPercent = round4(groupBad / bad * 100)
+		}
+		if total > 0 && allowedBad > 0 {
+			contributor.ConsumedPercentOfBudget = round4(groupBad / total / allowedBad * 100)
+		}
+		out.Contributors = append(out.Contributors, contributor)
+	}
+	sort.SliceStable(out.Contributors, func(i, j int) bool {
+		a, b := out.Contributors[i], out.Contributors[j]
+		if a.BadEvents != b.BadEvents {
+			return a.BadEvents > b.BadEvents
+		}
+		return a.BadFraction > b.BadFraction
+	})
+	if len(out.Contributors) > maxContributors {
+		out.OmittedGroups = len(out.Contributors) - maxContributors
+		out.Contributors = out.Contributors[:maxContributors]
+	}
+	return out
+}
@@ -0,0 +1,128 @@
+package analyze
+
+import (
+	"fmt"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/api/distribution"
+)
+
+func TestParseBreakdown(t *testing.T) {
+	labels, err := ParseBreakdown("metric.label.response_code, resource.label.revision_name")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(labels) != 2 || labels[1] != "resource.label.revision_name" {
+		t.Fatalf("unexpected labels %v", labels)
+	}
+	if _, err := ParseBreakdown("response_code"); err == nil {
+		t.Fatalf("expected an error for a bare label key")
+	}
+}
+
+func TestBuildBreakdown(t *testing.T) {
+	labels := []string{"resource.label.revision_name"}
+	groups := []Group{
+		{Labels: map[string]string{labels[0]: "rev-1"}, Good: 9990, Total: 10000},
+		{Labels: map[string]string{labels[0]: "rev-2"}, Good: 900, Total: 1000},
+		{Labels: map[string]string{labels[0]: "rev-3"}, Good: 9000, Total: 9000},
+	}
+	breakdown := BuildBreakdown(groups, labels, 0.001)
+	if len(breakdown.Contributors) != 2 {
+		t.Fatalf("expected groups without bad events to be dropped, got %+v", breakdown.Contributors)
+	}
+	top := breakdown.Contributors[0]
+	if top.Labels[labels[0]] != "rev-2" || top.BadEvents != 100 || top.BadFraction != 0.1 {
+		t.Fatalf("unexpected top contributor %+v", top)
+	}
+	// 110 bad events out of 20000 is 550% of the budget; rev-2 has 100 of them.
+	if top.ShareOfBadPercent != 90.9091 || top.ConsumedPercentOfBudget != 500 {
+		t.Fatalf("unexpected shares %+v", top)
+	}
+}
+
+func TestBuildBreakdownOmitsTail(t *testing.T) {
+	var groups []Group
+	for i := 0; i < maxContributors+3; i++ {
+		groups = append(groups, Group{Labels: map[string]string{"metric.label.code": fmt.Sprint(i)}, Good: 90, Total: 100})
+	}
+	breakdown := BuildBreakdown(groups, []string{"metric.label.code"}, 0.01)
+	if len(breakdown.Contributors) != maxContributors || breakdown.OmittedGroups != 3 {
+		t.Fatalf("unexpected truncation: %d kept, %d omitted", len(breakdown.Contributors), breakdown.OmittedGroups)
+	}
+}
+
+func TestBucketCountWithin(t *testing.T) {
+	dist := &distribution.Distribution{
+		BucketOptions: &distribution.Distribution_BucketOptions{
+			Options: &distribution.Distribution_BucketOptions_ExplicitBuckets{
+				ExplicitBuckets: &distribution.Distribution_BucketOptions_Explicit{Bounds: []float64{0, 100, 300, 1000}},
+			},
+		},
+		// underflow, [0,100), [100,300), [300,1000), overflow
+		BucketCounts: []int64{0, 50, 30, 15, 5},
+	}
+	if got := bucketCountWithin(dist, 0, 300); got != 80 {
+		t.Fatalf("expected 80 values within 300, got %v", got)
+	}
+	if got := bucketCountWithin(dist, 0, 250); got != 50 {
+		t.Fatalf("expected the straddling bucket to count as bad, got %v", got)
+	}
+}
+
+func TestBucketCountWithinUnderflow(t *testing.T) {
+	// Exponential: underflow (-inf,1), [1,2), [2,4), [4,8), overflow.
+	exponential := &distribution.Distribution{
+		BucketOptions: &distribution.Distribution_BucketOptions{
+			Options: &distribution.Distribution_BucketOptions_ExponentialBuckets{
+				ExponentialBuckets: &distribution.Distribution_BucketOptions_Exponential{NumFiniteBuckets: 3, GrowthFactor: 2, Scale: 1},
+			},
+		},
+		BucketCounts: []int64{40, 30, 20, 8, 2},
+	}
+	if got := bucketCountWithin(exponential, 0, 4); got != 90 {
+		t.Fatalf("expected the fastest requests to count as good, got %v", got)
+	}
+	if got := bucketCountWithin(exponential, 1, 4); got != 50 {
+		t.Fatalf("expected the underflow bucket to be outside a positive minimum, got %v", got)
+	}
+
+	// Linear: underflow (-inf,0), [0,100), [100,200), overflow.
+	linear := &distribution.Distribution{
+		BucketOptions: &distribution.Distribution_BucketOptions{
+			Options: &distribution.Distribution_BucketOptions_LinearBuckets{
+				LinearBuckets: &distribution.Distribution_BucketOptions_Linear{NumFiniteBuckets: 2, Width: 100, Offset: 0},
+			},
+		},
+		BucketCounts: []int64{5, 60, 30, 5},
+	}
+	if got := bucketCountWithin(linear, 0, 100); got != 65 {
+		t.Fatalf("expected 65 values within 100, got %v", got)
+	}
+	if got := bucketCountWithin(linear, 0, 150); got != 65 {
+		t.Fatalf("expected the straddling bucket to count as bad, got %v", got)
+	}
+}
+
+func TestHistogramWithinMatchesBacktest(t *testing.T) {
+	dist := &distribution.Distribution{
+		BucketOptions: &distribution.Distribution_BucketOptions{
+			Options: &distribution.Distribution_BucketOptions_ExponentialBuckets{
+				ExponentialBuckets: &distribution.Distribution_BucketOptions_Exponential{NumFiniteBuckets: 4, GrowthFactor: 2, Scale: 0.1},
+			},
+		},
+		BucketCounts: []int64{100, 40, 20, 10, 5, 1},
+	}
+	h := Histogram{UpperBounds: bucketUpperBounds(dist.GetBucketOptions(), len(dist.GetBucketCounts()))}
+	for _, count := range dist.GetBucketCounts() {
+		h.Counts = append(h.Counts, float64(count))
+	}
+	for _, max := range []float64{0.05, 0.1, 0.3, 0.4, 2} {
+		if got, want := h.Within(max), bucketCountWithin(dist, 0, max); got != want {
+			t.Fatalf("within %v: recommend counts %v, analyze -f counts %v", max, got, want)
+		}
+	}
+	if got := h.Within(0.4); got != 160 {
+		t.Fatalf("expected 160 events within 0.4s, got %v", got)
+	}
+}
@@ -0,0 +1,104 @@
+package analyze
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	autoSteps = 60
+	minStep   = time.Minute
+)
+
+// ParseStep parses the --step flag: "auto", "off", or a duration of at least
+// one minute (the smallest Monitoring alignment period).
+func ParseStep(input string) (step time.Duration, enabled bool, err error) {
+	switch strings.TrimSpace(input) {
+	case "", "auto":
+		return 0, true, nil
+	case "off":
+		return 0, false, nil
+	}
+	step, err = time.ParseDuration(input)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid --step: %w", err)
+	}
+	if step < minStep || step%time.Second != 0 {
+		return 0, false, fmt.Errorf("--step must be a whole number of seconds and at least %s", minStep)
+	}
+	return step, true, nil
+}
+
+// ResolveStep returns step, or when it is 0 the window split into about 60
+// buckets rounded up to whole minutes.
+func ResolveStep(step, window time.Duration) time.Duration {
+	if step > 0 {
+		return step
+	}
+	step = (window + autoSteps - 1) / autoSteps
+	if rem := step % minStep; rem != 0 {
+		step += minStep - rem
+	}
+	if step < minStep {
+		step = minStep
+	}
+	return step
+}
+
+// BuildBurndown turns samples into budget burndown points. Each bucket's bad
+// fraction is compared against the allowed bad fraction of the whole SLO;
+// the cumulative column spreads that over the number of buckets.
+func BuildBurndown(samples []Sample, allowedBad float64, step time.Duration) Burndown {
+	sorted := make([]Sample, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].End.Before(sorted[j].End) })
+
+	out := Burndown{StepSeconds: int64(step.Seconds())}
+	var cumulativeBad float64
+	for _, sample := range sorted {
+		compliance, _ := clamp01(sample.Compliance)
+		bad := 1 - compliance
+		burn := sample.BurnRate
+		if burn == 0 && allowedBad > 0 {
+			burn = bad / allowedBad
+		}
+		cumulativeBad += bad
+		point := BurndownPoint{
+			Start:       sample.End.Add(-step),
+			End:         sample.End,
+			BadFraction: round4(bad),
+			BurnRate:    round4(burn),
+		}
+		if allowedBad > 0 {
+			point.CumulativeConsumedPercent = round4(cumulativeBad / float64(len(sorted)) / allowedBad * 100)
+		}
+		if out.PeakAt == nil || point.BurnRate > out.PeakBurnRate {
+			end := point.End
+			out.PeakBurnRate = point.BurnRate
+			out.PeakAt = &end
+		}
+		out.Points = append(out.Points, point)
+	}
+	return out
+}
+
+// WorstIntervals returns up to n points with the highest bad fraction,
+// worst first. Points without bad events are skipped.
+func WorstIntervals(b *Burndown, n int) []BurndownPoint {
+	if b == nil || n <= 0 {
+		return nil
+	}
+	var points []BurndownPoint
+	for _, point := range b.Points {
+		if point.BadFraction > 0 {
+			points = append(points, point)
+		}
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].BadFraction > points[j].BadFraction })
+	if len(points) > n {
+		points = points[:n]
+	}
+	return points
+}
@@ -0,0 +1,151 @@
+package analyze
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseStep(t *testing.T) {
+	if step, enabled, err := ParseStep("auto"); err != nil || !enabled || step != 0 {
+		t.Fatalf("auto: got %s %v %v", step, enabled, err)
+	}
+	if _, enabled, err := ParseStep("off"); err != nil || enabled {
+		t.Fatalf("off: got %v %v", enabled, err)
+	}
+	if step, _, err := ParseStep("5m"); err != nil || step != 5*time.Minute {
+		t.Fatalf("5m: got %s %v", step, err)
+	}
+	for _, input := range []string{"30s", "90.5s", "soon"} {
+		if _, _, err := ParseStep(input); err == nil {
+			t.Fatalf("expected error for %q", input)
+		}
+	}
+}
+
+func TestResolveStep(t *testing.T) {
+	cases := map[time.Duration]time.Duration{
+		90 * time.Minute:    2 * time.Minute,
+		6 * time.Hour:       6 * time.Minute,
+		10 * time.Minute:    time.Minute,
+		30 * 24 * time.Hour: 12 * time.Hour,
+	}
+	for window, want := range cases {
+		if got := ResolveStep(0, window); got != want {
+			t.Fatalf("window %s: expected %s, got %s", window, want, got)
+		}
+	}
+	if got := ResolveStep(5*time.Minute, time.Hour); got != 5*time.Minute {
+		t.Fatalf("explicit step ignored, got %s", got)
+	}
+}
+
+func TestBuildBurndown(t *testing.T) {
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	samples := []Sample{
+		{End: base.Add(30 * time.Minute), Compliance: 1},
+		{End: base.Add(10 * time.Minute), Compliance: 0.999},
+		{End: base.Add(20 * time.Minute), Compliance: 0.99, BurnRate: 12},
+	}
+	b := BuildBurndown(samples, 0.001, 10*time.Minute)
+	if b.StepSeconds != 600 || len(b.Points) != 3 {
+		t.Fatalf("unexpected burndown %+v", b)
+	}
+	first := b.Points[0]
+	if !first.Start.Equal(base) || first.BadFraction != 0.001 || first.BurnRate != 1 {
+		t.Fatalf("unexpected first point %+v", first)
+	}
+	if got := b.Points[2].CumulativeConsumedPercent; got != 366.6667 {
+		t.Fatalf("unexpected cumulative consumed %v", got)
+	}
+	if b.PeakBurnRate != 12 || !b.PeakAt.Equal(base.Add(20*time.Minute)) {
+		t.Fatalf("unexpected peak %v at %v", b.PeakBurnRate, b.PeakAt)
+	}
+
+	worst := WorstIntervals(&b, 5)
+	if len(worst) != 2 || worst[0].BadFraction != 0.01 {
+		t.Fatalf("unexpected worst intervals %+v", worst)
+	}
+	if got := WorstIntervals(&b, 1); len(got) != 1 {
+		t.Fatalf("expected top 1, got %+v", got)
+	}
+}
+
+type seriesReader struct {
+	step time.Duration
+}
+
+func (r *seriesReader) ListServiceLevelObjectives(ctx context.Context, serviceName string, max int) ([]SLO, error) {
+	return []SLO{{
+		Name:        serviceName + "/serviceLevelObjectives/availability",
+		DisplayName: "availability",
+		Goal:        0.999,
+		SLIType:     "request-based",
+		SLIMethod:   "good-total-ratio",
+	}}, nil
+}
+
+func (r *seriesReader) FetchCompliance(ctx context.Context, project string, sloName string, start, end time.Time) (float64, error) {
+	return 0.9995, nil
+}
+
+func (r *seriesReader) FetchSeries(ctx context.Context, project string, sloName string, start, end time.Time, step time.Duration) ([]Sample, error) {
+	r.step = step
+	return []Sample{{End: end, Compliance: 0.9995}}, nil
+}
+
+func (r *seriesReader) FetchBreakdown(ctx context.Context, project string, slo SLO, labels []string, start, end time.Time) ([]Group, error) {
+	return nil, nil
+}
+
+func TestRunBurndown(t *testing.T) {
+	reader := &seriesReader{}
+	result, _, _, err := Run(context.Background(), reader, Options{
+		Project:  "demo",
+		Service:  "checkout",
+		Last:     90 * time.Minute,
+		Burndown: true,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if reader.step != 2*time.Minute {
+		t.Fatalf("expected auto step of 2m, got %s", reader.step)
+	}
+	burndown := result.SLOs[0].Burndown
+	if burndown == nil || len(burndown.Points) != 1 || burndown.Points[0].CumulativeConsumedPercent != 50 {
+		t.Fatalf("unexpected burndown %+v", burndown)
+	}
+	if result.SchemaVersion != SchemaVersion || result.Status != StatusOK {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+type noSeriesReader struct {
+	seriesReader
+}
+
+func (r *noSeriesReader) FetchSeries(ctx context.Context, project string, sloName string, start, end time.Time, step time.Duration) ([]Sample, error) {
+	return nil, errors.New("no compliance series in the window")
+}
+
+func TestRunBurndownUnavailable(t *testing.T) {
+	result, _, _, err := Run(context.Background(), &noSeriesReader{}, Options{
+		Project:  "demo",
+		Service:  "checkout",
+		Last:     time.Hour,
+		Now:      time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+		Burndown: true,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.Status != StatusOK || len(result.Errors) != 0 {
+		t.Fatalf("expected a missing series to leave the run ok, got %s %v", result.Status, result.Errors)
+	}
+	slo := result.SLOs[0]
+	if slo.Burndown != nil || len(slo.Notes) != 1 || slo.Notes[0] != "burndown unavailable: no compliance series in the window" {
+		t.Fatalf("expected the failure as an SLO note, got %+v", slo.Notes)
+	}
+}
@@ -0,0 +1,237 @@
+package analyze
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	runrest "google.golang.org/api/run/v2"
+
+	"github.com/bayneri/margin/internal/spec"
+)
+
+// Kinds of ChangeEvent. Events files may use other kinds too.
+const (
+	ChangeDeploy = "deploy"
+	ChangeConfig = "config"
+	ChangeFlag   = "flag"
+)
+
+// DefaultChangeLag is how long before a burn spike a change still counts as
+// preceding it when Options.ChangeLag is not set.
+const DefaultChangeLag = 30 * time.Minute
+
+// SpikeBurnRate is the burn rate a burndown step must reach to be part of
+// a burn spike: the default ticket threshold.
+var SpikeBurnRate = spec.DefaultSlowBurn.BurnRate
+
+// ChangeEvent is a deploy, config push, feature flag flip, or other change
+// to correlate with budget burn.
+type ChangeEvent struct {
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+	Name string    `json:"name"`
+	// Source is the events file or "cloud-run" for revisions.
+	Source string `json:"source,omitempty"`
+}
+
+// ChangeReader lists changes that margin can read from GCP. GCPReader
+// implements it.
+type ChangeReader interface {
+	// ListRevisions returns the creation of each revision of a Cloud Run
+	// service in location between start and end as a deploy.
+	ListRevisions(ctx context.Context, project, location, service string, start, end time.Time) ([]ChangeEvent, error)
+}
+
+// RevisionSource names the Cloud Run service whose revisions are read as
+// deploys.
+type RevisionSource struct {
+	Reader   ChangeReader
+	Location string
+	Service  string
+}
+
+// BurnSpike is a run of burndown steps at or above SpikeBurnRate and the
+// changes that preceded it.
+type BurnSpike struct {
+	Start            time.Time     `json:"start"`
+	End              time.Time     `json:"end"`
+	PeakBurnRate     float64       `json:"peakBurnRate"`
+	PrecedingChanges []ChangeEvent `json:"precedingChanges"`
+}
+
+// LoadChangeEvents reads an events file: CSV with a header row naming the
+// time, kind, and name columns, or JSON with an array of events, bare or
+// under "events". Times are RFC3339; a missing kind is a deploy.
+func LoadChangeEvents(path string) ([]ChangeEvent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read events: %w", err)
+	}
+	var events []ChangeEvent
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		events, err = parseChangeCSV(data)
+	} else {
+		events, err = parseChangeJSON(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for i := range events {
+		events[i].Time = events[i].Time.UTC()
+		events[i].Kind = strings.TrimSpace(events[i].Kind)
+		if events[i].Kind == "" {
+			events[i].Kind = ChangeDeploy
+		}
+		events[i].Source = filepath.Base(path)
+	}
+	sortChanges(events)
+	return events, nil
+}
+
+func parseChangeJSON(data []byte) ([]ChangeEvent, error) {
+	var events []ChangeEvent
+	if err := json.Unmarshal(data, &events); err == nil {
+		return events, validateChanges(events)
+	}
+	var file struct {
+		Events []ChangeEvent `json:"events"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	return file.Events, validateChanges(file.Events)
+}
+
+func validateChanges(events []ChangeEvent) error {
+	for i, event := range events {
+		if event.Time.IsZero() {
+			return fmt.Errorf("event %d has no time", i+1)
+		}
+	}
+	return nil
+}
+
+func parseChangeCSV(data []byte) ([]ChangeEvent, error) {
+	r := csv.NewReader(strings.NewReader(string(data)))
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	timeColumn, ok := columns["time"]
+	if !ok {
+		return nil, errors.New("header has no time column")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	var events []ChangeEvent
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		at, err := time.Parse(time.RFC3339, strings.TrimSpace(record[timeColumn]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid time: %w", line, err)
+		}
+		events = append(events, ChangeEvent{Time: at, Kind: field(record, "kind"), Name: field(record, "name")})
+	}
+	return events, nil
+}
+
+// ListRevisions pages through the service's revisions, which the Cloud Run
+// API returns newest first.
+func (r *GCPReader) ListRevisions(ctx context.Context, project, location, service string, start, end time.Time) ([]ChangeEvent, error) {
+	var out []ChangeEvent
+	parent := fmt.Sprintf("projects/%s/locations/%s/services/%s", project, location, service)
+	err := r.runService.Projects.Locations.Services.Revisions.List(parent).
+		Pages(ctx, func(page *runrest.GoogleCloudRunV2ListRevisionsResponse) error {
+			for _, revision := range page.Revisions {
+				created, err := time.Parse(time.RFC3339Nano, revision.CreateTime)
+				if err != nil {
+					continue
+				}
+				if created.Before(start) {
+					return errStopPaging
+				}
+				if created.After(end) {
+					continue
+				}
+				out = append(out, ChangeEvent{Time: created.UTC(), Kind: ChangeDeploy, Name: revision.Name[strings.LastIndex(revision.Name, "/")+1:], Source: "cloud-run"})
+			}
+			return nil
+		})
+	if err != nil && !errors.Is(err, errStopPaging) {
+		return nil, err
+	}
+	sortChanges(out)
+	return out, nil
+}
+
+// FindSpikes groups consecutive burndown steps at or above SpikeBurnRate and
+// lists the changes at most lag before the first step, or during it.
+func FindSpikes(burndown *Burndown, changes []ChangeEvent, lag time.Duration) []BurnSpike {
+	if burndown == nil {
+		return nil
+	}
+	var out []BurnSpike
+	var current *BurnSpike
+	for _, point := range burndown.Points {
+		if point.BurnRate < SpikeBurnRate {
+			current = nil
+			continue
+		}
+		if current != nil && point.Start.Equal(current.End) {
+			current.End = point.End
+			if point.BurnRate > current.PeakBurnRate {
+				current.PeakBurnRate = point.BurnRate
+			}
+			continue
+		}
+		spike := BurnSpike{Start: point.Start, End: point.End, PeakBurnRate: point.BurnRate, PrecedingChanges: []ChangeEvent{}}
+		for _, change := range changes {
+			if !change.Time.Before(point.Start.Add(-lag)) && change.Time.Before(point.End) {
+				spike.PrecedingChanges = append(spike.PrecedingChanges, change)
+			}
+		}
+		out = append(out, spike)
+		current = &out[len(out)-1]
+	}
+	return out
+}
+
+// annotateBurndown attaches each change to the burndown step it falls in.
+func annotateBurndown(burndown *Burndown, changes []ChangeEvent) {
+	for i := range burndown.Points {
+		point := &burndown.Points[i]
+		for _, change := range changes {
+			if !change.Time.Before(point.Start) && change.Time.Before(point.End) {
+				point.Changes = append(point.Changes, change)
+			}
+		}
+	}
+}
+
+func sortChanges(changes []ChangeEvent) {
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Time.Before(changes[j].Time) })
+}
@@ -0,0 +1,77 @@
+package analyze
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadChangeEvents(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "changes.csv")
+	csvData := "time,kind,name\n2025-01-01T12:00:00+01:00,flag,new-checkout\n2025-01-01T10:00:00Z,,checkout-00042\n"
+	if err := os.WriteFile(csvPath, []byte(csvData), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	events, err := LoadChangeEvents(csvPath)
+	if err != nil {
+		t.Fatalf("load csv: %v", err)
+	}
+	want := []ChangeEvent{
+		{Time: time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC), Kind: ChangeDeploy, Name: "checkout-00042", Source: "changes.csv"},
+		{Time: time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC), Kind: ChangeFlag, Name: "new-checkout", Source: "changes.csv"},
+	}
+	if len(events) != len(want) || events[0] != want[0] || events[1] != want[1] {
+		t.Fatalf("expected %+v, got %+v", want, events)
+	}
+
+	jsonPath := filepath.Join(dir, "changes.json")
+	if err := os.WriteFile(jsonPath, []byte(`{"events": [{"time": "2025-01-01T10:00:00Z", "kind": "config", "name": "pool size"}]}`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	events, err = LoadChangeEvents(jsonPath)
+	if err != nil {
+		t.Fatalf("load json: %v", err)
+	}
+	if len(events) != 1 || events[0].Kind != ChangeConfig || events[0].Source != "changes.json" {
+		t.Fatalf("unexpected events %+v", events)
+	}
+
+	for name, data := range map[string]string{"bad.csv": "kind,name\ndeploy,x\n", "bad.json": `[{"kind": "deploy"}]`} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if _, err := LoadChangeEvents(path); err == nil {
+			t.Fatalf("expected %s to be rejected", name)
+		}
+	}
+}
+
+func TestFindSpikes(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	var points []BurndownPoint
+	for i, rate := range []float64{1, 8, 14, 2, 7} {
+		points = append(points, BurndownPoint{Start: start.Add(time.Duration(i) * time.Hour), End: start.Add(time.Duration(i+1) * time.Hour), BurnRate: rate})
+	}
+	changes := []ChangeEvent{
+		{Time: start.Add(20 * time.Minute), Kind: ChangeDeploy, Name: "too early"},
+		{Time: start.Add(40 * time.Minute), Kind: ChangeDeploy, Name: "checkout-00042"},
+		{Time: start.Add(4*time.Hour + 10*time.Minute), Kind: ChangeFlag, Name: "new-checkout"},
+	}
+	spikes := FindSpikes(&Burndown{StepSeconds: 3600, Points: points}, changes, 30*time.Minute)
+	if len(spikes) != 2 {
+		t.Fatalf("expected 2 spikes, got %+v", spikes)
+	}
+	first := spikes[0]
+	if !first.Start.Equal(start.Add(time.Hour)) || !first.End.Equal(start.Add(3*time.Hour)) || first.PeakBurnRate != 14 {
+		t.Fatalf("unexpected first spike %+v", first)
+	}
+	if len(first.PrecedingChanges) != 1 || first.PrecedingChanges[0].Name != "checkout-00042" {
+		t.Fatalf("expected only the deploy within the lag, got %+v", first.PrecedingChanges)
+	}
+	if len(spikes[1].PrecedingChanges) != 1 || spikes[1].PrecedingChanges[0].Name != "new-checkout" {
+		t.Fatalf("expected the change during the spike, got %+v", spikes[1].PrecedingChanges)
+	}
+}
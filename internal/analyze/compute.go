@@ -1,6 +1,9 @@
 package analyze
 
-import "math"
+import (
+	"math"
+	"time"
+)
 
 func ComputeBudget(goal, compliance float64) (allowedBad, bad, consumed float64, notes []string) {
 	allowedBad = 1 - goal
@@ -16,6 +19,64 @@ func ComputeBudget(goal, compliance float64) (allowedBad, bad, consumed float64,
 	return allowedBad, bad, consumed, notes
 }
 
+// CountWindows converts the fractions of a windows-based SLI into whole
+// windows of period within duration.
+func CountWindows(duration, period time.Duration, bad, allowedBad float64) WindowCounts {
+	counts := WindowCounts{WindowSeconds: int64(period.Seconds())}
+	if period <= 0 {
+		return counts
+	}
+	counts.Total = int64(duration / period)
+	counts.Bad = int64(math.Round(bad * float64(counts.Total)))
+	counts.Good = counts.Total - counts.Bad
+	counts.AllowedBad = round4(allowedBad * float64(counts.Total))
+	return counts
+}
+
+// BurnRateLookback is the trailing window used for the current burn rate.
+const BurnRateLookback = time.Hour
+
+// PeriodInput holds the measurements ComputePeriod combines. Durations use
+// time as a stand-in for traffic, like the rest of analyze.
+type PeriodInput struct {
+	Goal              float64
+	Start, Now        time.Time
+	Length            time.Duration
+	Compliance        float64
+	CurrentCompliance float64
+	// WindowBad is the bad fraction of the analysis window and
+	// WindowOverlap how much of the window falls inside the period.
+	WindowBad     float64
+	WindowOverlap time.Duration
+}
+
+// ComputePeriod reports period-to-date budget use against the whole period's
+// budget, as margin forecast does. A burn rate of 1 spends that budget in one
+// period length, so the remaining budget lasts remaining/100 * length /
+// burnRate.
+func ComputePeriod(in PeriodInput) Period {
+	allowedBad := 1 - in.Goal
+	out := Period{Start: in.Start, End: in.Now, Compliance: round4(in.Compliance)}
+	if allowedBad <= 0 || in.Length <= 0 {
+		return out
+	}
+	compliance, _ := clamp01(in.Compliance)
+	elapsed := math.Max(0, in.Now.Sub(in.Start).Seconds())
+	consumed := (1 - compliance) * elapsed / in.Length.Seconds() / allowedBad * 100
+	out.ConsumedPercentOfBudget = round4(consumed)
+	out.RemainingPercentOfBudget = round4(100 - consumed)
+	out.WindowConsumedPercentOfBudget = round4(in.WindowBad * in.WindowOverlap.Seconds() / in.Length.Seconds() / allowedBad * 100)
+
+	current, _ := clamp01(in.CurrentCompliance)
+	out.CurrentBurnRate = round4((1 - current) / allowedBad)
+	if out.CurrentBurnRate > 0 {
+		remaining := math.Max(0, 100-consumed) / 100
+		exhaustion := in.Now.Add(time.Duration(remaining * float64(in.Length) / out.CurrentBurnRate)).Round(time.Second)
+		out.ProjectedExhaustion = &exhaustion
+	}
+	return out
+}
+
 func clamp01(value float64) (float64, string) {
 	if value < 0 {
 		return 0, "compliance clamped to 0"
@@ -1,6 +1,11 @@
 package analyze
 
-import "testing"
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestComputeBudget(t *testing.T) {
 	allowed, bad, consumed, _ := ComputeBudget(0.999, 0.995)
@@ -24,3 +29,212 @@ func TestComputeBudgetGoal100(t *testing.T) {
 		t.Fatalf("expected consumed 0, got %v", consumed)
 	}
 }
+
+func TestComputePeriod(t *testing.T) {
+	now := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	period := ComputePeriod(PeriodInput{
+		Goal:              0.999,
+		Start:             now.Add(-30 * 24 * time.Hour),
+		Now:               now,
+		Length:            30 * 24 * time.Hour,
+		Compliance:        0.9995,
+		CurrentCompliance: 0.998,
+		WindowBad:         0.01,
+		WindowOverlap:     time.Hour,
+	})
+	if period.ConsumedPercentOfBudget != 50 || period.RemainingPercentOfBudget != 50 {
+		t.Fatalf("unexpected budget: consumed %v remaining %v", period.ConsumedPercentOfBudget, period.RemainingPercentOfBudget)
+	}
+	if period.WindowConsumedPercentOfBudget != 1.3889 {
+		t.Fatalf("unexpected window share: %v", period.WindowConsumedPercentOfBudget)
+	}
+	if period.CurrentBurnRate != 2 {
+		t.Fatalf("unexpected burn rate: %v", period.CurrentBurnRate)
+	}
+	want := now.Add(180 * time.Hour)
+	if period.ProjectedExhaustion == nil || !period.ProjectedExhaustion.Equal(want) {
+		t.Fatalf("unexpected exhaustion: %v, want %v", period.ProjectedExhaustion, want)
+	}
+}
+
+func TestComputePeriodCalendar(t *testing.T) {
+	start := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	now := start.Add(3 * 24 * time.Hour)
+	period := ComputePeriod(PeriodInput{
+		Goal:              0.999,
+		Start:             start,
+		Now:               now,
+		Length:            30 * 24 * time.Hour,
+		Compliance:        0.998,
+		CurrentCompliance: 0.998,
+		WindowBad:         0.002,
+		WindowOverlap:     24 * time.Hour,
+	})
+	if period.ConsumedPercentOfBudget != 20 || period.RemainingPercentOfBudget != 80 {
+		t.Fatalf("expected the month's budget to be 20%% used, got consumed %v remaining %v", period.ConsumedPercentOfBudget, period.RemainingPercentOfBudget)
+	}
+	if period.WindowConsumedPercentOfBudget != 6.6667 {
+		t.Fatalf("unexpected window share: %v", period.WindowConsumedPercentOfBudget)
+	}
+	want := now.Add(12 * 24 * time.Hour)
+	if period.ProjectedExhaustion == nil || !period.ProjectedExhaustion.Equal(want) {
+		t.Fatalf("unexpected exhaustion: %v, want %v", period.ProjectedExhaustion, want)
+	}
+}
+
+func TestComputePeriodNotBurning(t *testing.T) {
+	now := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	period := ComputePeriod(PeriodInput{
+		Goal:              0.999,
+		Start:             now.Add(-24 * time.Hour),
+		Now:               now,
+		Length:            24 * time.Hour,
+		Compliance:        0.9998,
+		CurrentCompliance: 1,
+	})
+	if period.ProjectedExhaustion != nil {
+		t.Fatalf("expected no exhaustion, got %v", period.ProjectedExhaustion)
+	}
+}
+
+func TestRunPeriodUnavailable(t *testing.T) {
+	// seriesReader's SLO has no rolling or calendar period.
+	result, _, _, err := Run(context.Background(), &seriesReader{}, Options{
+		Project: "demo",
+		Service: "checkout",
+		Last:    time.Hour,
+		Now:     time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+		Period:  true,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.Status != StatusOK || len(result.Errors) != 0 {
+		t.Fatalf("expected a missing period budget to leave the run ok, got %s %v", result.Status, result.Errors)
+	}
+	slo := result.SLOs[0]
+	if slo.Period != nil || len(slo.Notes) != 1 || !strings.HasPrefix(slo.Notes[0], "period budget unavailable: ") {
+		t.Fatalf("expected the failure as an SLO note, got %+v", slo.Notes)
+	}
+}
+
+type periodReader struct {
+	seriesReader
+	ranges [][2]time.Time
+}
+
+func (r *periodReader) ListServiceLevelObjectives(ctx context.Context, serviceName string, max int) ([]SLO, error) {
+	return []SLO{{
+		Name:          serviceName + "/serviceLevelObjectives/availability",
+		DisplayName:   "availability",
+		Goal:          0.999,
+		RollingDays:   30,
+		RollingPeriod: 30 * 24 * time.Hour,
+		SLIType:       "request-based",
+		SLIMethod:     "good-total-ratio",
+	}}, nil
+}
+
+func (r *periodReader) FetchCompliance(ctx context.Context, project string, sloName string, start, end time.Time) (float64, error) {
+	r.ranges = append(r.ranges, [2]time.Time{start, end})
+	return 0.9995, nil
+}
+
+func TestRunPeriod(t *testing.T) {
+	now := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	reader := &periodReader{}
+	result, _, _, err := Run(context.Background(), reader, Options{
+		Project: "demo",
+		Service: "checkout",
+		Last:    time.Hour,
+		Period:  true,
+		Now:     now,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(reader.ranges) != 3 {
+		t.Fatalf("expected window, period, and lookback queries, got %v", reader.ranges)
+	}
+	if want := now.Add(-30 * 24 * time.Hour); !reader.ranges[1][0].Equal(want) {
+		t.Fatalf("period query starts at %s, want %s", reader.ranges[1][0], want)
+	}
+	period := result.SLOs[0].Period
+	if period == nil || period.RemainingPercentOfBudget != 50 || period.CurrentBurnRate != 0.5 {
+		t.Fatalf("unexpected period %+v", period)
+	}
+	if len(result.Errors) != 0 {
+		t.Fatalf("unexpected errors %v", result.Errors)
+	}
+}
+
+func TestSupportedSLO(t *testing.T) {
+	cases := []struct {
+		slo  SLO
+		want bool
+	}{
+		{SLO{SLIType: SLITypeRequestBased, SLIMethod: "good-total-ratio"}, true},
+		{SLO{SLIType: SLITypeRequestBased, SLIMethod: "unknown"}, false},
+		{SLO{SLIType: SLITypeWindowsBased, SLIMethod: "metric-mean-in-range", WindowPeriod: 5 * time.Minute}, true},
+		{SLO{SLIType: SLITypeWindowsBased, SLIMethod: "metric-mean-in-range"}, false},
+		{SLO{SLIType: SLITypeBasic, SLIMethod: "latency"}, true},
+		{SLO{SLIType: "unknown"}, false},
+	}
+	for _, tc := range cases {
+		if got, note := supportedSLO(tc.slo); got != tc.want {
+			t.Fatalf("supportedSLO(%+v) = %v (%s), want %v", tc.slo, got, note, tc.want)
+		}
+	}
+}
+
+func TestCountWindows(t *testing.T) {
+	counts := CountWindows(90*time.Minute, 5*time.Minute, 0.1111, 0.01)
+	if counts.Total != 18 || counts.Bad != 2 || counts.Good != 16 || counts.AllowedBad != 0.18 || counts.WindowSeconds != 300 {
+		t.Fatalf("unexpected counts %+v", counts)
+	}
+}
+
+type windowsReader struct {
+	seriesReader
+}
+
+func (r *windowsReader) ListServiceLevelObjectives(ctx context.Context, serviceName string, max int) ([]SLO, error) {
+	return []SLO{{
+		Name:         serviceName + "/serviceLevelObjectives/uptime",
+		DisplayName:  "uptime",
+		Goal:         0.99,
+		SLIType:      SLITypeWindowsBased,
+		SLIMethod:    "good-total-ratio-threshold",
+		WindowPeriod: time.Minute,
+	}}, nil
+}
+
+func (r *windowsReader) FetchCompliance(ctx context.Context, project string, sloName string, start, end time.Time) (float64, error) {
+	return 0.95, nil
+}
+
+func TestRunWindowsBased(t *testing.T) {
+	result, _, _, err := Run(context.Background(), &windowsReader{}, Options{
+		Project:   "demo",
+		Service:   "checkout",
+		Last:      time.Hour,
+		Explain:   true,
+		Breakdown: []string{"metric.label.response_code"},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	slo := result.SLOs[0]
+	if slo.Status != StatusBreach || slo.ConsumedPercentOfBudget != 500 || slo.SLIType != SLITypeWindowsBased {
+		t.Fatalf("unexpected result %+v", slo)
+	}
+	if slo.Windows == nil || slo.Windows.Total != 60 || slo.Windows.Bad != 3 {
+		t.Fatalf("unexpected window counts %+v", slo.Windows)
+	}
+	if slo.Explain == nil || slo.Explain.Formula != budgetFormula(SLO{SLIType: SLITypeWindowsBased}) {
+		t.Fatalf("expected the windows-based formula, got %+v", slo.Explain)
+	}
+	if slo.Breakdown != nil || len(result.Errors) != 0 {
+		t.Fatalf("expected the breakdown to be skipped without errors, got %+v %v", slo.Breakdown, result.Errors)
+	}
+}
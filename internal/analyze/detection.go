@@ -0,0 +1,196 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bayneri/margin/internal/spec"
+)
+
+// AlertReader reads the burn-rate alert policies margin manages and the
+// incidents they opened.
+type AlertReader interface {
+	// ListBurnRateAlerts returns the alert policies labeled managed-by=margin
+	// for a service, with the SLO each one watches.
+	ListBurnRateAlerts(ctx context.Context, project, serviceID string) ([]BurnRateAlert, error)
+	// ListIncidents returns incidents of margin-managed policies that were
+	// open at some point between start and end.
+	ListIncidents(ctx context.Context, project string, start, end time.Time) ([]Incident, error)
+	// FetchBurnRate reads select_slo_burn_rate over lookback, per step.
+	FetchBurnRate(ctx context.Context, project, sloName string, lookback time.Duration, start, end time.Time, step time.Duration) ([]Sample, error)
+}
+
+// BurnRateAlert is a margin-managed alert policy. It fires when every
+// condition holds: the burn rate over Window stays above BurnRate for
+// Duration.
+type BurnRateAlert struct {
+	Policy      string
+	DisplayName string
+	SLOName     string
+	BurnRate    float64
+	Conditions  []AlertCondition
+}
+
+type AlertCondition struct {
+	Window   time.Duration
+	Duration time.Duration
+}
+
+type Incident struct {
+	Name      string     `json:"name"`
+	Policy    string     `json:"-"`
+	State     string     `json:"state"`
+	OpenTime  time.Time  `json:"openTime"`
+	CloseTime *time.Time `json:"closeTime"`
+}
+
+// Detection compares the alerts that fired for an SLO with the ones its
+// burn rate says should have fired.
+type Detection struct {
+	// FirstBurnAt is the end of the first burndown step that burned faster
+	// than the budget allows (burn rate above 1).
+	FirstBurnAt *time.Time       `json:"firstBurnAt"`
+	Alerts      []AlertDetection `json:"alerts"`
+	// Note says why the alerts or their incidents could not be read. It
+	// does not change the SLO's or the run's status.
+	Note string `json:"note,omitempty"`
+}
+
+type AlertDetection struct {
+	Policy      string     `json:"policy"`
+	DisplayName string     `json:"displayName"`
+	BurnRate    float64    `json:"burnRateThreshold"`
+	Windows     []string   `json:"windows"`
+	Incidents   []Incident `json:"incidents"`
+	// ExpectedAt is when the alert's conditions first held in the window.
+	ExpectedAt *time.Time `json:"expectedAt"`
+	// TimeToDetectSeconds runs from FirstBurnAt to the first incident opened
+	// after it.
+	TimeToDetectSeconds *int64 `json:"timeToDetectSeconds"`
+	// Missed is set when the conditions held but no incident was open.
+	Missed bool `json:"missed"`
+}
+
+// maxAlertPoints caps the per-window burn-rate series read for detection;
+// the step grows to fit long alert durations.
+const maxAlertPoints = 500
+
+func detect(ctx context.Context, reader AlertReader, project string, alerts []BurnRateAlert, incidents []Incident, start, end, firstBurn time.Time, step time.Duration) (Detection, error) {
+	detection := Detection{}
+	if !firstBurn.IsZero() {
+		detection.FirstBurnAt = &firstBurn
+	}
+	for _, alert := range alerts {
+		item := AlertDetection{Policy: alert.Policy, DisplayName: alert.DisplayName, BurnRate: alert.BurnRate}
+		var longest time.Duration
+		for _, condition := range alert.Conditions {
+			window, _ := spec.FormatWindow(condition.Window)
+			item.Windows = append(item.Windows, window)
+			if condition.Duration > longest {
+				longest = condition.Duration
+			}
+		}
+		for _, incident := range incidents {
+			if incident.Policy == alert.Policy {
+				item.Incidents = append(item.Incidents, incident)
+			}
+		}
+		sort.Slice(item.Incidents, func(i, j int) bool { return item.Incidents[i].OpenTime.Before(item.Incidents[j].OpenTime) })
+
+		alertStep := step
+		if span := end.Sub(start) + longest; span/alertStep > maxAlertPoints {
+			alertStep = (span/maxAlertPoints + time.Minute - 1).Truncate(time.Minute)
+		}
+		series := make([][]Sample, len(alert.Conditions))
+		for i, condition := range alert.Conditions {
+			samples, err := reader.FetchBurnRate(ctx, project, alert.SLOName, condition.Window, start.Add(-condition.Duration), end, alertStep)
+			if err != nil {
+				return Detection{}, fmt.Errorf("%s: %w", alert.DisplayName, err)
+			}
+			series[i] = samples
+		}
+		item.ExpectedAt = ExpectedFiring(alert, series, alertStep, start, end)
+
+		if detection.FirstBurnAt != nil {
+			for _, incident := range item.Incidents {
+				if !incident.OpenTime.Before(firstBurn) {
+					seconds := int64(incident.OpenTime.Sub(firstBurn).Seconds())
+					item.TimeToDetectSeconds = &seconds
+					break
+				}
+			}
+		}
+		item.Missed = item.ExpectedAt != nil && len(item.Incidents) == 0
+		detection.Alerts = append(detection.Alerts, item)
+	}
+	return detection, nil
+}
+
+// ExpectedFiring returns the first step end in [start, end] at which every
+// condition of the alert held, or nil. series[i] holds the burn rate over
+// Conditions[i].Window per step; a condition holds once its burn rate has
+// stayed above the threshold for its duration without gaps.
+func ExpectedFiring(alert BurnRateAlert, series [][]Sample, step time.Duration, start, end time.Time) *time.Time {
+	if len(alert.Conditions) == 0 || len(series) != len(alert.Conditions) {
+		return nil
+	}
+	held := make([]map[time.Time]bool, len(series))
+	for i, samples := range series {
+		sorted := append([]Sample(nil), samples...)
+		sort.Slice(sorted, func(a, b int) bool { return sorted[a].End.Before(sorted[b].End) })
+		held[i] = map[time.Time]bool{}
+		var runStart, prev time.Time
+		for _, sample := range sorted {
+			if sample.BurnRate <= alert.BurnRate {
+				runStart = time.Time{}
+				prev = sample.End
+				continue
+			}
+			if runStart.IsZero() || sample.End.Sub(prev) > step {
+				runStart = sample.End.Add(-step)
+			}
+			prev = sample.End
+			if sample.End.Sub(runStart) >= alert.Conditions[i].Duration {
+				held[i][sample.End] = true
+			}
+		}
+	}
+
+	var candidates []time.Time
+	for at := range held[0] {
+		if !at.Before(start) && !at.After(end) {
+			candidates = append(candidates, at)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+	for _, at := range candidates {
+		all := true
+		for _, conditionHeld := range held[1:] {
+			if !conditionHeld[at] {
+				all = false
+				break
+			}
+		}
+		if all {
+			at := at
+			return &at
+		}
+	}
+	return nil
+}
+
+// FirstBurn returns the end of the first burndown step with a burn rate
+// above 1, or the zero time.
+func FirstBurn(b *Burndown) time.Time {
+	if b == nil {
+		return time.Time{}
+	}
+	for _, point := range b.Points {
+		if point.BurnRate > 1 {
+			return point.End
+		}
+	}
+	return time.Time{}
+}
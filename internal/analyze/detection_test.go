@@ -0,0 +1,138 @@
+package analyze
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func burnSeries(start time.Time, step time.Duration, rates ...float64) []Sample {
+	samples := make([]Sample, len(rates))
+	for i, rate := range rates {
+		samples[i] = Sample{End: start.Add(time.Duration(i+1) * step), BurnRate: rate}
+	}
+	return samples
+}
+
+func TestExpectedFiring(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	step := 5 * time.Minute
+	alert := BurnRateAlert{
+		BurnRate: 14.4,
+		Conditions: []AlertCondition{
+			{Window: 5 * time.Minute, Duration: 10 * time.Minute},
+			{Window: time.Hour, Duration: 0},
+		},
+	}
+	short := burnSeries(start, step, 20, 2, 20, 20, 20, 20)
+	long := burnSeries(start, step, 1, 1, 1, 15, 15, 15)
+
+	got := ExpectedFiring(alert, [][]Sample{short, long}, step, start, start.Add(time.Hour))
+	want := start.Add(20 * time.Minute)
+	if got == nil || !got.Equal(want) {
+		t.Fatalf("expected firing at %s, got %v", want, got)
+	}
+
+	long = burnSeries(start, step, 1, 1, 1, 1, 1, 1)
+	if got := ExpectedFiring(alert, [][]Sample{short, long}, step, start, start.Add(time.Hour)); got != nil {
+		t.Fatalf("expected no firing while the long window is low, got %v", got)
+	}
+}
+
+func TestExpectedFiringGap(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	step := 5 * time.Minute
+	alert := BurnRateAlert{BurnRate: 6, Conditions: []AlertCondition{{Window: time.Hour, Duration: 10 * time.Minute}}}
+	series := []Sample{
+		{End: start.Add(5 * time.Minute), BurnRate: 10},
+		{End: start.Add(15 * time.Minute), BurnRate: 10},
+	}
+	if got := ExpectedFiring(alert, [][]Sample{series}, step, start, start.Add(time.Hour)); got != nil {
+		t.Fatalf("expected a gap to reset the condition, got %v", got)
+	}
+}
+
+type alertReader struct {
+	alerts    []BurnRateAlert
+	incidents []Incident
+	series    []Sample
+}
+
+func (r *alertReader) ListBurnRateAlerts(ctx context.Context, project, serviceID string) ([]BurnRateAlert, error) {
+	return r.alerts, nil
+}
+
+func (r *alertReader) ListIncidents(ctx context.Context, project string, start, end time.Time) ([]Incident, error) {
+	return r.incidents, nil
+}
+
+func (r *alertReader) FetchBurnRate(ctx context.Context, project, sloName string, lookback time.Duration, start, end time.Time, step time.Duration) ([]Sample, error) {
+	return r.series, nil
+}
+
+func TestRunDetection(t *testing.T) {
+	now := time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC)
+	start := now.Add(-time.Hour)
+	slo := "projects/demo/services/checkout/serviceLevelObjectives/availability"
+	fast := BurnRateAlert{Policy: "projects/demo/alertPolicies/1", DisplayName: "checkout availability fast-burn", SLOName: slo, BurnRate: 14.4,
+		Conditions: []AlertCondition{{Window: time.Hour}}}
+	slow := BurnRateAlert{Policy: "projects/demo/alertPolicies/2", DisplayName: "checkout availability slow-burn", SLOName: slo, BurnRate: 6,
+		Conditions: []AlertCondition{{Window: 6 * time.Hour}}}
+	reader := &alertReader{
+		alerts:    []BurnRateAlert{fast, slow},
+		incidents: []Incident{{Name: "projects/demo/alerts/a", Policy: fast.Policy, State: "CLOSED", OpenTime: start.Add(30 * time.Minute)}},
+		series:    burnSeries(start, 30*time.Minute, 20, 20),
+	}
+	result, _, _, err := Run(context.Background(), &seriesReader{}, Options{
+		Project:  "demo",
+		Service:  "checkout",
+		Last:     time.Hour,
+		Now:      now,
+		Burndown: true,
+		Step:     30 * time.Minute,
+		Alerts:   reader,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	detection := result.SLOs[0].Detection
+	if detection == nil || len(detection.Alerts) != 2 {
+		t.Fatalf("unexpected detection %+v", detection)
+	}
+	if got := detection.Alerts[0]; got.Missed || len(got.Incidents) != 1 || got.ExpectedAt == nil {
+		t.Fatalf("unexpected fast-burn detection %+v", got)
+	}
+	if got := detection.Alerts[1]; !got.Missed || got.Windows[0] != "6h" {
+		t.Fatalf("expected the slow-burn alert to be missed, got %+v", got)
+	}
+}
+
+type failingAlertReader struct {
+	alertReader
+}
+
+func (r *failingAlertReader) ListIncidents(ctx context.Context, project string, start, end time.Time) ([]Incident, error) {
+	return nil, errors.New("permission denied")
+}
+
+func TestRunDetectionUnavailable(t *testing.T) {
+	now := time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC)
+	result, _, _, err := Run(context.Background(), &seriesReader{}, Options{
+		Project: "demo",
+		Service: "checkout",
+		Last:    time.Hour,
+		Now:     now,
+		Alerts:  &failingAlertReader{},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.Status != StatusOK || len(result.Errors) != 0 {
+		t.Fatalf("expected a failed alert lookup to leave the run ok, got %s %v", result.Status, result.Errors)
+	}
+	detection := result.SLOs[0].Detection
+	if detection == nil || detection.Note != "alert incidents unavailable: permission denied" {
+		t.Fatalf("expected the failure as a detection note, got %+v", detection)
+	}
+}
@@ -0,0 +1,217 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultConcurrency is the number of SLOs Run evaluates at once when
+// Options.Concurrency is not set.
+const DefaultConcurrency = 4
+
+// sloRun holds what every SLO of one Run shares.
+type sloRun struct {
+	reader    Reader
+	opts      Options
+	start     time.Time
+	end       time.Time
+	now       time.Time
+	alerts    []BurnRateAlert
+	incidents []Incident
+	detecting bool
+	// detectionNote says why the alert lookup failed; each SLO reports it
+	// in its detection section instead of failing the run.
+	detectionNote string
+	// exclusions are Options.Exclusions clipped to the window.
+	exclusions []Exclusion
+	changes    []ChangeEvent
+}
+
+// evaluateAll evaluates up to Options.Concurrency SLOs at a time. Results
+// and error lists keep the order of slos. It stops starting new SLOs and
+// returns the context's error once ctx is done.
+func (r sloRun) evaluateAll(ctx context.Context, slos []SLO) ([]SLOResult, [][]string, error) {
+	workers := r.opts.Concurrency
+	if workers <= 0 {
+		workers = DefaultConcurrency
+	}
+	items := make([]SLOResult, len(slos))
+	errorsList := make([][]string, len(slos))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, slo := range slos {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int, slo SLO) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			items[i], errorsList[i] = r.evaluate(ctx, slo)
+		}(i, slo)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	return items, errorsList, nil
+}
+
+// evaluate computes one SLO's result. Failures of optional sections are
+// returned as error lines; a failed or timed out compliance query marks the
+// SLO itself as an error.
+func (r sloRun) evaluate(parent context.Context, slo SLO) (SLOResult, []string) {
+	ctx := parent
+	if r.opts.SLOTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(parent, r.opts.SLOTimeout)
+		defer cancel()
+	}
+	reader, opts := r.reader, r.opts
+	start, end, now := r.start, r.end, r.now
+	alerts, incidents, detecting := r.alerts, r.incidents, r.detecting
+	var errorsList []string
+
+	item := SLOResult{
+		SLOResourceName:   slo.Name,
+		SLOID:             extractSLOID(slo.Name),
+		DisplayName:       slo.DisplayName,
+		Goal:              round4(slo.Goal),
+		RollingPeriodDays: slo.RollingDays,
+		CalendarPeriod:    slo.Calendar,
+		SLIType:           slo.SLIType,
+	}
+
+	supported, supportNote := supportedSLO(slo)
+	if !supported {
+		item.Status = StatusPartial
+		item.Error = supportNote
+		if opts.Explain {
+			item.Explain = &Explain{
+				Formula: budgetFormula(slo),
+				Notes:   []string{supportNote},
+			}
+		}
+		return item, []string{fmt.Sprintf("%s: %s", slo.DisplayName, supportNote)}
+	}
+
+	compliance, err := reader.FetchCompliance(ctx, opts.Project, slo.Name, start, end)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded && parent.Err() == nil {
+			err = fmt.Errorf("timed out after %s", opts.SLOTimeout)
+		}
+		item.Status = StatusError
+		item.Error = err.Error()
+		return item, []string{fmt.Sprintf("%s: %s", slo.DisplayName, err.Error())}
+	}
+
+	allowedBad, bad, consumed, notes := ComputeBudget(slo.Goal, compliance)
+	item.Compliance = round4(compliance)
+	item.BadFraction = round4(bad)
+	item.AllowedBadFraction = round4(allowedBad)
+	item.ConsumedPercentOfBudget = round4(consumed)
+	item.Status = StatusOK
+	if note := sliNote(slo); note != "" {
+		notes = append([]string{note}, notes...)
+	}
+	if slo.SLIType == SLITypeWindowsBased {
+		windows := CountWindows(end.Sub(start), slo.WindowPeriod, bad, allowedBad)
+		item.Windows = &windows
+		if windows.Total == 0 {
+			notes = append(notes, fmt.Sprintf("the window is shorter than one %s SLI window", slo.WindowPeriod))
+		}
+	}
+
+	if allowedBad <= 0 {
+		item.Status = StatusPartial
+		note := "goal is 100%; cannot compute allowed bad fraction"
+		item.Error = note
+		notes = append(notes, note)
+		errorsList = append(errorsList, fmt.Sprintf("%s: %s", slo.DisplayName, note))
+	}
+	if allowedBad > 0 && consumed > 100 {
+		item.Status = StatusBreach
+		note := "error budget exceeded in window"
+		item.Error = note
+		notes = append(notes, note)
+	}
+
+	if len(r.exclusions) > 0 && allowedBad > 0 {
+		adjusted, err := fetchAdjusted(ctx, reader, opts.Project, slo, r.exclusions, start, end)
+		if err != nil {
+			errorsList = append(errorsList, fmt.Sprintf("%s: adjusted budget unavailable: %s", slo.DisplayName, err.Error()))
+		} else {
+			item.Adjusted = &adjusted
+			notes = append(notes, fmt.Sprintf("adjusted budget leaves out %d excluded interval(s)", len(r.exclusions)))
+		}
+	}
+
+	if opts.Burndown && allowedBad > 0 {
+		step := ResolveStep(opts.Step, end.Sub(start))
+		samples, err := reader.FetchSeries(ctx, opts.Project, slo.Name, start, end, step)
+		if err != nil {
+			item.Notes = append(item.Notes, fmt.Sprintf("burndown unavailable: %s", err.Error()))
+		} else {
+			burndown := BuildBurndown(samples, allowedBad, step)
+			if len(r.changes) > 0 {
+				annotateBurndown(&burndown, r.changes)
+				item.Spikes = FindSpikes(&burndown, r.changes, opts.ChangeLag)
+			}
+			item.Burndown = &burndown
+			notes = append(notes, fmt.Sprintf("burndown uses %s steps", step))
+		}
+	}
+
+	if opts.Period && allowedBad > 0 {
+		period, err := fetchPeriod(ctx, reader, opts.Project, slo, start, end, bad, now)
+		if err != nil {
+			item.Notes = append(item.Notes, fmt.Sprintf("period budget unavailable: %s", err.Error()))
+		} else {
+			item.Period = &period
+			notes = append(notes, "period budget attributes consumption to the window")
+		}
+	}
+
+	if len(opts.Breakdown) > 0 && slo.SLIType != SLITypeRequestBased {
+		notes = append(notes, "breakdown skipped: it needs the filters of a request-based SLI")
+	} else if len(opts.Breakdown) > 0 && allowedBad > 0 {
+		groups, err := reader.FetchBreakdown(ctx, opts.Project, slo, opts.Breakdown, start, end)
+		if err != nil {
+			errorsList = append(errorsList, fmt.Sprintf("%s: breakdown unavailable: %s", slo.DisplayName, err.Error()))
+		} else {
+			breakdown := BuildBreakdown(groups, opts.Breakdown, allowedBad)
+			item.Breakdown = &breakdown
+			notes = append(notes, "breakdown counts events from the SLI filters; distribution buckets that straddle the threshold count as bad")
+		}
+	}
+
+	if sloAlerts := alertsFor(alerts, slo.Name); detecting && len(sloAlerts) > 0 {
+		step := ResolveStep(opts.Step, end.Sub(start))
+		detection, err := detect(ctx, opts.Alerts, opts.Project, sloAlerts, incidents, start, end, FirstBurn(item.Burndown), step)
+		if err != nil {
+			item.Detection = &Detection{Note: fmt.Sprintf("detection unavailable: %s", err.Error())}
+		} else {
+			item.Detection = &detection
+		}
+	} else if r.detectionNote != "" {
+		item.Detection = &Detection{Note: r.detectionNote}
+	}
+
+	if opts.Explain {
+		if item.Burndown != nil || item.Period != nil || item.Adjusted != nil {
+			notes = append(notes, "burndown, period, and adjusted budgets combine intervals weighted by time, not by request volume")
+		}
+		item.Explain = &Explain{
+			Formula: budgetFormula(slo),
+			Notes:   notes,
+		}
+	}
+
+	return item, errorsList
+}
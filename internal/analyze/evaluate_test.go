@@ -0,0 +1,115 @@
+package analyze
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowReader answers later SLOs faster than earlier ones and blocks on the
+// SLO named "stuck" until its context is done.
+type slowReader struct {
+	seriesReader
+	count int
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (r *slowReader) ListServiceLevelObjectives(ctx context.Context, serviceName string, max int) ([]SLO, error) {
+	var out []SLO
+	for i := 0; i < r.count; i++ {
+		name := fmt.Sprintf("slo-%02d", i)
+		if i == 3 {
+			name = "stuck"
+		}
+		out = append(out, SLO{
+			Name:        serviceName + "/serviceLevelObjectives/" + name,
+			DisplayName: name,
+			Goal:        0.999,
+			SLIType:     SLITypeRequestBased,
+			SLIMethod:   "good-total-ratio",
+		})
+	}
+	return out, nil
+}
+
+func (r *slowReader) FetchCompliance(ctx context.Context, project string, sloName string, start, end time.Time) (float64, error) {
+	r.mu.Lock()
+	r.inFlight++
+	if r.inFlight > r.peak {
+		r.peak = r.inFlight
+	}
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.inFlight--
+		r.mu.Unlock()
+	}()
+
+	delay := time.Duration(r.count) * time.Millisecond
+	if extractSLOID(sloName) == "stuck" {
+		delay = time.Hour
+	}
+	select {
+	case <-time.After(delay):
+		return 0.9995, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func TestRunConcurrentKeepsOrder(t *testing.T) {
+	reader := &slowReader{count: 12}
+	result, _, _, err := Run(context.Background(), reader, Options{
+		Project:     "demo",
+		Service:     "checkout",
+		Last:        time.Hour,
+		Concurrency: 3,
+		SLOTimeout:  50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(result.SLOs) != 12 {
+		t.Fatalf("expected 12 SLOs, got %d", len(result.SLOs))
+	}
+	for i := 1; i < len(result.SLOs); i++ {
+		if result.SLOs[i-1].DisplayName > result.SLOs[i].DisplayName {
+			t.Fatalf("SLOs out of order: %q before %q", result.SLOs[i-1].DisplayName, result.SLOs[i].DisplayName)
+		}
+	}
+	if reader.peak > 3 {
+		t.Fatalf("expected at most 3 concurrent queries, saw %d", reader.peak)
+	}
+
+	stuck := result.SLOs[len(result.SLOs)-1]
+	if stuck.DisplayName != "stuck" || stuck.Status != StatusError || stuck.Error != "timed out after 50ms" {
+		t.Fatalf("expected the stuck SLO to time out, got %+v", stuck)
+	}
+	if result.Status != StatusPartial || len(result.Errors) != 1 || result.Errors[0] != "stuck: timed out after 50ms" {
+		t.Fatalf("expected one timeout error, got %s %v", result.Status, result.Errors)
+	}
+	for _, slo := range result.SLOs[:len(result.SLOs)-1] {
+		if slo.Status != StatusOK {
+			t.Fatalf("expected %s to be ok, got %s", slo.DisplayName, slo.Status)
+		}
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, _, _, err := Run(ctx, &slowReader{count: 8}, Options{
+		Project: "demo",
+		Service: "checkout",
+		Last:    time.Hour,
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the run to stop with the context, got %v", err)
+	}
+}
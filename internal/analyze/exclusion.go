@@ -0,0 +1,239 @@
+package analyze
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bayneri/margin/internal/spec"
+)
+
+// Exclusion is an interval, such as planned maintenance, that the adjusted
+// budget leaves out.
+type Exclusion struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// Adjusted is an SLO's window budget with the Result's exclusions left out.
+// Kept intervals are weighted by time, like the rest of analyze.
+type Adjusted struct {
+	ExcludedSeconds         int64   `json:"excludedSeconds"`
+	Compliance              float64 `json:"compliance"`
+	BadFraction             float64 `json:"badFraction"`
+	ConsumedPercentOfBudget float64 `json:"consumedPercentOfBudget"`
+	Status                  string  `json:"status"`
+	// FromBurndown is set when margin report derived the budget from the
+	// burndown steps rather than from compliance queries.
+	FromBurndown bool `json:"fromBurndown,omitempty"`
+}
+
+// ParseExclusion parses start/end or start/end=reason, with RFC3339 times.
+func ParseExclusion(input string) (Exclusion, error) {
+	value, reason, _ := strings.Cut(strings.TrimSpace(input), "=")
+	from, to, ok := strings.Cut(value, "/")
+	if !ok {
+		return Exclusion{}, fmt.Errorf("invalid exclusion %q (want start/end or start/end=reason)", input)
+	}
+	start, err := time.Parse(time.RFC3339, strings.TrimSpace(from))
+	if err != nil {
+		return Exclusion{}, fmt.Errorf("invalid exclusion start: %w", err)
+	}
+	end, err := time.Parse(time.RFC3339, strings.TrimSpace(to))
+	if err != nil {
+		return Exclusion{}, fmt.Errorf("invalid exclusion end: %w", err)
+	}
+	if !end.After(start) {
+		return Exclusion{}, fmt.Errorf("exclusion %q must end after it starts", input)
+	}
+	return Exclusion{Start: start.UTC(), End: end.UTC(), Reason: strings.TrimSpace(reason)}, nil
+}
+
+// ParseExclusions parses a list of exclusions such as the comma-separated
+// --exclude flag.
+func ParseExclusions(inputs []string) ([]Exclusion, error) {
+	var out []Exclusion
+	for _, input := range inputs {
+		exclusion, err := ParseExclusion(input)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, exclusion)
+	}
+	return out, nil
+}
+
+// SpecExclusions converts the maintenance section of a validated spec.
+func SpecExclusions(windows []spec.MaintenanceWindow) ([]Exclusion, error) {
+	var out []Exclusion
+	for i, window := range windows {
+		start, err := time.Parse(time.RFC3339, strings.TrimSpace(window.Start))
+		if err != nil {
+			return nil, fmt.Errorf("maintenance[%d].start: %w", i, err)
+		}
+		end, err := time.Parse(time.RFC3339, strings.TrimSpace(window.End))
+		if err != nil {
+			return nil, fmt.Errorf("maintenance[%d].end: %w", i, err)
+		}
+		reason := strings.TrimSpace(window.Reason)
+		if reason == "" {
+			reason = "planned maintenance"
+		}
+		out = append(out, Exclusion{Start: start.UTC(), End: end.UTC(), Reason: reason})
+	}
+	return out, nil
+}
+
+// ClipExclusions returns the exclusions that overlap start to end, clipped to
+// it and sorted by start. Overlapping exclusions are kept apart so each
+// reason is reported.
+func ClipExclusions(exclusions []Exclusion, start, end time.Time) []Exclusion {
+	var out []Exclusion
+	for _, exclusion := range exclusions {
+		from, to := maxTime(exclusion.Start, start), minTime(exclusion.End, end)
+		if !to.After(from) {
+			continue
+		}
+		out = append(out, Exclusion{Start: from, End: to, Reason: exclusion.Reason})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+// interval is one part of the window that is not excluded.
+type interval struct {
+	start, end time.Time
+}
+
+// keptIntervals returns the parts of start to end outside every exclusion.
+func keptIntervals(exclusions []Exclusion, start, end time.Time) []interval {
+	var out []interval
+	from := start
+	for _, exclusion := range ClipExclusions(exclusions, start, end) {
+		if exclusion.Start.After(from) {
+			out = append(out, interval{from, exclusion.Start})
+		}
+		from = maxTime(from, exclusion.End)
+	}
+	if end.After(from) {
+		out = append(out, interval{from, end})
+	}
+	return out
+}
+
+var errAllExcluded = errors.New("the whole window is excluded")
+
+// fetchAdjusted queries compliance over each kept interval and combines them
+// weighted by time. Monitoring cannot align less than minStep, so shorter
+// kept intervals, such as the seconds between an exclusion and the window
+// edge, are left out and count as excluded.
+func fetchAdjusted(ctx context.Context, reader Reader, project string, slo SLO, exclusions []Exclusion, start, end time.Time) (Adjusted, error) {
+	var kept []interval
+	for _, part := range keptIntervals(exclusions, start, end) {
+		if part.end.Sub(part.start) >= minStep {
+			kept = append(kept, part)
+		}
+	}
+	if len(kept) == 0 {
+		return Adjusted{}, errAllExcluded
+	}
+	var good, total float64
+	for _, part := range kept {
+		compliance, err := reader.FetchCompliance(ctx, project, slo.Name, part.start, part.end)
+		if err != nil {
+			return Adjusted{}, err
+		}
+		compliance, _ = clamp01(compliance)
+		good += compliance * part.end.Sub(part.start).Seconds()
+		total += part.end.Sub(part.start).Seconds()
+	}
+	return adjustedBudget(slo.Goal, good/total, end.Sub(start).Seconds()-total), nil
+}
+
+// AdjustFromBurndown derives an SLO's adjusted budget from its burndown
+// steps, counting the part of each step outside the exclusions. It returns
+// false when the SLO has no burndown or every step is excluded.
+func AdjustFromBurndown(slo SLOResult, exclusions []Exclusion, start, end time.Time) (Adjusted, bool) {
+	if slo.Burndown == nil || len(slo.Burndown.Points) == 0 {
+		return Adjusted{}, false
+	}
+	var good, total float64
+	for _, point := range slo.Burndown.Points {
+		for _, part := range keptIntervals(exclusions, point.Start, point.End) {
+			seconds := part.end.Sub(part.start).Seconds()
+			good += (1 - point.BadFraction) * seconds
+			total += seconds
+		}
+	}
+	if total <= 0 {
+		return Adjusted{}, false
+	}
+	var excluded float64
+	for _, part := range mergedExclusions(exclusions, start, end) {
+		excluded += part.end.Sub(part.start).Seconds()
+	}
+	adjusted := adjustedBudget(slo.Goal, good/total, excluded)
+	adjusted.FromBurndown = true
+	return adjusted, true
+}
+
+// ExcludeFromResult adds exclusions to an analyze result after the fact,
+// as margin report does, and recomputes the adjusted budget of every
+// evaluated SLO from its burndown steps. The result's own exclusions still
+// apply.
+func ExcludeFromResult(result Result, exclusions []Exclusion) Result {
+	start, end := result.Window.Start, result.Window.End
+	result.Exclusions = ClipExclusions(append(append([]Exclusion{}, result.Exclusions...), exclusions...), start, end)
+	slos := make([]SLOResult, len(result.SLOs))
+	for i, slo := range result.SLOs {
+		if slo.Status == StatusOK || slo.Status == StatusBreach {
+			slo.Adjusted = nil
+			if adjusted, ok := AdjustFromBurndown(slo, result.Exclusions, start, end); ok {
+				slo.Adjusted = &adjusted
+			} else if slo.Burndown == nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: adjusted budget unavailable: excluding intervals from a summary needs its burndown", slo.DisplayName))
+			} else {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: adjusted budget unavailable: %s", slo.DisplayName, errAllExcluded))
+			}
+		}
+		slos[i] = slo
+	}
+	result.SLOs = slos
+	return result
+}
+
+// mergedExclusions returns the excluded parts of start to end with overlaps
+// merged.
+func mergedExclusions(exclusions []Exclusion, start, end time.Time) []interval {
+	var out []interval
+	from := start
+	for _, part := range keptIntervals(exclusions, start, end) {
+		if part.start.After(from) {
+			out = append(out, interval{from, part.start})
+		}
+		from = part.end
+	}
+	if end.After(from) {
+		out = append(out, interval{from, end})
+	}
+	return out
+}
+
+func adjustedBudget(goal, compliance, excludedSeconds float64) Adjusted {
+	allowedBad, bad, consumed, _ := ComputeBudget(goal, compliance)
+	out := Adjusted{
+		ExcludedSeconds:         int64(excludedSeconds),
+		Compliance:              round4(compliance),
+		BadFraction:             round4(bad),
+		ConsumedPercentOfBudget: round4(consumed),
+		Status:                  StatusOK,
+	}
+	if allowedBad > 0 && consumed > 100 {
+		out.Status = StatusBreach
+	}
+	return out
+}
@@ -0,0 +1,124 @@
+package analyze
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// maintenanceReader burns budget only between 11:00 and 12:00, so the
+// whole window reads worse than any interval without it.
+type maintenanceReader struct {
+	seriesReader
+	queried []string
+}
+
+func (r *maintenanceReader) FetchCompliance(ctx context.Context, project string, sloName string, start, end time.Time) (float64, error) {
+	r.queried = append(r.queried, start.Format("15:04")+"-"+end.Format("15:04"))
+	if start.Hour() <= 11 && end.Hour() >= 12 {
+		return 0.99, nil
+	}
+	return 0.9995, nil
+}
+
+func TestRunExclusions(t *testing.T) {
+	reader := &maintenanceReader{}
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	result, _, _, err := Run(context.Background(), reader, Options{
+		Project: "demo",
+		Service: "checkout",
+		Start:   start.Format(time.RFC3339),
+		End:     start.Add(4 * time.Hour).Format(time.RFC3339),
+		Exclusions: []Exclusion{
+			{Start: start.Add(time.Hour), End: start.Add(2 * time.Hour), Reason: "database upgrade"},
+			{Start: start.Add(-time.Hour), End: start.Add(-30 * time.Minute), Reason: "before the window"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(result.Exclusions) != 1 || result.Exclusions[0].Reason != "database upgrade" {
+		t.Fatalf("expected the exclusion inside the window, got %+v", result.Exclusions)
+	}
+	if got := strings.Join(reader.queried, ","); got != "10:00-14:00,10:00-11:00,12:00-14:00" {
+		t.Fatalf("unexpected compliance queries %s", got)
+	}
+	slo := result.SLOs[0]
+	if slo.Status != StatusBreach || slo.ConsumedPercentOfBudget != 1000 {
+		t.Fatalf("expected the raw budget to include the maintenance, got %s %.2f", slo.Status, slo.ConsumedPercentOfBudget)
+	}
+	want := Adjusted{ExcludedSeconds: 3600, Compliance: 0.9995, BadFraction: 0.0005, ConsumedPercentOfBudget: 50, Status: StatusOK}
+	if slo.Adjusted == nil || *slo.Adjusted != want {
+		t.Fatalf("expected adjusted %+v, got %+v", want, slo.Adjusted)
+	}
+}
+
+func TestRunExclusionsShortKeptInterval(t *testing.T) {
+	reader := &maintenanceReader{}
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	result, _, _, err := Run(context.Background(), reader, Options{
+		Project:    "demo",
+		Service:    "checkout",
+		Start:      start.Format(time.RFC3339),
+		End:        start.Add(4 * time.Hour).Format(time.RFC3339),
+		Exclusions: []Exclusion{{Start: start.Add(time.Hour), End: start.Add(4*time.Hour - 30*time.Second), Reason: "database upgrade"}},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := strings.Join(reader.queried, ","); got != "10:00-14:00,10:00-11:00" {
+		t.Fatalf("expected the 30s before the window end not to be queried, got %s", got)
+	}
+	adjusted := result.SLOs[0].Adjusted
+	if adjusted == nil || adjusted.ExcludedSeconds != 3*3600 || adjusted.Compliance != 0.9995 {
+		t.Fatalf("unexpected adjusted budget %+v (errors %v)", adjusted, result.Errors)
+	}
+}
+
+func TestParseExclusion(t *testing.T) {
+	got, err := ParseExclusion("2025-01-01T11:00:00+01:00/2025-01-01T12:00:00+01:00=db upgrade")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := Exclusion{Start: time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC), End: time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC), Reason: "db upgrade"}
+	if got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	for _, input := range []string{"2025-01-01T11:00:00Z", "2025-01-01T12:00:00Z/2025-01-01T11:00:00Z", "yesterday/today"} {
+		if _, err := ParseExclusion(input); err == nil {
+			t.Fatalf("expected %q to be rejected", input)
+		}
+	}
+}
+
+func TestExcludeFromResult(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	var points []BurndownPoint
+	for i := 0; i < 4; i++ {
+		point := BurndownPoint{Start: start.Add(time.Duration(i) * time.Hour), End: start.Add(time.Duration(i+1) * time.Hour)}
+		if i == 1 {
+			point.BadFraction = 0.01
+		}
+		points = append(points, point)
+	}
+	result := Result{
+		Window: Window{Start: start, End: start.Add(4 * time.Hour), DurationSeconds: 4 * 3600},
+		SLOs: []SLOResult{
+			{DisplayName: "availability", Goal: 0.999, Status: StatusBreach, Burndown: &Burndown{StepSeconds: 3600, Points: points}},
+			{DisplayName: "latency", Goal: 0.99, Status: StatusOK},
+		},
+	}
+	// The exclusion covers half of the bad hour.
+	out := ExcludeFromResult(result, []Exclusion{{Start: start.Add(90 * time.Minute), End: start.Add(3 * time.Hour)}})
+	adjusted := out.SLOs[0].Adjusted
+	if adjusted == nil || !adjusted.FromBurndown || adjusted.ExcludedSeconds != 5400 || adjusted.ConsumedPercentOfBudget != 200 || adjusted.Status != StatusBreach {
+		t.Fatalf("unexpected adjusted budget %+v", adjusted)
+	}
+	if out.SLOs[1].Adjusted != nil || len(out.Errors) != 1 || !strings.Contains(out.Errors[0], "latency: adjusted budget unavailable") {
+		t.Fatalf("expected the SLO without a burndown to be reported, got %v", out.Errors)
+	}
+	if result.Exclusions != nil {
+		t.Fatalf("ExcludeFromResult changed its input")
+	}
+}
@@ -3,12 +3,17 @@ package analyze
 import (
 	"context"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 	"time"
 
 	"cloud.google.com/go/monitoring/apiv3/v2"
 	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
 	"google.golang.org/api/iterator"
+	monitoringrest "google.golang.org/api/monitoring/v3"
+	runrest "google.golang.org/api/run/v2"
+	"google.golang.org/genproto/googleapis/api/distribution"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/durationpb"
@@ -18,6 +23,9 @@ import (
 type GCPReader struct {
 	serviceClient *monitoring.ServiceMonitoringClient
 	metricClient  *monitoring.MetricClient
+	alertClient   *monitoring.AlertPolicyClient
+	alertsService *monitoringrest.Service
+	runService    *runrest.Service
 }
 
 func NewGCPReader(ctx context.Context) (*GCPReader, error) {
@@ -30,7 +38,27 @@ func NewGCPReader(ctx context.Context) (*GCPReader, error) {
 		serviceClient.Close()
 		return nil, fmt.Errorf("create metric client: %w", err)
 	}
-	return &GCPReader{serviceClient: serviceClient, metricClient: metricClient}, nil
+	alertClient, err := monitoring.NewAlertPolicyClient(ctx)
+	if err != nil {
+		serviceClient.Close()
+		metricClient.Close()
+		return nil, fmt.Errorf("create alert policy client: %w", err)
+	}
+	alertsService, err := monitoringrest.NewService(ctx)
+	if err != nil {
+		serviceClient.Close()
+		metricClient.Close()
+		alertClient.Close()
+		return nil, fmt.Errorf("create alerts client: %w", err)
+	}
+	runService, err := runrest.NewService(ctx)
+	if err != nil {
+		serviceClient.Close()
+		metricClient.Close()
+		alertClient.Close()
+		return nil, fmt.Errorf("create cloud run client: %w", err)
+	}
+	return &GCPReader{serviceClient: serviceClient, metricClient: metricClient, alertClient: alertClient, alertsService: alertsService, runService: runService}, nil
 }
 
 func (r *GCPReader) Close() error {
@@ -41,12 +69,33 @@ func (r *GCPReader) Close() error {
 	if err := r.metricClient.Close(); err != nil {
 		errs = append(errs, err.Error())
 	}
+	if err := r.alertClient.Close(); err != nil {
+		errs = append(errs, err.Error())
+	}
 	if len(errs) > 0 {
 		return fmt.Errorf("close monitoring clients: %s", strings.Join(errs, "; "))
 	}
 	return nil
 }
 
+// ListServices returns the IDs of the project's Monitoring services, sorted.
+func (r *GCPReader) ListServices(ctx context.Context, project string) ([]string, error) {
+	iter := r.serviceClient.ListServices(ctx, &monitoringpb.ListServicesRequest{Parent: fmt.Sprintf("projects/%s", project)})
+	var out []string
+	for {
+		service, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("list services: %w", err)
+		}
+		out = append(out, service.GetName()[strings.LastIndex(service.GetName(), "/")+1:])
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
 func (r *GCPReader) ListServiceLevelObjectives(ctx context.Context, serviceName string, max int) ([]SLO, error) {
 	iter := r.serviceClient.ListServiceLevelObjectives(ctx, &monitoringpb.ListServiceLevelObjectivesRequest{Parent: serviceName})
 	var out []SLO
@@ -105,19 +154,312 @@ func (r *GCPReader) FetchCompliance(ctx context.Context, project string, sloName
 		if point.Value == nil {
 			continue
 		}
-		switch v := point.Value.GetValue().(type) {
-		case *monitoringpb.TypedValue_DoubleValue:
-			return v.DoubleValue, nil
-		case *monitoringpb.TypedValue_Int64Value:
-			return float64(v.Int64Value), nil
-		default:
-			return point.Value.GetDoubleValue(), nil
-		}
+		return pointValue(point.Value), nil
 	}
 
 	return 0, status.Error(codes.NotFound, "no compliance points returned")
 }
 
+// FetchSeries reads compliance and burn rate per step. Both queries align to
+// step; the burn-rate lookback is one step so each point covers its bucket.
+func (r *GCPReader) FetchSeries(ctx context.Context, project string, sloName string, start, end time.Time, step time.Duration) ([]Sample, error) {
+	compliance, err := r.listPoints(ctx, project, fmt.Sprintf("select_slo_compliance(%q)", sloName), start, end, step, monitoringpb.Aggregation_ALIGN_MEAN, monitoringpb.Aggregation_REDUCE_MEAN)
+	if err != nil {
+		return nil, err
+	}
+	if len(compliance) == 0 {
+		return nil, status.Error(codes.NotFound, "no compliance points returned")
+	}
+	lookback := fmt.Sprintf("%ds", int64(step.Seconds()))
+	burnRates, err := r.listPoints(ctx, project, fmt.Sprintf("select_slo_burn_rate(%q, %q)", sloName, lookback), start, end, step, monitoringpb.Aggregation_ALIGN_MAX, monitoringpb.Aggregation_REDUCE_MAX)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Sample, 0, len(compliance))
+	for at, value := range compliance {
+		out = append(out, Sample{End: at, Compliance: value, BurnRate: burnRates[at]})
+	}
+	return out, nil
+}
+
+// listPoints returns the points of the first series matching filter, keyed
+// by the end of their alignment period.
+func (r *GCPReader) listPoints(ctx context.Context, project, filter string, start, end time.Time, step time.Duration, aligner monitoringpb.Aggregation_Aligner, reducer monitoringpb.Aggregation_Reducer) (map[time.Time]float64, error) {
+	req := &monitoringpb.ListTimeSeriesRequest{
+		Name:   fmt.Sprintf("projects/%s", project),
+		Filter: filter,
+		Interval: &monitoringpb.TimeInterval{
+			StartTime: timestamppb.New(start),
+			EndTime:   timestamppb.New(end),
+		},
+		Aggregation: &monitoringpb.Aggregation{
+			AlignmentPeriod:    durationpb.New(step),
+			PerSeriesAligner:   aligner,
+			CrossSeriesReducer: reducer,
+		},
+		View: monitoringpb.ListTimeSeriesRequest_FULL,
+	}
+	out := map[time.Time]float64{}
+	iter := r.metricClient.ListTimeSeries(ctx, req)
+	for {
+		ts, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, point := range ts.Points {
+			if point.Value == nil || point.Interval == nil {
+				continue
+			}
+			out[point.Interval.EndTime.AsTime().UTC()] = pointValue(point.Value)
+		}
+		if len(out) > 0 {
+			break
+		}
+	}
+	return out, nil
+}
+
+// FetchBreakdown reads the SLI's underlying series over the whole window,
+// grouped by labels. Good-total-ratio SLOs combine whichever two of the good,
+// bad, and total filters are set; distribution-cut SLOs count the buckets
+// inside the range as good.
+func (r *GCPReader) FetchBreakdown(ctx context.Context, project string, slo SLO, labels []string, start, end time.Time) ([]Group, error) {
+	return r.countEvents(ctx, project, slo, labels, start, end, end.Sub(start), false)
+}
+
+// FetchEvents reads the SLI's good and total events per step, with End set
+// to the end of each step. It evaluates SLIs that are not in Monitoring.
+func (r *GCPReader) FetchEvents(ctx context.Context, project string, slo SLO, start, end time.Time, step time.Duration) ([]Group, error) {
+	return r.countEvents(ctx, project, slo, nil, start, end, step, true)
+}
+
+// countEvents sums the SLI's events per combination of labels, and per step
+// when perStep is set.
+func (r *GCPReader) countEvents(ctx context.Context, project string, slo SLO, labels []string, start, end time.Time, step time.Duration, perStep bool) ([]Group, error) {
+	type counts struct {
+		key              map[string]string
+		end              time.Time
+		good, bad, total float64
+	}
+	groups := map[string]*counts{}
+	sum := func(filter string, apply func(c *counts, value *monitoringpb.TypedValue)) error {
+		if filter == "" {
+			return nil
+		}
+		return r.listGroups(ctx, project, filter, labels, start, end, step, func(key map[string]string, at time.Time, value *monitoringpb.TypedValue) {
+			id := groupKey(key, labels)
+			if perStep {
+				id += "\x00" + at.Format(time.RFC3339)
+			} else {
+				at = time.Time{}
+			}
+			if groups[id] == nil {
+				groups[id] = &counts{key: key, end: at}
+			}
+			apply(groups[id], value)
+		})
+	}
+
+	switch slo.SLIMethod {
+	case "good-total-ratio":
+		for _, query := range []struct {
+			filter string
+			apply  func(c *counts, value *monitoringpb.TypedValue)
+		}{
+			{slo.GoodFilter, func(c *counts, v *monitoringpb.TypedValue) { c.good += pointValue(v) }},
+			{slo.BadFilter, func(c *counts, v *monitoringpb.TypedValue) { c.bad += pointValue(v) }},
+			{slo.TotalFilter, func(c *counts, v *monitoringpb.TypedValue) { c.total += pointValue(v) }},
+		} {
+			if err := sum(query.filter, query.apply); err != nil {
+				return nil, err
+			}
+		}
+	case "distribution-cut":
+		err := sum(slo.DistributionFilter, func(c *counts, value *monitoringpb.TypedValue) {
+			dist := value.GetDistributionValue()
+			c.total += float64(dist.GetCount())
+			c.good += bucketCountWithin(dist, slo.RangeMin, slo.RangeMax)
+		})
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("breakdown does not support SLI method %q", slo.SLIMethod)
+	}
+
+	out := make([]Group, 0, len(groups))
+	for _, c := range groups {
+		group := Group{Labels: c.key, End: c.end, Good: c.good, Total: c.total}
+		switch {
+		case slo.SLIMethod != "good-total-ratio":
+		case slo.TotalFilter == "":
+			group.Total = c.good + c.bad
+		case slo.GoodFilter == "":
+			group.Good = c.total - c.bad
+		}
+		out = append(out, group)
+	}
+	return out, nil
+}
+
+// FetchHistograms sums the distributions matching filter per step. Latency
+// recommendations read them to try thresholds the SLO does not use yet.
+func (r *GCPReader) FetchHistograms(ctx context.Context, project, filter string, start, end time.Time, step time.Duration) ([]Histogram, error) {
+	byEnd := map[time.Time]*Histogram{}
+	err := r.listGroups(ctx, project, filter, nil, start, end, step, func(_ map[string]string, at time.Time, value *monitoringpb.TypedValue) {
+		dist := value.GetDistributionValue()
+		if dist == nil {
+			return
+		}
+		upper := bucketUpperBounds(dist.GetBucketOptions(), len(dist.GetBucketCounts()))
+		h := byEnd[at]
+		if h == nil {
+			h = &Histogram{End: at, UpperBounds: upper, Counts: make([]float64, len(upper))}
+			byEnd[at] = h
+		}
+		for i, count := range dist.GetBucketCounts() {
+			if i < len(h.Counts) {
+				h.Counts[i] += float64(count)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Histogram, 0, len(byEnd))
+	for _, h := range byEnd {
+		out = append(out, *h)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].End.Before(out[j].End) })
+	return out, nil
+}
+
+// listGroups sums filter per step and per combination of label values.
+func (r *GCPReader) listGroups(ctx context.Context, project, filter string, labels []string, start, end time.Time, step time.Duration, visit func(key map[string]string, at time.Time, value *monitoringpb.TypedValue)) error {
+	req := &monitoringpb.ListTimeSeriesRequest{
+		Name:   fmt.Sprintf("projects/%s", project),
+		Filter: filter,
+		Interval: &monitoringpb.TimeInterval{
+			StartTime: timestamppb.New(start),
+			EndTime:   timestamppb.New(end),
+		},
+		Aggregation: &monitoringpb.Aggregation{
+			AlignmentPeriod:    durationpb.New(step),
+			PerSeriesAligner:   monitoringpb.Aggregation_ALIGN_DELTA,
+			CrossSeriesReducer: monitoringpb.Aggregation_REDUCE_SUM,
+			GroupByFields:      labels,
+		},
+		View: monitoringpb.ListTimeSeriesRequest_FULL,
+	}
+	iter := r.metricClient.ListTimeSeries(ctx, req)
+	for {
+		ts, err := iter.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		key := map[string]string{}
+		for _, label := range labels {
+			switch {
+			case strings.HasPrefix(label, "metric.label."):
+				key[label] = ts.GetMetric().GetLabels()[strings.TrimPrefix(label, "metric.label.")]
+			case strings.HasPrefix(label, "resource.label."):
+				key[label] = ts.GetResource().GetLabels()[strings.TrimPrefix(label, "resource.label.")]
+			}
+		}
+		for _, point := range ts.Points {
+			if point.Value != nil {
+				visit(key, point.GetInterval().GetEndTime().AsTime().UTC(), point.Value)
+			}
+		}
+	}
+}
+
+func groupKey(key map[string]string, labels []string) string {
+	values := make([]string, len(labels))
+	for i, label := range labels {
+		values[i] = key[label]
+	}
+	return strings.Join(values, "\x00")
+}
+
+// bucketCountWithin counts the values in buckets that lie entirely inside
+// [min, max]. Buckets straddling a bound count as bad, so the result is a
+// lower bound on good events. The underflow bucket counts as inside when
+// min <= 0, since latencies are not negative; Monitoring's distribution cut
+// counts it as good too.
+func bucketCountWithin(dist *distribution.Distribution, min, max float64) float64 {
+	counts := make([]float64, len(dist.GetBucketCounts()))
+	for i, count := range dist.GetBucketCounts() {
+		counts[i] = float64(count)
+	}
+	return countWithin(bucketUpperBounds(dist.GetBucketOptions(), len(counts)), counts, min, max)
+}
+
+// countWithin is bucketCountWithin over bucket upper bounds and counts, as
+// kept by Histogram.
+func countWithin(upper, counts []float64, min, max float64) float64 {
+	var good float64
+	lower := math.Inf(-1)
+	if min <= 0 {
+		lower = 0
+	}
+	for i, count := range counts {
+		if i >= len(upper) {
+			break
+		}
+		if lower >= min && upper[i] <= max {
+			good += count
+		}
+		lower = upper[i]
+	}
+	return good
+}
+
+// bucketUpperBounds returns the upper bound of each of n buckets, with the
+// underflow bucket first and the overflow bucket unbounded.
+func bucketUpperBounds(options *distribution.Distribution_BucketOptions, n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = math.Inf(1)
+	}
+	switch {
+	case options.GetLinearBuckets() != nil:
+		linear := options.GetLinearBuckets()
+		for i := 0; i < n && i <= int(linear.GetNumFiniteBuckets()); i++ {
+			out[i] = linear.GetOffset() + linear.GetWidth()*float64(i)
+		}
+	case options.GetExponentialBuckets() != nil:
+		exp := options.GetExponentialBuckets()
+		for i := 0; i < n && i <= int(exp.GetNumFiniteBuckets()); i++ {
+			out[i] = exp.GetScale() * math.Pow(exp.GetGrowthFactor(), float64(i))
+		}
+	case options.GetExplicitBuckets() != nil:
+		bounds := options.GetExplicitBuckets().GetBounds()
+		for i := 0; i < n && i < len(bounds); i++ {
+			out[i] = bounds[i]
+		}
+	}
+	return out
+}
+
+func pointValue(value *monitoringpb.TypedValue) float64 {
+	switch v := value.GetValue().(type) {
+	case *monitoringpb.TypedValue_DoubleValue:
+		return v.DoubleValue
+	case *monitoringpb.TypedValue_Int64Value:
+		return float64(v.Int64Value)
+	default:
+		return value.GetDoubleValue()
+	}
+}
+
 func toAnalyzeSLO(slo *monitoringpb.ServiceLevelObjective) (SLO, error) {
 	result := SLO{
 		Name:        slo.GetName(),
@@ -125,7 +467,8 @@ func toAnalyzeSLO(slo *monitoringpb.ServiceLevelObjective) (SLO, error) {
 		Goal:        slo.GetGoal(),
 	}
 	if slo.GetRollingPeriod() != nil {
-		result.RollingDays = int64(slo.GetRollingPeriod().AsDuration().Hours() / 24)
+		result.RollingPeriod = slo.GetRollingPeriod().AsDuration()
+		result.RollingDays = int64(result.RollingPeriod.Hours() / 24)
 	}
 	if slo.GetCalendarPeriod() != 0 {
 		value := slo.GetCalendarPeriod().String()
@@ -137,20 +480,50 @@ func toAnalyzeSLO(slo *monitoringpb.ServiceLevelObjective) (SLO, error) {
 	}
 	switch indicator.GetType().(type) {
 	case *monitoringpb.ServiceLevelIndicator_RequestBased:
-		result.SLIType = "request-based"
+		result.SLIType = SLITypeRequestBased
 		method := indicator.GetRequestBased().GetMethod()
 		switch method.(type) {
 		case *monitoringpb.RequestBasedSli_GoodTotalRatio:
 			result.SLIMethod = "good-total-ratio"
+			ratio := indicator.GetRequestBased().GetGoodTotalRatio()
+			result.GoodFilter = ratio.GetGoodServiceFilter()
+			result.BadFilter = ratio.GetBadServiceFilter()
+			result.TotalFilter = ratio.GetTotalServiceFilter()
 		case *monitoringpb.RequestBasedSli_DistributionCut:
 			result.SLIMethod = "distribution-cut"
+			cut := indicator.GetRequestBased().GetDistributionCut()
+			result.DistributionFilter = cut.GetDistributionFilter()
+			result.RangeMin = cut.GetRange().GetMin()
+			result.RangeMax = cut.GetRange().GetMax()
 		default:
 			result.SLIMethod = "unknown"
 		}
 	case *monitoringpb.ServiceLevelIndicator_WindowsBased:
-		result.SLIType = "windows-based"
+		result.SLIType = SLITypeWindowsBased
+		windows := indicator.GetWindowsBased()
+		result.WindowPeriod = windows.GetWindowPeriod().AsDuration()
+		switch windows.GetWindowCriterion().(type) {
+		case *monitoringpb.WindowsBasedSli_GoodBadMetricFilter:
+			result.SLIMethod = "good-bad-metric-filter"
+		case *monitoringpb.WindowsBasedSli_GoodTotalRatioThreshold:
+			result.SLIMethod = "good-total-ratio-threshold"
+		case *monitoringpb.WindowsBasedSli_MetricMeanInRange:
+			result.SLIMethod = "metric-mean-in-range"
+		case *monitoringpb.WindowsBasedSli_MetricSumInRange:
+			result.SLIMethod = "metric-sum-in-range"
+		default:
+			result.SLIMethod = "unknown"
+		}
 	case *monitoringpb.ServiceLevelIndicator_BasicSli:
-		result.SLIType = "basic-sli"
+		result.SLIType = SLITypeBasic
+		switch indicator.GetBasicSli().GetSliCriteria().(type) {
+		case *monitoringpb.BasicSli_Availability:
+			result.SLIMethod = "availability"
+		case *monitoringpb.BasicSli_Latency:
+			result.SLIMethod = "latency"
+		default:
+			result.SLIMethod = "unknown"
+		}
 	default:
 		result.SLIType = "unknown"
 	}
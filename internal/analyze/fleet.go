@@ -0,0 +1,101 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// Target is one service of a fleet run. Service is a service ID or a full
+// service resource name. Exclusions apply to this service on top of
+// Options.Exclusions, and Revisions replaces Options.Revisions.
+type Target struct {
+	Project    string
+	Service    string
+	Exclusions []Exclusion
+	Revisions  *RevisionSource
+}
+
+// ServiceRun is the analysis of one fleet target. A service that could not
+// be analyzed at all has Err set and a Result with StatusError.
+type ServiceRun struct {
+	Target  Target
+	Result  Result
+	Sources Sources
+	OutDir  string
+	Err     error
+}
+
+// RunFleet analyzes each target with opts, one service after another, over
+// one shared window. opts.Project and opts.Service are ignored. Each service
+// gets its own directory under the returned output directory.
+func RunFleet(ctx context.Context, reader Reader, targets []Target, opts Options) ([]ServiceRun, string, error) {
+	if len(targets) == 0 {
+		return nil, "", fmt.Errorf("no services to analyze")
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	opts.Now = now.UTC()
+	start, end, err := ResolveWindow(opts.Start, opts.End, opts.Last, opts.Now)
+	if err != nil {
+		return nil, "", err
+	}
+	root := opts.OutDir
+	if root == "" {
+		stamp := start.In(time.UTC).Format("20060102-150405")
+		root = filepath.Join("out", "margin-analyze", fmt.Sprintf("%s-fleet", stamp))
+	}
+
+	seen := map[string]bool{}
+	dirs := map[string]bool{}
+	var runs []ServiceRun
+	for _, target := range targets {
+		serviceName, serviceID, err := NormalizeService(target.Project, target.Service)
+		if err != nil {
+			return nil, root, err
+		}
+		if seen[serviceName] {
+			continue
+		}
+		seen[serviceName] = true
+
+		dir := sanitizeSegment(serviceID)
+		if dirs[dir] {
+			dir = sanitizeSegment(target.Project + "-" + serviceID)
+		}
+		dirs[dir] = true
+
+		serviceOpts := opts
+		serviceOpts.Project = target.Project
+		serviceOpts.Service = target.Service
+		serviceOpts.OutDir = filepath.Join(root, dir)
+		serviceOpts.Exclusions = append(append([]Exclusion{}, opts.Exclusions...), target.Exclusions...)
+		if target.Revisions != nil {
+			serviceOpts.Revisions = target.Revisions
+		}
+		result, sources, outDir, err := Run(ctx, reader, serviceOpts)
+		if ctx.Err() != nil {
+			return nil, root, ctx.Err()
+		}
+		run := ServiceRun{Target: target, Result: result, Sources: sources, OutDir: outDir, Err: err}
+		if err != nil {
+			run.Result = Result{
+				SchemaVersion: SchemaVersion,
+				Project:       target.Project,
+				Service:       serviceID,
+				Window: Window{
+					Start:           start,
+					End:             end,
+					DurationSeconds: int64(end.Sub(start).Seconds()),
+				},
+				Status: StatusError,
+				Errors: []string{fmt.Sprintf("%s: %s", serviceID, err.Error())},
+			}
+		}
+		runs = append(runs, run)
+	}
+	return runs, root, nil
+}
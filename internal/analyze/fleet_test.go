@@ -0,0 +1,58 @@
+package analyze
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fleetReader fails to list the SLOs of the "gone" service.
+type fleetReader struct {
+	seriesReader
+}
+
+func (r *fleetReader) ListServiceLevelObjectives(ctx context.Context, serviceName string, max int) ([]SLO, error) {
+	if strings.HasSuffix(serviceName, "/gone") {
+		return nil, errors.New("service not found")
+	}
+	return r.seriesReader.ListServiceLevelObjectives(ctx, serviceName, max)
+}
+
+func TestRunFleet(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	runs, outDir, err := RunFleet(context.Background(), &fleetReader{}, []Target{
+		{Project: "demo", Service: "checkout"},
+		{Project: "demo", Service: "gone"},
+		{Project: "other", Service: "checkout"},
+		{Project: "demo", Service: "checkout"},
+	}, Options{Last: time.Hour, Now: now})
+	if err != nil {
+		t.Fatalf("run fleet: %v", err)
+	}
+	if outDir != filepath.Join("out", "margin-analyze", "20260302-110000-fleet") {
+		t.Fatalf("unexpected out dir %s", outDir)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("expected duplicate targets to be skipped, got %d runs", len(runs))
+	}
+
+	checkout, gone, other := runs[0], runs[1], runs[2]
+	if checkout.Err != nil || checkout.Result.Status != StatusOK || checkout.OutDir != filepath.Join(outDir, "checkout") {
+		t.Fatalf("unexpected checkout run %+v", checkout)
+	}
+	if other.OutDir != filepath.Join(outDir, "other-checkout") || other.Result.Project != "other" {
+		t.Fatalf("expected a separate directory for the other project, got %s", other.OutDir)
+	}
+	if gone.Err == nil || gone.Result.Status != StatusError || gone.Result.Service != "gone" {
+		t.Fatalf("expected an error result for the missing service, got %+v", gone)
+	}
+	if len(gone.Result.Errors) != 1 || gone.Result.Errors[0] != "gone: service not found" {
+		t.Fatalf("unexpected errors %v", gone.Result.Errors)
+	}
+	if !gone.Result.Window.End.Equal(checkout.Result.Window.End) || !checkout.Result.Window.End.Equal(other.Result.Window.End) {
+		t.Fatal("expected every service to share one window")
+	}
+}
@@ -0,0 +1,376 @@
+package analyze
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// ForecastSchemaVersion versions forecast.json independently of summary.json.
+const ForecastSchemaVersion = "1.0"
+
+// Forecast models fitted to the recent burn rate.
+const (
+	ModelLinear   = "linear"
+	ModelEWMA     = "ewma"
+	ModelSeasonal = "seasonal"
+)
+
+// Forecast statuses, from best to worst.
+const (
+	ForecastOnTrack   = "on-track"
+	ForecastAtRisk    = "at-risk"
+	ForecastOffTrack  = "off-track"
+	ForecastExhausted = "exhausted"
+)
+
+const (
+	// forecastZ gives a two-sided 90% band around each model.
+	forecastZ = 1.645
+	ewmaAlpha = 0.3
+)
+
+type ForecastOptions struct {
+	Project string
+	Service string
+	// Lookback is the history the models are fitted to; default 7d.
+	Lookback time.Duration
+	// Step is the resolution of that history; default 1h.
+	Step time.Duration
+	// Horizon bounds forecasts for rolling SLOs, which have no period end;
+	// default 7d. Calendar SLOs are forecast to the end of their period.
+	Horizon time.Duration
+	MaxSLOs int
+	Only    *regexp.Regexp
+	// Now overrides the current time; zero means time.Now.
+	Now time.Time
+}
+
+type ForecastResult struct {
+	SchemaVersion   string        `json:"schemaVersion"`
+	Project         string        `json:"project"`
+	Service         string        `json:"service"`
+	GeneratedAt     time.Time     `json:"generatedAt"`
+	LookbackSeconds int64         `json:"lookbackSeconds"`
+	StepSeconds     int64         `json:"stepSeconds"`
+	Status          string        `json:"status"`
+	SLOs            []SLOForecast `json:"slos"`
+	Errors          []string      `json:"errors"`
+}
+
+type SLOForecast struct {
+	SLOResourceName string    `json:"sloResourceName"`
+	SLOID           string    `json:"sloId"`
+	DisplayName     string    `json:"displayName"`
+	Goal            float64   `json:"goal"`
+	PeriodStart     time.Time `json:"periodStart"`
+	HorizonEnd      time.Time `json:"horizonEnd"`
+	// RemainingPercentOfBudget is relative to the full period's budget.
+	RemainingPercentOfBudget float64         `json:"remainingPercentOfBudget"`
+	Status                   string          `json:"status"`
+	Models                   []ModelForecast `json:"models,omitempty"`
+	Error                    string          `json:"error,omitempty"`
+}
+
+// ModelForecast is one model's projection. Exhaustion follows the expected
+// burn rate, ExhaustionEarliest the upper band and ExhaustionLatest the
+// lower band; each is nil when the budget outlasts HorizonEnd.
+type ModelForecast struct {
+	Model              string     `json:"model"`
+	BurnRate           float64    `json:"burnRate"`
+	BurnRateLow        float64    `json:"burnRateLow"`
+	BurnRateHigh       float64    `json:"burnRateHigh"`
+	Exhaustion         *time.Time `json:"exhaustion"`
+	ExhaustionEarliest *time.Time `json:"exhaustionEarliest"`
+	ExhaustionLatest   *time.Time `json:"exhaustionLatest"`
+	// RemainingAtHorizonPercent is the budget left at HorizonEnd under the
+	// expected burn rate; 0 when it runs out first.
+	RemainingAtHorizonPercent float64 `json:"remainingAtHorizonPercentOfBudget"`
+}
+
+// Forecast fits each SLO's recent burn rate and projects when its period
+// budget runs out.
+func Forecast(ctx context.Context, reader Reader, opts ForecastOptions) (ForecastResult, error) {
+	if opts.Project == "" {
+		return ForecastResult{}, errors.New("--project is required")
+	}
+	if opts.Service == "" {
+		return ForecastResult{}, errors.New("--service is required")
+	}
+	if opts.Lookback <= 0 {
+		opts.Lookback = 7 * 24 * time.Hour
+	}
+	if opts.Step <= 0 {
+		opts.Step = time.Hour
+	}
+	if opts.Horizon <= 0 {
+		opts.Horizon = 7 * 24 * time.Hour
+	}
+	if opts.MaxSLOs <= 0 {
+		opts.MaxSLOs = 50
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
+
+	serviceName, serviceID, err := NormalizeService(opts.Project, opts.Service)
+	if err != nil {
+		return ForecastResult{}, err
+	}
+	slos, err := reader.ListServiceLevelObjectives(ctx, serviceName, opts.MaxSLOs)
+	if err != nil {
+		return ForecastResult{}, err
+	}
+	slos = filterSLOs(slos, opts.Only)
+	sort.Slice(slos, func(i, j int) bool {
+		if slos[i].DisplayName == slos[j].DisplayName {
+			return slos[i].Name < slos[j].Name
+		}
+		return slos[i].DisplayName < slos[j].DisplayName
+	})
+
+	result := ForecastResult{
+		SchemaVersion:   ForecastSchemaVersion,
+		Project:         opts.Project,
+		Service:         serviceID,
+		GeneratedAt:     now,
+		LookbackSeconds: int64(opts.Lookback.Seconds()),
+		StepSeconds:     int64(opts.Step.Seconds()),
+		Status:          ForecastOnTrack,
+	}
+	for _, slo := range slos {
+		item, err := forecastSLO(ctx, reader, opts, slo, now)
+		if err != nil {
+			item.Status = StatusError
+			item.Error = err.Error()
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", slo.DisplayName, err.Error()))
+		} else if forecastRank(item.Status) > forecastRank(result.Status) {
+			result.Status = item.Status
+		}
+		result.SLOs = append(result.SLOs, item)
+	}
+	return result, nil
+}
+
+func forecastSLO(ctx context.Context, reader Reader, opts ForecastOptions, slo SLO, now time.Time) (SLOForecast, error) {
+	item := SLOForecast{
+		SLOResourceName: slo.Name,
+		SLOID:           extractSLOID(slo.Name),
+		DisplayName:     slo.DisplayName,
+		Goal:            round4(slo.Goal),
+	}
+	if supported, note := supportedSLO(slo); !supported {
+		return item, errors.New(note)
+	}
+	allowedBad := 1 - slo.Goal
+	if allowedBad <= 0 {
+		return item, errors.New("goal is 100%; there is no error budget")
+	}
+
+	start, _, err := PeriodWindow(slo, now)
+	if err != nil {
+		return item, err
+	}
+	length := PeriodLength(slo, start)
+	item.PeriodStart = start
+	item.HorizonEnd = now.Add(opts.Horizon)
+	if slo.Calendar != nil {
+		item.HorizonEnd = start.Add(length)
+	}
+
+	compliance, err := reader.FetchCompliance(ctx, opts.Project, slo.Name, start, now)
+	if err != nil {
+		return item, err
+	}
+	compliance, _ = clamp01(compliance)
+	remaining := 100 - (1-compliance)*now.Sub(start).Seconds()/length.Seconds()/allowedBad*100
+	item.RemainingPercentOfBudget = round4(remaining)
+
+	samples, err := reader.FetchSeries(ctx, opts.Project, slo.Name, now.Add(-opts.Lookback), now, opts.Step)
+	if err != nil {
+		return item, err
+	}
+	if len(samples) < 3 {
+		return item, fmt.Errorf("only %d step(s) of history in the last %s; need at least 3", len(samples), opts.Lookback)
+	}
+	rates := make([]float64, len(samples))
+	times := make([]time.Time, len(samples))
+	for i, sample := range samples {
+		c, _ := clamp01(sample.Compliance)
+		rates[i] = (1 - c) / allowedBad
+		times[i] = sample.End
+	}
+
+	type fitted struct {
+		name  string
+		model burnModel
+	}
+	models := []fitted{{ModelLinear, fitLinear(times, rates)}, {ModelEWMA, fitEWMA(rates)}}
+	if seasonal, ok := fitSeasonal(times, rates, opts.Step); ok {
+		models = append(models, fitted{ModelSeasonal, seasonal})
+	}
+
+	item.Status = ForecastOnTrack
+	if remaining <= 0 {
+		item.Status = ForecastExhausted
+	}
+	for _, model := range models {
+		forecast := projectModel(model.name, model.model, remaining, now, item.HorizonEnd, opts.Step, length)
+		item.Models = append(item.Models, forecast)
+		switch {
+		case item.Status == ForecastExhausted:
+		case forecast.Exhaustion != nil:
+			item.Status = ForecastOffTrack
+		case forecast.ExhaustionEarliest != nil && item.Status == ForecastOnTrack:
+			item.Status = ForecastAtRisk
+		}
+	}
+	return item, nil
+}
+
+// burnModel predicts the burn rate of the step ending at t; sigma is the
+// standard deviation of its residuals over the history.
+type burnModel struct {
+	predict func(t time.Time) float64
+	sigma   float64
+}
+
+// fitLinear fits a least-squares trend line through the history.
+func fitLinear(times []time.Time, rates []float64) burnModel {
+	origin := times[0]
+	var sumX, sumY, sumXX, sumXY float64
+	for i, rate := range rates {
+		x := times[i].Sub(origin).Hours()
+		sumX += x
+		sumY += rate
+		sumXX += x * x
+		sumXY += x * rate
+	}
+	n := float64(len(rates))
+	slope := 0.0
+	if denom := n*sumXX - sumX*sumX; denom != 0 {
+		slope = (n*sumXY - sumX*sumY) / denom
+	}
+	intercept := (sumY - slope*sumX) / n
+	predict := func(t time.Time) float64 { return intercept + slope*t.Sub(origin).Hours() }
+	return burnModel{predict: predict, sigma: residualSigma(times, rates, predict)}
+}
+
+// fitEWMA forecasts the exponentially weighted level of the history; sigma
+// comes from its one-step-ahead errors.
+func fitEWMA(rates []float64) burnModel {
+	level := rates[0]
+	var sumSq float64
+	for _, rate := range rates[1:] {
+		diff := rate - level
+		sumSq += diff * diff
+		level += ewmaAlpha * diff
+	}
+	sigma := math.Sqrt(sumSq / float64(len(rates)-1))
+	return burnModel{predict: func(time.Time) float64 { return level }, sigma: sigma}
+}
+
+// fitSeasonal averages the history by hour of day (UTC). It needs at least
+// one full day of history.
+func fitSeasonal(times []time.Time, rates []float64, step time.Duration) (burnModel, bool) {
+	if step > time.Hour || times[len(times)-1].Sub(times[0]) < 24*time.Hour-step {
+		return burnModel{}, false
+	}
+	var sum, count [24]float64
+	for i, rate := range rates {
+		hour := times[i].Add(-step).Hour()
+		sum[hour] += rate
+		count[hour]++
+	}
+	var profile [24]float64
+	for hour := range profile {
+		if count[hour] == 0 {
+			return burnModel{}, false
+		}
+		profile[hour] = sum[hour] / count[hour]
+	}
+	predict := func(t time.Time) float64 { return profile[t.Add(-step).UTC().Hour()] }
+	return burnModel{predict: predict, sigma: residualSigma(times, rates, predict)}, true
+}
+
+func residualSigma(times []time.Time, rates []float64, predict func(time.Time) float64) float64 {
+	var sumSq float64
+	for i, rate := range rates {
+		diff := rate - predict(times[i])
+		sumSq += diff * diff
+	}
+	return math.Sqrt(sumSq / float64(len(rates)))
+}
+
+func projectModel(name string, model burnModel, remaining float64, now, horizonEnd time.Time, step, length time.Duration) ModelForecast {
+	band := forecastZ * model.sigma
+	expected, mean, left := projectBudget(model, 0, remaining, now, horizonEnd, step, length)
+	earliest, high, _ := projectBudget(model, band, remaining, now, horizonEnd, step, length)
+	latest, low, _ := projectBudget(model, -band, remaining, now, horizonEnd, step, length)
+	return ModelForecast{
+		Model:                     name,
+		BurnRate:                  round4(mean),
+		BurnRateLow:               round4(low),
+		BurnRateHigh:              round4(high),
+		Exhaustion:                expected,
+		ExhaustionEarliest:        earliest,
+		ExhaustionLatest:          latest,
+		RemainingAtHorizonPercent: round4(left),
+	}
+}
+
+// projectBudget walks from now to horizonEnd one step at a time, spending
+// burnRate * stepLength / periodLength of the budget per step. It returns
+// when the budget runs out, the mean burn rate, and the budget left.
+func projectBudget(model burnModel, offset, remaining float64, now, horizonEnd time.Time, step, length time.Duration) (*time.Time, float64, float64) {
+	left := math.Max(0, remaining)
+	var weighted, total float64
+	var exhaustion *time.Time
+	if remaining <= 0 {
+		exhaustion = &now
+	}
+	for t := now; t.Before(horizonEnd); t = t.Add(step) {
+		d := step
+		if next := t.Add(step); next.After(horizonEnd) {
+			d = horizonEnd.Sub(t)
+		}
+		rate := math.Max(0, model.predict(t.Add(d))+offset)
+		weighted += rate * d.Seconds()
+		total += d.Seconds()
+		if exhaustion != nil {
+			continue
+		}
+		spent := rate * d.Seconds() / length.Seconds() * 100
+		if spent >= left && spent > 0 {
+			at := t.Add(time.Duration(float64(d) * left / spent)).Round(time.Second)
+			exhaustion = &at
+			left = 0
+			continue
+		}
+		left -= spent
+	}
+	mean := 0.0
+	if total > 0 {
+		mean = weighted / total
+	}
+	return exhaustion, mean, left
+}
+
+func forecastRank(status string) int {
+	switch status {
+	case ForecastAtRisk:
+		return 1
+	case ForecastOffTrack:
+		return 2
+	case ForecastExhausted:
+		return 3
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,112 @@
+package analyze
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFitLinear(t *testing.T) {
+	origin := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var times []time.Time
+	var rates []float64
+	for i := 0; i < 5; i++ {
+		times = append(times, origin.Add(time.Duration(i)*time.Hour))
+		rates = append(rates, 1+0.5*float64(i))
+	}
+	model := fitLinear(times, rates)
+	if got := model.predict(origin.Add(10 * time.Hour)); math.Abs(got-6) > 1e-9 {
+		t.Fatalf("expected 6 at +10h, got %v", got)
+	}
+	if model.sigma > 1e-9 {
+		t.Fatalf("expected no residuals, got %v", model.sigma)
+	}
+}
+
+func TestFitSeasonal(t *testing.T) {
+	origin := time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+	var times []time.Time
+	var rates []float64
+	for i := 0; i < 48; i++ {
+		end := origin.Add(time.Duration(i) * time.Hour)
+		rate := 0.0
+		if end.Add(-time.Hour).Hour() == 9 {
+			rate = 12
+		}
+		times = append(times, end)
+		rates = append(rates, rate)
+	}
+	model, ok := fitSeasonal(times, rates, time.Hour)
+	if !ok {
+		t.Fatalf("expected a seasonal fit from two days of history")
+	}
+	if got := model.predict(time.Date(2025, 1, 5, 10, 0, 0, 0, time.UTC)); got != 12 {
+		t.Fatalf("expected the 09:00 step to burn 12x, got %v", got)
+	}
+	if _, ok := fitSeasonal(times[:12], rates[:12], time.Hour); ok {
+		t.Fatalf("expected no seasonal fit from half a day")
+	}
+}
+
+func TestProjectBudget(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	constant := burnModel{predict: func(time.Time) float64 { return 2 }}
+	// 10% of a 30d budget at 2x lasts 1.5 days.
+	exhaustion, mean, left := projectBudget(constant, 0, 10, now, now.Add(7*24*time.Hour), time.Hour, 30*24*time.Hour)
+	want := now.Add(36 * time.Hour)
+	if exhaustion == nil || !exhaustion.Equal(want) {
+		t.Fatalf("expected exhaustion at %s, got %v", want, exhaustion)
+	}
+	if mean != 2 || left != 0 {
+		t.Fatalf("unexpected mean %v or left %v", mean, left)
+	}
+
+	exhaustion, _, left = projectBudget(constant, -2, 10, now, now.Add(24*time.Hour), time.Hour, 30*24*time.Hour)
+	if exhaustion != nil || left != 10 {
+		t.Fatalf("expected no burn below zero, got %v with %v left", exhaustion, left)
+	}
+}
+
+type forecastReader struct {
+	periodReader
+	rate func(end time.Time) float64
+}
+
+func (r *forecastReader) FetchSeries(ctx context.Context, project string, sloName string, start, end time.Time, step time.Duration) ([]Sample, error) {
+	var samples []Sample
+	for t := start.Add(step); !t.After(end); t = t.Add(step) {
+		samples = append(samples, Sample{End: t, Compliance: 1 - r.rate(t)*0.001})
+	}
+	return samples, nil
+}
+
+func TestForecast(t *testing.T) {
+	now := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	reader := &forecastReader{rate: func(time.Time) float64 { return 4 }}
+	result, err := Forecast(context.Background(), reader, ForecastOptions{
+		Project: "demo",
+		Service: "checkout",
+		Now:     now,
+	})
+	if err != nil {
+		t.Fatalf("forecast: %v", err)
+	}
+	if len(result.SLOs) != 1 {
+		t.Fatalf("expected one SLO, got %+v", result.SLOs)
+	}
+	slo := result.SLOs[0]
+	// Half the budget is left; at 4x a 30d budget lasts 3.75 more days.
+	if slo.RemainingPercentOfBudget != 50 || slo.Status != ForecastOffTrack || result.Status != ForecastOffTrack {
+		t.Fatalf("unexpected forecast %+v", slo)
+	}
+	if len(slo.Models) != 3 {
+		t.Fatalf("expected linear, ewma, and seasonal models, got %+v", slo.Models)
+	}
+	want := now.Add(90 * time.Hour)
+	for _, model := range slo.Models {
+		if model.BurnRate != 4 || model.Exhaustion == nil || !model.Exhaustion.Equal(want) {
+			t.Fatalf("unexpected %s forecast %+v", model.Model, model)
+		}
+	}
+}
@@ -2,7 +2,7 @@ package analyze
 
 import "time"
 
-const SchemaVersion = "1.1"
+const SchemaVersion = "1.9"
 
 type Result struct {
 	SchemaVersion string      `json:"schemaVersion"`
@@ -11,7 +11,16 @@ type Result struct {
 	Window        Window      `json:"window"`
 	Status        string      `json:"status"`
 	SLOs          []SLOResult `json:"slos"`
-	Errors        []string    `json:"errors"`
+	// Exclusions are the excluded intervals within the window, such as
+	// planned maintenance.
+	Exclusions []Exclusion `json:"exclusions,omitempty"`
+	// Changes are the deploys, config pushes, and flag flips in the window
+	// and the change lag before it.
+	Changes []ChangeEvent `json:"changes,omitempty"`
+	// ChangeLagSeconds is how long before a burn spike a change counts as
+	// preceding it.
+	ChangeLagSeconds int64    `json:"changeLagSeconds,omitempty"`
+	Errors           []string `json:"errors"`
 }
 
 type Window struct {
@@ -21,19 +30,81 @@ type Window struct {
 }
 
 type SLOResult struct {
-	SLOResourceName         string   `json:"sloResourceName"`
-	SLOID                   string   `json:"sloId"`
-	DisplayName             string   `json:"displayName"`
-	Goal                    float64  `json:"goal"`
-	RollingPeriodDays       int64    `json:"rollingPeriodDays"`
-	CalendarPeriod          *string  `json:"calendarPeriod"`
-	Compliance              float64  `json:"compliance"`
-	BadFraction             float64  `json:"badFraction"`
-	AllowedBadFraction      float64  `json:"allowedBadFraction"`
-	ConsumedPercentOfBudget float64  `json:"consumedPercentOfBudget"`
-	Status                  string   `json:"status"`
-	Explain                 *Explain `json:"explain,omitempty"`
-	Error                   string   `json:"error,omitempty"`
+	SLOResourceName         string        `json:"sloResourceName"`
+	SLOID                   string        `json:"sloId"`
+	DisplayName             string        `json:"displayName"`
+	Goal                    float64       `json:"goal"`
+	RollingPeriodDays       int64         `json:"rollingPeriodDays"`
+	CalendarPeriod          *string       `json:"calendarPeriod"`
+	SLIType                 string        `json:"sliType,omitempty"`
+	Compliance              float64       `json:"compliance"`
+	BadFraction             float64       `json:"badFraction"`
+	AllowedBadFraction      float64       `json:"allowedBadFraction"`
+	ConsumedPercentOfBudget float64       `json:"consumedPercentOfBudget"`
+	Status                  string        `json:"status"`
+	Adjusted                *Adjusted     `json:"adjusted,omitempty"`
+	Windows                 *WindowCounts `json:"windows,omitempty"`
+	Burndown                *Burndown     `json:"burndown,omitempty"`
+	Spikes                  []BurnSpike   `json:"spikes,omitempty"`
+	Period                  *Period       `json:"period,omitempty"`
+	Breakdown               *Breakdown    `json:"breakdown,omitempty"`
+	Detection               *Detection    `json:"detection,omitempty"`
+	Explain                 *Explain      `json:"explain,omitempty"`
+	Error                   string        `json:"error,omitempty"`
+	// Notes name optional sections, such as the period budget, that could
+	// not be read. They do not change the SLO's or the run's status.
+	Notes []string `json:"notes,omitempty"`
+}
+
+// Burndown splits the analysis window into steps. Buckets are weighted by
+// time, so CumulativeConsumedPercent of the last point approximates the
+// window's ConsumedPercentOfBudget when traffic is steady.
+type Burndown struct {
+	StepSeconds  int64           `json:"stepSeconds"`
+	PeakBurnRate float64         `json:"peakBurnRate"`
+	PeakAt       *time.Time      `json:"peakAt"`
+	Points       []BurndownPoint `json:"points"`
+}
+
+type BurndownPoint struct {
+	Start                     time.Time `json:"start"`
+	End                       time.Time `json:"end"`
+	BadFraction               float64   `json:"badFraction"`
+	BurnRate                  float64   `json:"burnRate"`
+	CumulativeConsumedPercent float64   `json:"cumulativeConsumedPercentOfBudget"`
+	// Changes are the Result's changes that fall in this step.
+	Changes []ChangeEvent `json:"changes,omitempty"`
+}
+
+// WindowCounts is the window-local budget of a windows-based SLI in whole
+// windows rather than fractions.
+type WindowCounts struct {
+	WindowSeconds int64   `json:"windowSeconds"`
+	Total         int64   `json:"total"`
+	Good          int64   `json:"good"`
+	Bad           int64   `json:"bad"`
+	AllowedBad    float64 `json:"allowedBad"`
+}
+
+// Period is the error budget of the SLO's own compliance period, from the
+// start of the rolling or calendar period to the time of analysis. Budget
+// percentages are relative to the whole period's budget, so early in a
+// calendar period they stay small.
+type Period struct {
+	Start                   time.Time `json:"start"`
+	End                     time.Time `json:"end"`
+	Compliance              float64   `json:"compliance"`
+	ConsumedPercentOfBudget float64   `json:"consumedPercentOfBudget"`
+	// RemainingPercentOfBudget goes negative once the budget is exhausted.
+	RemainingPercentOfBudget float64 `json:"remainingPercentOfBudget"`
+	// WindowConsumedPercentOfBudget is the part of ConsumedPercentOfBudget
+	// spent during the analysis window, assuming steady traffic.
+	WindowConsumedPercentOfBudget float64 `json:"windowConsumedPercentOfBudget"`
+	// CurrentBurnRate is measured over the last BurnRateLookback.
+	CurrentBurnRate float64 `json:"currentBurnRate"`
+	// ProjectedExhaustion is when the remaining budget runs out at
+	// CurrentBurnRate; nil when nothing is burning.
+	ProjectedExhaustion *time.Time `json:"projectedExhaustion"`
 }
 
 type Explain struct {
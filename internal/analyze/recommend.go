@@ -0,0 +1,372 @@
+package analyze
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/bayneri/margin/internal/planner"
+	"github.com/bayneri/margin/internal/spec"
+)
+
+// RecommendSchemaVersion versions recommend.json independently of
+// summary.json.
+const RecommendSchemaVersion = "1.0"
+
+var (
+	// DefaultObjectives are tried for every SLO, along with its own.
+	DefaultObjectives = []float64{90, 95, 99, 99.5, 99.9, 99.95, 99.99}
+	// DefaultThresholds are tried for latency SLOs, along with their own.
+	DefaultThresholds = []time.Duration{
+		100 * time.Millisecond, 200 * time.Millisecond, 250 * time.Millisecond, 300 * time.Millisecond,
+		400 * time.Millisecond, 500 * time.Millisecond, 750 * time.Millisecond, time.Second,
+		1500 * time.Millisecond, 2 * time.Second, 3 * time.Second, 5 * time.Second, 10 * time.Second,
+	}
+)
+
+// HistoryReader reads the metrics behind spec SLIs over long lookbacks.
+// GCPReader implements it.
+type HistoryReader interface {
+	FetchEvents(ctx context.Context, project string, slo SLO, start, end time.Time, step time.Duration) ([]Group, error)
+	FetchHistograms(ctx context.Context, project, filter string, start, end time.Time, step time.Duration) ([]Histogram, error)
+}
+
+// Histogram is a distribution summed over the step ending at End.
+// UpperBounds holds the upper bound of each bucket, the last one unbounded.
+type Histogram struct {
+	End         time.Time
+	UpperBounds []float64
+	Counts      []float64
+}
+
+// Within counts the events in [0, max] the way margin analyze -f does, so
+// both read the same compliance for a threshold.
+func (h Histogram) Within(max float64) float64 {
+	return countWithin(h.UpperBounds, h.Counts, 0, max)
+}
+
+func (h Histogram) Total() float64 {
+	var total float64
+	for _, count := range h.Counts {
+		total += count
+	}
+	return total
+}
+
+type RecommendOptions struct {
+	// Lookback is the history to evaluate; default 90d.
+	Lookback time.Duration
+	// Step is the resolution of that history; default 1h.
+	Step time.Duration
+	// TargetPercent is the share of periods in which a recommended objective
+	// must have been met; default 90.
+	TargetPercent float64
+	// Now overrides the current time; zero means time.Now.
+	Now time.Time
+}
+
+type RecommendResult struct {
+	SchemaVersion   string              `json:"schemaVersion"`
+	Project         string              `json:"project"`
+	Service         string              `json:"service"`
+	GeneratedAt     time.Time           `json:"generatedAt"`
+	LookbackSeconds int64               `json:"lookbackSeconds"`
+	StepSeconds     int64               `json:"stepSeconds"`
+	TargetPercent   float64             `json:"targetPercent"`
+	SLOs            []SLORecommendation `json:"slos"`
+	Errors          []string            `json:"errors"`
+}
+
+// SLORecommendation compares candidate objectives, and thresholds for
+// latency SLOs, over rolling periods of the SLO's length that end once a
+// day. A nil RecommendedObjective means no candidate reached the target.
+type SLORecommendation struct {
+	Name                 string      `json:"name"`
+	SLOID                string      `json:"sloId"`
+	PeriodSeconds        int64       `json:"periodSeconds"`
+	Periods              int         `json:"periods"`
+	Objective            float64     `json:"objective"`
+	RecommendedObjective *float64    `json:"recommendedObjective"`
+	Threshold            string      `json:"threshold,omitempty"`
+	RecommendedThreshold string      `json:"recommendedThreshold,omitempty"`
+	Objectives           []Candidate `json:"objectives,omitempty"`
+	Thresholds           []Candidate `json:"thresholds,omitempty"`
+	Notes                []string    `json:"notes,omitempty"`
+	Error                string      `json:"error,omitempty"`
+}
+
+// Candidate is how one objective and threshold would have fared.
+// Exhaustions counts runs of consecutive periods that missed the objective,
+// and AlertFirings the times the spec's alert tiers would have fired.
+type Candidate struct {
+	Objective       float64 `json:"objective"`
+	Threshold       string  `json:"threshold,omitempty"`
+	MetPercent      float64 `json:"metPercent"`
+	Exhaustions     int     `json:"budgetExhaustions"`
+	AlertFirings    int     `json:"alertFirings"`
+	WorstCompliance float64 `json:"worstCompliance"`
+}
+
+// Recommend evaluates the SLOs of a plan against their history and picks,
+// per SLO, the highest objective met in at least TargetPercent of periods
+// and, for latency SLOs, the lowest threshold that meets that objective as
+// often.
+func Recommend(ctx context.Context, reader HistoryReader, plan planner.Plan, opts RecommendOptions) (RecommendResult, error) {
+	if opts.Lookback <= 0 {
+		opts.Lookback = 90 * 24 * time.Hour
+	}
+	if opts.Step <= 0 {
+		opts.Step = time.Hour
+	}
+	if opts.Step > opts.Lookback {
+		return RecommendResult{}, errors.New("step must not be longer than the lookback")
+	}
+	if opts.TargetPercent <= 0 || opts.TargetPercent > 100 {
+		opts.TargetPercent = 90
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	end := now.UTC().Truncate(opts.Step)
+	start := end.Add(-opts.Lookback)
+
+	slos, err := SpecSLOs(plan)
+	if err != nil {
+		return RecommendResult{}, err
+	}
+	result := RecommendResult{
+		SchemaVersion:   RecommendSchemaVersion,
+		Project:         plan.Project,
+		Service:         plan.ServiceID,
+		GeneratedAt:     now.UTC(),
+		LookbackSeconds: int64(opts.Lookback.Seconds()),
+		StepSeconds:     int64(opts.Step.Seconds()),
+		TargetPercent:   opts.TargetPercent,
+	}
+	for i, slo := range slos {
+		sloPlan := plan.SLOs[i]
+		item := SLORecommendation{Name: sloPlan.Name, SLOID: extractSLOID(slo.Name), Objective: sloPlan.Objective}
+		if err := recommendSLO(ctx, reader, plan, sloPlan, slo, start, end, opts, &item); err != nil {
+			if ctx.Err() != nil {
+				return RecommendResult{}, ctx.Err()
+			}
+			item.Error = err.Error()
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", sloPlan.Name, err.Error()))
+		}
+		result.SLOs = append(result.SLOs, item)
+	}
+	return result, nil
+}
+
+func recommendSLO(ctx context.Context, reader HistoryReader, plan planner.Plan, sloPlan planner.SLOPlan, slo SLO, start, end time.Time, opts RecommendOptions, item *SLORecommendation) error {
+	period := PeriodLength(slo, start)
+	if period <= 0 {
+		return fmt.Errorf("SLO has no rolling or calendar period")
+	}
+	if slo.Calendar != nil {
+		label, _ := spec.FormatWindow(period)
+		item.Notes = append(item.Notes, fmt.Sprintf("calendar periods are evaluated as rolling %s windows", label))
+	}
+	if period > opts.Lookback {
+		label, _ := spec.FormatWindow(period)
+		item.Notes = append(item.Notes, fmt.Sprintf("the lookback is shorter than the %s period, so the whole lookback is one period", label))
+		period = opts.Lookback
+	}
+	item.PeriodSeconds = int64(period.Seconds())
+
+	policies, err := AlertPolicies(plan.Alerts, sloPlan.Name)
+	if err != nil {
+		return err
+	}
+	short := false
+	for _, policy := range policies {
+		for _, window := range policy.Windows {
+			short = short || window < opts.Step
+		}
+	}
+	if short {
+		item.Notes = append(item.Notes, fmt.Sprintf("alert windows shorter than the %s step are evaluated over one step", opts.Step))
+	}
+
+	if slo.SLIMethod != "distribution-cut" {
+		groups, err := reader.FetchEvents(ctx, plan.Project, slo, start, end, opts.Step)
+		if err != nil {
+			return err
+		}
+		steps := denseSteps(groups, start, end, opts.Step)
+		if !hasEvents(steps) {
+			return fmt.Errorf("no events in the lookback")
+		}
+		item.Objectives = objectiveCandidates(steps, opts.Step, period, sloPlan.Objective, "", policies)
+		item.Periods = countPeriods(steps, opts.Step, period)
+		item.RecommendedObjective = highestMet(item.Objectives, opts.TargetPercent)
+		return nil
+	}
+
+	current, err := time.ParseDuration(sloPlan.SLI.Threshold)
+	if err != nil {
+		return fmt.Errorf("invalid threshold %q", sloPlan.SLI.Threshold)
+	}
+	item.Threshold = spec.FormatThreshold(current)
+	histograms, err := reader.FetchHistograms(ctx, plan.Project, slo.DistributionFilter, start, end, opts.Step)
+	if err != nil {
+		return err
+	}
+	stepsAt := func(threshold time.Duration) []Group {
+		groups := make([]Group, 0, len(histograms))
+		for _, h := range histograms {
+			// buildIndicator sets the range in seconds; match it.
+			groups = append(groups, Group{End: h.End, Good: h.Within(threshold.Seconds()), Total: h.Total()})
+		}
+		return denseSteps(groups, start, end, opts.Step)
+	}
+	steps := stepsAt(current)
+	if !hasEvents(steps) {
+		return fmt.Errorf("no events in the lookback")
+	}
+	item.Notes = append(item.Notes, "latency buckets that straddle a threshold count as bad")
+	item.Periods = countPeriods(steps, opts.Step, period)
+	item.Objectives = objectiveCandidates(steps, opts.Step, period, sloPlan.Objective, item.Threshold, policies)
+	item.RecommendedObjective = highestMet(item.Objectives, opts.TargetPercent)
+
+	objective := sloPlan.Objective
+	if item.RecommendedObjective != nil {
+		objective = *item.RecommendedObjective
+	}
+	thresholds := append([]time.Duration{current}, DefaultThresholds...)
+	sort.Slice(thresholds, func(i, j int) bool { return thresholds[i] < thresholds[j] })
+	for i, threshold := range thresholds {
+		if i > 0 && threshold == thresholds[i-1] {
+			continue
+		}
+		candidate := evaluateCandidate(stepsAt(threshold), opts.Step, period, objective, policies)
+		candidate.Threshold = spec.FormatThreshold(threshold)
+		item.Thresholds = append(item.Thresholds, candidate)
+	}
+	for _, candidate := range item.Thresholds {
+		if candidate.MetPercent >= opts.TargetPercent {
+			item.RecommendedThreshold = candidate.Threshold
+			break
+		}
+	}
+	return nil
+}
+
+func objectiveCandidates(steps []Group, step, period time.Duration, current float64, threshold string, policies []AlertPolicy) []Candidate {
+	objectives := append([]float64{current}, DefaultObjectives...)
+	sort.Float64s(objectives)
+	var out []Candidate
+	for i, objective := range objectives {
+		if i > 0 && objective == objectives[i-1] {
+			continue
+		}
+		candidate := evaluateCandidate(steps, step, period, objective, policies)
+		candidate.Threshold = threshold
+		out = append(out, candidate)
+	}
+	return out
+}
+
+func highestMet(candidates []Candidate, target float64) *float64 {
+	for i := len(candidates) - 1; i >= 0; i-- {
+		if candidates[i].MetPercent >= target {
+			objective := candidates[i].Objective
+			return &objective
+		}
+	}
+	return nil
+}
+
+// evaluateCandidate measures rolling periods that end once a day (or once a
+// period, for periods shorter than a day) and replays the alert tiers.
+func evaluateCandidate(steps []Group, step, period time.Duration, objective float64, policies []AlertPolicy) Candidate {
+	goal := objective / 100
+	candidate := Candidate{Objective: objective, WorstCompliance: 1}
+	var periods, met int
+	missed := false
+	forEachPeriod(steps, step, period, func(good, total float64) {
+		compliance := good / total
+		periods++
+		if compliance >= goal {
+			met++
+			missed = false
+		} else {
+			if !missed {
+				candidate.Exhaustions++
+			}
+			missed = true
+		}
+		candidate.WorstCompliance = math.Min(candidate.WorstCompliance, compliance)
+	})
+	if periods > 0 {
+		candidate.MetPercent = round4(float64(met) / float64(periods) * 100)
+	}
+	candidate.WorstCompliance = round4(candidate.WorstCompliance)
+	candidate.AlertFirings = len(SimulateAlerts(steps, step, goal, policies))
+	return candidate
+}
+
+func countPeriods(steps []Group, step, period time.Duration) int {
+	count := 0
+	forEachPeriod(steps, step, period, func(good, total float64) { count++ })
+	return count
+}
+
+// forEachPeriod visits, oldest first, the rolling periods that end on the
+// last step and every day before it, skipping periods without events.
+func forEachPeriod(steps []Group, step, period time.Duration, visit func(good, total float64)) {
+	k := stepsIn(period, step)
+	if k > len(steps) {
+		k = len(steps)
+	}
+	stride := stepsIn(24*time.Hour, step)
+	if stride > k {
+		stride = k
+	}
+	var ends []int
+	for i := len(steps) - 1; i >= k-1; i -= stride {
+		ends = append(ends, i)
+	}
+	for j := len(ends) - 1; j >= 0; j-- {
+		var good, total float64
+		for _, s := range steps[ends[j]-k+1 : ends[j]+1] {
+			good += s.Good
+			total += s.Total
+		}
+		if total > 0 {
+			visit(good, total)
+		}
+	}
+}
+
+// denseSteps places groups on the grid of steps ending at start+step through
+// end, leaving steps without data empty.
+func denseSteps(groups []Group, start, end time.Time, step time.Duration) []Group {
+	n := int(end.Sub(start) / step)
+	out := make([]Group, n)
+	for i := range out {
+		out[i].End = start.Add(time.Duration(i+1) * step)
+	}
+	for _, group := range groups {
+		i := int(math.Round(float64(group.End.Sub(start))/float64(step))) - 1
+		if i < 0 || i >= n {
+			continue
+		}
+		out[i].Good += group.Good
+		out[i].Total += group.Total
+	}
+	return out
+}
+
+func hasEvents(steps []Group) bool {
+	for _, s := range steps {
+		if s.Total > 0 {
+			return true
+		}
+	}
+	return false
+}
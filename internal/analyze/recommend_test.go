@@ -0,0 +1,117 @@
+package analyze
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bayneri/margin/internal/planner"
+	"github.com/bayneri/margin/internal/spec"
+)
+
+const recommendSpec = `apiVersion: margin/v2
+kind: ServiceSLO
+metadata:
+  name: checkout-api
+  service: cloud-run
+  project: demo
+slos:
+  - name: availability
+    objective: 99.9
+    window: 7d
+    sli:
+      requestBased:
+        good:
+          metric: run.googleapis.com/request_count
+          filter: metric.label.response_code_class = "2xx"
+        total:
+          metric: run.googleapis.com/request_count
+  - name: latency
+    objective: 99
+    window: 7d
+    sli:
+      latency:
+        metric: run.googleapis.com/request_latencies
+        threshold: 500ms
+`
+
+// historyReader serves 1000 requests an hour with 0.07% errors, plus three
+// hours of 10% errors starting at spike. 98% of requests take up to 0.2s,
+// another 1.5% up to 0.4s, and the rest longer.
+type historyReader struct {
+	spike time.Time
+}
+
+func (r historyReader) FetchEvents(ctx context.Context, project string, slo SLO, start, end time.Time, step time.Duration) ([]Group, error) {
+	var out []Group
+	for at := start.Add(step); !at.After(end); at = at.Add(step) {
+		group := Group{End: at, Good: 999.3, Total: 1000}
+		if !at.Before(r.spike) && at.Before(r.spike.Add(3*time.Hour)) {
+			group.Good = 900
+		}
+		out = append(out, group)
+	}
+	return out, nil
+}
+
+func (r historyReader) FetchHistograms(ctx context.Context, project, filter string, start, end time.Time, step time.Duration) ([]Histogram, error) {
+	var out []Histogram
+	for at := start.Add(step); !at.After(end); at = at.Add(step) {
+		out = append(out, Histogram{End: at, UpperBounds: []float64{0.2, 0.4, 0.8, 1e9}, Counts: []float64{980, 15, 4, 1}})
+	}
+	return out, nil
+}
+
+func TestRecommend(t *testing.T) {
+	doc, err := spec.Parse([]byte(recommendSpec))
+	if err != nil {
+		t.Fatalf("parse spec: %v", err)
+	}
+	now := time.Date(2026, 3, 29, 0, 30, 0, 0, time.UTC)
+	end := now.Truncate(time.Hour)
+	start := end.Add(-28 * 24 * time.Hour)
+	result, err := Recommend(context.Background(), historyReader{spike: start.Add(480 * time.Hour)}, planner.Build(doc, planner.Options{}), RecommendOptions{
+		Lookback: 28 * 24 * time.Hour,
+		Now:      now,
+	})
+	if err != nil {
+		t.Fatalf("recommend: %v", err)
+	}
+	if len(result.Errors) != 0 || len(result.SLOs) != 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	availability := result.SLOs[0]
+	if availability.Periods != 22 {
+		t.Fatalf("expected 22 daily 7d periods, got %d", availability.Periods)
+	}
+	if availability.RecommendedObjective == nil || *availability.RecommendedObjective != 99.5 {
+		t.Fatalf("expected 99.5 to be recommended, got %v", availability.RecommendedObjective)
+	}
+	current := findCandidate(t, availability.Objectives, 99.9)
+	if current.MetPercent != 63.6364 || current.Exhaustions != 1 || current.AlertFirings != 1 {
+		t.Fatalf("unexpected 99.9 candidate %+v", current)
+	}
+	if strict := findCandidate(t, availability.Objectives, 99.95); strict.MetPercent != 0 {
+		t.Fatalf("expected 99.95 to miss every period, got %+v", strict)
+	}
+
+	latency := result.SLOs[1]
+	if latency.RecommendedObjective == nil || *latency.RecommendedObjective != 99.5 {
+		t.Fatalf("expected latency to tighten to 99.5, got %v", latency.RecommendedObjective)
+	}
+	if latency.Threshold != "500ms" || latency.RecommendedThreshold != "400ms" {
+		t.Fatalf("expected the threshold to tighten to 400ms, got %s -> %s", latency.Threshold, latency.RecommendedThreshold)
+	}
+}
+
+func findCandidate(t *testing.T, candidates []Candidate, objective float64) Candidate {
+	t.Helper()
+	for _, candidate := range candidates {
+		if candidate.Objective == objective {
+			return candidate
+		}
+	}
+	t.Fatalf("no candidate for %v in %+v", objective, candidates)
+	return Candidate{}
+}
@@ -0,0 +1,309 @@
+package analyze
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	RecordingSchemaVersion = "1.0"
+	// RecordingFile holds the Recording of a recording directory; every
+	// other JSON file in it is one recorded call.
+	RecordingFile = "recording.json"
+)
+
+// Source is everything analyze reads from GCP. GCPReader, Recorder, and
+// Replay implement it.
+type Source interface {
+	Reader
+	AlertReader
+	EventReader
+	ChangeReader
+	ListServices(ctx context.Context, project string) ([]string, error)
+}
+
+// Recording describes a recording directory. RecordedAt is the time of
+// analysis, so relative windows such as --last resolve the same on replay.
+type Recording struct {
+	SchemaVersion string    `json:"schemaVersion"`
+	RecordedAt    time.Time `json:"recordedAt"`
+	Args          []string  `json:"args,omitempty"`
+}
+
+// recordedCall is one call and its response or error. Calls are looked up
+// by method and arguments.
+type recordedCall struct {
+	Method   string          `json:"method"`
+	Args     json.RawMessage `json:"args"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// recordedIncident keeps the policy that Incident leaves out of reports.
+type recordedIncident struct {
+	Incident
+	Policy string `json:"policy"`
+}
+
+// Recorder passes calls through to a source and saves every response, or
+// error, in a directory that Replay can serve them from.
+type Recorder struct {
+	source Source
+	dir    string
+}
+
+func NewRecorder(dir string, source Source, recording Recording) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create recording directory: %w", err)
+	}
+	recording.SchemaVersion = RecordingSchemaVersion
+	recording.RecordedAt = recording.RecordedAt.UTC()
+	if err := writeRecordingJSON(filepath.Join(dir, RecordingFile), recording); err != nil {
+		return nil, err
+	}
+	return &Recorder{source: source, dir: dir}, nil
+}
+
+func (r *Recorder) ListServices(ctx context.Context, project string) ([]string, error) {
+	return record(r, "list-services", []any{project}, func() ([]string, error) {
+		return r.source.ListServices(ctx, project)
+	})
+}
+
+func (r *Recorder) ListServiceLevelObjectives(ctx context.Context, serviceName string, max int) ([]SLO, error) {
+	return record(r, "list-slos", []any{serviceName, max}, func() ([]SLO, error) {
+		return r.source.ListServiceLevelObjectives(ctx, serviceName, max)
+	})
+}
+
+func (r *Recorder) FetchCompliance(ctx context.Context, project string, sloName string, start, end time.Time) (float64, error) {
+	return record(r, "compliance", []any{project, sloName, start, end}, func() (float64, error) {
+		return r.source.FetchCompliance(ctx, project, sloName, start, end)
+	})
+}
+
+func (r *Recorder) FetchSeries(ctx context.Context, project string, sloName string, start, end time.Time, step time.Duration) ([]Sample, error) {
+	return record(r, "series", []any{project, sloName, start, end, step.String()}, func() ([]Sample, error) {
+		return r.source.FetchSeries(ctx, project, sloName, start, end, step)
+	})
+}
+
+func (r *Recorder) FetchBreakdown(ctx context.Context, project string, slo SLO, labels []string, start, end time.Time) ([]Group, error) {
+	return record(r, "breakdown", []any{project, slo.Name, labels, start, end}, func() ([]Group, error) {
+		return r.source.FetchBreakdown(ctx, project, slo, labels, start, end)
+	})
+}
+
+func (r *Recorder) FetchEvents(ctx context.Context, project string, slo SLO, start, end time.Time, step time.Duration) ([]Group, error) {
+	return record(r, "events", []any{project, slo.Name, start, end, step.String()}, func() ([]Group, error) {
+		return r.source.FetchEvents(ctx, project, slo, start, end, step)
+	})
+}
+
+func (r *Recorder) ListBurnRateAlerts(ctx context.Context, project, serviceID string) ([]BurnRateAlert, error) {
+	return record(r, "burn-rate-alerts", []any{project, serviceID}, func() ([]BurnRateAlert, error) {
+		return r.source.ListBurnRateAlerts(ctx, project, serviceID)
+	})
+}
+
+func (r *Recorder) ListIncidents(ctx context.Context, project string, start, end time.Time) ([]Incident, error) {
+	saved, err := record(r, "incidents", []any{project, start, end}, func() ([]recordedIncident, error) {
+		incidents, err := r.source.ListIncidents(ctx, project, start, end)
+		out := make([]recordedIncident, len(incidents))
+		for i, incident := range incidents {
+			out[i] = recordedIncident{Incident: incident, Policy: incident.Policy}
+		}
+		return out, err
+	})
+	return fromRecordedIncidents(saved), err
+}
+
+func (r *Recorder) FetchBurnRate(ctx context.Context, project, sloName string, lookback time.Duration, start, end time.Time, step time.Duration) ([]Sample, error) {
+	return record(r, "burn-rate", []any{project, sloName, lookback.String(), start, end, step.String()}, func() ([]Sample, error) {
+		return r.source.FetchBurnRate(ctx, project, sloName, lookback, start, end, step)
+	})
+}
+
+func (r *Recorder) ListRevisions(ctx context.Context, project, location, service string, start, end time.Time) ([]ChangeEvent, error) {
+	return record(r, "revisions", []any{project, location, service, start, end}, func() ([]ChangeEvent, error) {
+		return r.source.ListRevisions(ctx, project, location, service, start, end)
+	})
+}
+
+// record makes the call and saves its outcome. Cancellations and timeouts
+// belong to this run, not to Monitoring, so they are not saved.
+func record[T any](r *Recorder, method string, args []any, call func() (T, error)) (T, error) {
+	value, err := call()
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return value, err
+	}
+	saved := recordedCall{Method: method, Args: callArgs(args)}
+	if err != nil {
+		saved.Error = err.Error()
+	} else {
+		response, marshalErr := json.Marshal(value)
+		if marshalErr != nil {
+			return value, fmt.Errorf("record %s: %w", method, marshalErr)
+		}
+		saved.Response = response
+	}
+	path := filepath.Join(r.dir, callFileName(method, saved.Args))
+	if writeErr := writeRecordingJSON(path, saved); writeErr != nil {
+		var zero T
+		return zero, writeErr
+	}
+	return value, err
+}
+
+// Replay serves the calls saved by a Recorder, without credentials. Calls
+// that were not recorded, such as queries for a different window or step,
+// fail.
+type Replay struct {
+	dir       string
+	recording Recording
+	calls     map[string]recordedCall
+}
+
+func OpenReplay(dir string) (*Replay, error) {
+	data, err := os.ReadFile(filepath.Join(dir, RecordingFile))
+	if err != nil {
+		return nil, fmt.Errorf("read recording: %w", err)
+	}
+	out := &Replay{dir: dir, calls: map[string]recordedCall{}}
+	if err := json.Unmarshal(data, &out.recording); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filepath.Join(dir, RecordingFile), err)
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		if filepath.Base(path) == RecordingFile {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read recording: %w", err)
+		}
+		var call recordedCall
+		if err := json.Unmarshal(data, &call); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		// The files are indented; keys use compact arguments.
+		var args bytes.Buffer
+		if err := json.Compact(&args, call.Args); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		call.Args = args.Bytes()
+		out.calls[callKey(call.Method, call.Args)] = call
+	}
+	return out, nil
+}
+
+// RecordedAt is the time of analysis of the recording.
+func (r *Replay) RecordedAt() time.Time {
+	return r.recording.RecordedAt
+}
+
+func (r *Replay) ListServices(ctx context.Context, project string) ([]string, error) {
+	return replay[[]string](r, "list-services", []any{project})
+}
+
+func (r *Replay) ListServiceLevelObjectives(ctx context.Context, serviceName string, max int) ([]SLO, error) {
+	return replay[[]SLO](r, "list-slos", []any{serviceName, max})
+}
+
+func (r *Replay) FetchCompliance(ctx context.Context, project string, sloName string, start, end time.Time) (float64, error) {
+	return replay[float64](r, "compliance", []any{project, sloName, start, end})
+}
+
+func (r *Replay) FetchSeries(ctx context.Context, project string, sloName string, start, end time.Time, step time.Duration) ([]Sample, error) {
+	return replay[[]Sample](r, "series", []any{project, sloName, start, end, step.String()})
+}
+
+func (r *Replay) FetchBreakdown(ctx context.Context, project string, slo SLO, labels []string, start, end time.Time) ([]Group, error) {
+	return replay[[]Group](r, "breakdown", []any{project, slo.Name, labels, start, end})
+}
+
+func (r *Replay) FetchEvents(ctx context.Context, project string, slo SLO, start, end time.Time, step time.Duration) ([]Group, error) {
+	return replay[[]Group](r, "events", []any{project, slo.Name, start, end, step.String()})
+}
+
+func (r *Replay) ListBurnRateAlerts(ctx context.Context, project, serviceID string) ([]BurnRateAlert, error) {
+	return replay[[]BurnRateAlert](r, "burn-rate-alerts", []any{project, serviceID})
+}
+
+func (r *Replay) ListIncidents(ctx context.Context, project string, start, end time.Time) ([]Incident, error) {
+	saved, err := replay[[]recordedIncident](r, "incidents", []any{project, start, end})
+	return fromRecordedIncidents(saved), err
+}
+
+func (r *Replay) FetchBurnRate(ctx context.Context, project, sloName string, lookback time.Duration, start, end time.Time, step time.Duration) ([]Sample, error) {
+	return replay[[]Sample](r, "burn-rate", []any{project, sloName, lookback.String(), start, end, step.String()})
+}
+
+func (r *Replay) ListRevisions(ctx context.Context, project, location, service string, start, end time.Time) ([]ChangeEvent, error) {
+	return replay[[]ChangeEvent](r, "revisions", []any{project, location, service, start, end})
+}
+
+func replay[T any](r *Replay, method string, args []any) (T, error) {
+	var value T
+	encoded := callArgs(args)
+	call, ok := r.calls[callKey(method, encoded)]
+	if !ok {
+		return value, fmt.Errorf("%s %s is not in the recording %s", method, encoded, r.dir)
+	}
+	if call.Error != "" {
+		return value, errors.New(call.Error)
+	}
+	if len(call.Response) > 0 {
+		if err := json.Unmarshal(call.Response, &value); err != nil {
+			return value, fmt.Errorf("replay %s: %w", method, err)
+		}
+	}
+	return value, nil
+}
+
+func fromRecordedIncidents(saved []recordedIncident) []Incident {
+	if saved == nil {
+		return nil
+	}
+	out := make([]Incident, len(saved))
+	for i, incident := range saved {
+		out[i] = incident.Incident
+		out[i].Policy = incident.Policy
+	}
+	return out
+}
+
+// callArgs encodes arguments compactly. They are strings, numbers, string
+// slices, and times, which always encode.
+func callArgs(args []any) json.RawMessage {
+	data, _ := json.Marshal(args)
+	return data
+}
+
+func callKey(method string, args json.RawMessage) string {
+	return method + " " + string(args)
+}
+
+func callFileName(method string, args json.RawMessage) string {
+	sum := sha256.Sum256([]byte(callKey(method, args)))
+	return fmt.Sprintf("%s-%s.json", method, hex.EncodeToString(sum[:8]))
+}
+
+func writeRecordingJSON(path string, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
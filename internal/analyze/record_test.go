@@ -0,0 +1,134 @@
+package analyze
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// liveSource stands in for Monitoring: two SLOs, one of which cannot be
+// queried, and one open incident.
+type liveSource struct {
+	alertReader
+	calls int
+}
+
+func (s *liveSource) ListServices(ctx context.Context, project string) ([]string, error) {
+	s.calls++
+	return []string{"checkout"}, nil
+}
+
+func (s *liveSource) ListServiceLevelObjectives(ctx context.Context, serviceName string, max int) ([]SLO, error) {
+	s.calls++
+	calendar := "MONTH"
+	return []SLO{
+		{Name: serviceName + "/serviceLevelObjectives/availability", DisplayName: "availability", Goal: 0.999, Calendar: &calendar, SLIType: "request-based", SLIMethod: "good-total-ratio"},
+		{Name: serviceName + "/serviceLevelObjectives/latency", DisplayName: "latency", Goal: 0.99, RollingDays: 7, RollingPeriod: 7 * 24 * time.Hour, SLIType: "request-based", SLIMethod: "distribution-cut"},
+	}, nil
+}
+
+func (s *liveSource) FetchCompliance(ctx context.Context, project string, sloName string, start, end time.Time) (float64, error) {
+	s.calls++
+	if strings.HasSuffix(sloName, "/latency") {
+		return 0, errors.New("permission denied")
+	}
+	return 0.9995, nil
+}
+
+func (s *liveSource) FetchSeries(ctx context.Context, project string, sloName string, start, end time.Time, step time.Duration) ([]Sample, error) {
+	s.calls++
+	return []Sample{{End: start.Add(step), Compliance: 0.999, BurnRate: 2}, {End: end, Compliance: 1}}, nil
+}
+
+func (s *liveSource) FetchBreakdown(ctx context.Context, project string, slo SLO, labels []string, start, end time.Time) ([]Group, error) {
+	s.calls++
+	return []Group{{Labels: map[string]string{labels[0]: "500"}, Good: 90, Total: 100}}, nil
+}
+
+func (s *liveSource) FetchEvents(ctx context.Context, project string, slo SLO, start, end time.Time, step time.Duration) ([]Group, error) {
+	s.calls++
+	return nil, nil
+}
+
+func (s *liveSource) ListRevisions(ctx context.Context, project, location, service string, start, end time.Time) ([]ChangeEvent, error) {
+	s.calls++
+	return []ChangeEvent{{Time: end.Add(-20 * time.Minute), Kind: ChangeDeploy, Name: service + "-00042-abc", Source: "cloud-run"}}, nil
+}
+
+func TestRecordReplay(t *testing.T) {
+	now := time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC)
+	open := now.Add(-30 * time.Minute)
+	live := &liveSource{alertReader: alertReader{incidents: []Incident{{Name: "incidents/1", Policy: "projects/demo/alertPolicies/1", State: "open", OpenTime: open}}}}
+	dir := t.TempDir()
+	recorder, err := NewRecorder(dir, live, Recording{RecordedAt: now})
+	if err != nil {
+		t.Fatalf("new recorder: %v", err)
+	}
+	opts := Options{
+		Project:   "demo",
+		Service:   "checkout",
+		Last:      time.Hour,
+		Burndown:  true,
+		Period:    true,
+		Breakdown: []string{"metric.label.response_code"},
+		Now:       now,
+		Alerts:    recorder,
+		Revisions: &RevisionSource{Reader: recorder, Location: "us-central1", Service: "checkout"},
+	}
+	recorded, _, _, err := Run(context.Background(), recorder, opts)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if len(recorded.Errors) == 0 {
+		t.Fatalf("expected the latency SLO to fail, got %+v", recorded)
+	}
+	if len(recorded.Changes) != 1 {
+		t.Fatalf("expected the revision to be recorded, got %+v", recorded.Changes)
+	}
+
+	replay, err := OpenReplay(dir)
+	if err != nil {
+		t.Fatalf("open replay: %v", err)
+	}
+	if !replay.RecordedAt().Equal(now) {
+		t.Fatalf("expected the recording time %s, got %s", now, replay.RecordedAt())
+	}
+	calls := live.calls
+	opts.Now = replay.RecordedAt()
+	opts.Alerts = replay
+	opts.Revisions.Reader = replay
+	replayed, _, _, err := Run(context.Background(), replay, opts)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if live.calls != calls {
+		t.Fatalf("replay called the live source")
+	}
+	want, _ := json.Marshal(recorded)
+	got, _ := json.Marshal(replayed)
+	if string(got) != string(want) {
+		t.Fatalf("replay differs from the recording:\nwant %s\ngot  %s", want, got)
+	}
+
+	incidents, err := replay.ListIncidents(context.Background(), "demo", now.Add(-time.Hour), now)
+	if err != nil || len(incidents) != 1 || incidents[0].Policy != "projects/demo/alertPolicies/1" {
+		t.Fatalf("expected the incident policy to be replayed, got %+v, %v", incidents, err)
+	}
+
+	opts.Step = 5 * time.Minute
+	rerun, _, _, err := Run(context.Background(), replay, opts)
+	if err != nil {
+		t.Fatalf("replay with another step: %v", err)
+	}
+	// The burndown is optional, so its missing series is an SLO note.
+	var notes []string
+	for _, slo := range rerun.SLOs {
+		notes = append(notes, slo.Notes...)
+	}
+	if !strings.Contains(strings.Join(notes, "\n"), "is not in the recording") {
+		t.Fatalf("expected queries for another step to be missing, got %v", notes)
+	}
+}
@@ -0,0 +1,193 @@
+package analyze
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bayneri/margin/internal/planner"
+	"github.com/bayneri/margin/internal/spec"
+)
+
+// Scenario is a synthetic incident: a step to Level that lasts Duration, or
+// a ramp from the baseline up to Level over Duration.
+type Scenario struct {
+	Kind     string
+	Level    float64
+	Duration time.Duration
+}
+
+// ParseScenario parses scenarios such as step:2%:20m or ramp:5%:2h.
+func ParseScenario(value string) (Scenario, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return Scenario{}, fmt.Errorf("scenario %q must look like step:2%%:20m or ramp:5%%:2h", value)
+	}
+	kind := strings.TrimSpace(parts[0])
+	if kind != "step" && kind != "ramp" {
+		return Scenario{}, fmt.Errorf("scenario %q: kind must be step or ramp", value)
+	}
+	level, err := ParsePercent(parts[1])
+	if err != nil {
+		return Scenario{}, fmt.Errorf("scenario %q: %w", value, err)
+	}
+	if level <= 0 {
+		return Scenario{}, fmt.Errorf("scenario %q: level must be above 0%%", value)
+	}
+	duration, err := spec.ParseWindow(strings.TrimSpace(parts[2]))
+	if err != nil {
+		return Scenario{}, fmt.Errorf("scenario %q: %w", value, err)
+	}
+	if duration <= 0 {
+		return Scenario{}, fmt.Errorf("scenario %q: duration must be positive", value)
+	}
+	return Scenario{Kind: kind, Level: level, Duration: duration}, nil
+}
+
+// ParsePercent parses a bad fraction written as a percentage, such as 2% or
+// 0.05%.
+func ParsePercent(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if !strings.HasSuffix(value, "%") {
+		return 0, fmt.Errorf("%q must be a percentage such as 2%%", value)
+	}
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil || percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("%q must be a percentage between 0%% and 100%%", value)
+	}
+	return percent / 100, nil
+}
+
+func (s Scenario) String() string {
+	duration, ok := spec.FormatWindow(s.Duration)
+	if !ok {
+		duration = s.Duration.String()
+	}
+	return fmt.Sprintf("%s:%s%%:%s", s.Kind, strconv.FormatFloat(s.Level*100, 'f', -1, 64), duration)
+}
+
+// Steps builds the scenario at step resolution with one event per step: lead
+// of baseline, the scenario starting at onset, then tail of baseline.
+func (s Scenario) Steps(start time.Time, step, lead, tail time.Duration, baseline float64) (steps []Group, onset time.Time) {
+	onset = start.Add(time.Duration(stepsIn(lead, step)) * step)
+	n := stepsIn(s.Duration, step)
+	total := stepsIn(lead, step) + n + stepsIn(tail, step)
+	for i := 0; i < total; i++ {
+		end := start.Add(time.Duration(i+1) * step)
+		bad := baseline
+		if !end.Before(onset.Add(step)) && !end.After(onset.Add(time.Duration(n)*step)) {
+			bad = s.Level
+			if s.Kind == "ramp" {
+				k := int(end.Sub(onset) / step)
+				bad = baseline + (s.Level-baseline)*float64(k)/float64(n)
+			}
+		}
+		steps = append(steps, Group{End: end, Good: 1 - bad, Total: 1})
+	}
+	return steps, onset
+}
+
+// SeriesFile is a recorded SLI per SLO, as written by margin simulate
+// --record.
+type SeriesFile struct {
+	Project     string    `json:"project"`
+	Service     string    `json:"service"`
+	RecordedAt  time.Time `json:"recordedAt"`
+	StepSeconds int64     `json:"stepSeconds"`
+	Series      []Series  `json:"series"`
+}
+
+// Series is the SLI of one SLO: per step, the fraction of bad events and how
+// many events there were.
+type Series struct {
+	SLO    string        `json:"slo"`
+	Points []SeriesPoint `json:"points"`
+}
+
+type SeriesPoint struct {
+	End         time.Time `json:"end"`
+	BadFraction float64   `json:"badFraction"`
+	Total       float64   `json:"total,omitempty"`
+}
+
+// LoadSeries reads a series file.
+func LoadSeries(path string) (SeriesFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SeriesFile{}, fmt.Errorf("read series: %w", err)
+	}
+	var file SeriesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return SeriesFile{}, fmt.Errorf("parse series %s: %w", path, err)
+	}
+	if file.StepSeconds <= 0 {
+		return SeriesFile{}, fmt.Errorf("series %s: stepSeconds must be positive", path)
+	}
+	return file, nil
+}
+
+// Lookup returns the series recorded for the spec SLO named name.
+func (f SeriesFile) Lookup(name string) (Series, bool) {
+	for _, series := range f.Series {
+		if series.SLO == name {
+			return series, true
+		}
+	}
+	return Series{}, false
+}
+
+// Steps converts the points back to event counts on a dense grid. Points
+// without a total count as one event; steps without a point have no events.
+func (s Series) Steps(step time.Duration) []Group {
+	if len(s.Points) == 0 {
+		return nil
+	}
+	groups := make([]Group, 0, len(s.Points))
+	for _, point := range s.Points {
+		total := point.Total
+		if total <= 0 {
+			total = 1
+		}
+		groups = append(groups, Group{End: point.End, Good: total * (1 - point.BadFraction), Total: total})
+	}
+	start := s.Points[0].End.Add(-step)
+	end := s.Points[len(s.Points)-1].End
+	return denseSteps(groups, start, end, step)
+}
+
+// RecordSeries reads the SLI of every SLO in the plan from its metrics.
+func RecordSeries(ctx context.Context, reader EventReader, plan planner.Plan, start, end time.Time, step time.Duration) (SeriesFile, error) {
+	slos, err := SpecSLOs(plan)
+	if err != nil {
+		return SeriesFile{}, err
+	}
+	file := SeriesFile{
+		Project:     plan.Project,
+		Service:     plan.ServiceID,
+		RecordedAt:  end,
+		StepSeconds: int64(step.Seconds()),
+	}
+	for i, slo := range slos {
+		groups, err := reader.FetchEvents(ctx, plan.Project, slo, start, end, step)
+		if err != nil {
+			return SeriesFile{}, fmt.Errorf("%s: %w", plan.SLOs[i].Name, err)
+		}
+		series := Series{SLO: plan.SLOs[i].Name}
+		for _, group := range denseSteps(groups, start, end, step) {
+			if group.Total <= 0 {
+				continue
+			}
+			series.Points = append(series.Points, SeriesPoint{
+				End:         group.End,
+				BadFraction: (group.Total - group.Good) / group.Total,
+				Total:       group.Total,
+			})
+		}
+		file.Series = append(file.Series, series)
+	}
+	return file, nil
+}
@@ -0,0 +1,291 @@
+package analyze
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/bayneri/margin/internal/planner"
+	"github.com/bayneri/margin/internal/spec"
+)
+
+// AlertPolicy is a burn-rate alert tier as margin apply creates it: one
+// condition per window, each holding once the burn rate over the window has
+// stayed above BurnRate for as long as the window, combined with AND.
+type AlertPolicy struct {
+	Name     string
+	Tier     string
+	Windows  []time.Duration
+	BurnRate float64
+}
+
+// AlertPolicies returns the policies planner builds for the spec SLO named
+// sloName.
+func AlertPolicies(alerts []planner.AlertPlan, sloName string) ([]AlertPolicy, error) {
+	var out []AlertPolicy
+	for _, alert := range alerts {
+		if alert.SLOName != sloName {
+			continue
+		}
+		policy := AlertPolicy{Name: alert.DisplayName, Tier: alert.Type, BurnRate: alert.BurnRate}
+		for _, window := range alert.Windows {
+			d, err := spec.ParseWindow(window)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", alert.DisplayName, err)
+			}
+			policy.Windows = append(policy.Windows, d)
+		}
+		out = append(out, policy)
+	}
+	return out, nil
+}
+
+// Firing is one stretch during which a policy would have been open.
+// ResolvedAt is nil when it was still open at the end of the series.
+// BudgetSpentPercent is the share of the period's budget spent from the start
+// of the series until the policy fired; only Simulate sets it.
+type Firing struct {
+	Policy             string     `json:"policy"`
+	Tier               string     `json:"tier"`
+	FiredAt            time.Time  `json:"firedAt"`
+	ResolvedAt         *time.Time `json:"resolvedAt"`
+	BudgetSpentPercent float64    `json:"budgetSpentPercentBeforeFiring"`
+}
+
+// SimulateAlerts replays per-step event counts through policies. steps are
+// sorted by End and spaced by step; burn rates weigh steps by their events,
+// and windows shorter than step are evaluated over one step. A policy
+// resolves at the first step where any of its conditions stops holding.
+func SimulateAlerts(steps []Group, step time.Duration, goal float64, policies []AlertPolicy) []Firing {
+	if len(steps) == 0 || step <= 0 || goal >= 1 {
+		return nil
+	}
+	badSum := make([]float64, len(steps)+1)
+	totalSum := make([]float64, len(steps)+1)
+	for i, s := range steps {
+		badSum[i+1] = badSum[i] + s.Total - s.Good
+		totalSum[i+1] = totalSum[i] + s.Total
+	}
+	allowedBad := 1 - goal
+
+	var out []Firing
+	for _, policy := range policies {
+		held := make([]bool, len(steps))
+		for i := range held {
+			held[i] = len(policy.Windows) > 0
+		}
+		for _, window := range policy.Windows {
+			k := stepsIn(window, step)
+			run := 0
+			for i := range steps {
+				from := i + 1 - k
+				if from < 0 {
+					from = 0
+				}
+				total := totalSum[i+1] - totalSum[from]
+				if total > 0 && (badSum[i+1]-badSum[from])/total/allowedBad > policy.BurnRate {
+					run++
+				} else {
+					run = 0
+				}
+				held[i] = held[i] && run >= k
+			}
+		}
+
+		var open *Firing
+		for i, s := range steps {
+			switch {
+			case held[i] && open == nil:
+				open = &Firing{Policy: policy.Name, Tier: policy.Tier, FiredAt: s.End}
+			case !held[i] && open != nil:
+				resolved := s.End
+				open.ResolvedAt = &resolved
+				out = append(out, *open)
+				open = nil
+			}
+		}
+		if open != nil {
+			out = append(out, *open)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].FiredAt.Before(out[j].FiredAt) })
+	return out
+}
+
+// stepsIn returns how many steps cover d, at least one.
+func stepsIn(d, step time.Duration) int {
+	k := int((d + step - 1) / step)
+	if k < 1 {
+		return 1
+	}
+	return k
+}
+
+const SimulateSchemaVersion = "1.0"
+
+// SimulateOptions selects the series to replay: a recorded Series file or a
+// synthetic Scenario on top of Baseline at Step resolution (default 1m).
+type SimulateOptions struct {
+	Series   *SeriesFile
+	Scenario *Scenario
+	Baseline float64
+	Step     time.Duration
+	Only     *regexp.Regexp
+	Now      time.Time
+}
+
+type SimulateResult struct {
+	SchemaVersion string          `json:"schemaVersion"`
+	Project       string          `json:"project"`
+	Service       string          `json:"service"`
+	GeneratedAt   time.Time       `json:"generatedAt"`
+	Source        string          `json:"source"`
+	StepSeconds   int64           `json:"stepSeconds"`
+	SLOs          []SLOSimulation `json:"slos"`
+	Errors        []string        `json:"errors"`
+}
+
+// SLOSimulation is the replay of one SLO's alert policies. Onset is when a
+// synthetic scenario starts; it is nil for recorded series.
+type SLOSimulation struct {
+	Name            string             `json:"name"`
+	Objective       float64            `json:"objective"`
+	PeriodSeconds   int64              `json:"periodSeconds"`
+	Start           time.Time          `json:"start"`
+	End             time.Time          `json:"end"`
+	Onset           *time.Time         `json:"onset,omitempty"`
+	ConsumedPercent float64            `json:"consumedPercentOfBudget"`
+	Policies        []PolicySimulation `json:"policies"`
+	Error           string             `json:"error,omitempty"`
+}
+
+type PolicySimulation struct {
+	Name     string   `json:"name"`
+	Tier     string   `json:"tier"`
+	Windows  []string `json:"windows"`
+	BurnRate float64  `json:"burnRate"`
+	Firings  []Firing `json:"firings"`
+}
+
+// Simulate replays a recorded or synthetic series through the alert policies
+// planner builds for each SLO of the plan. Budget is measured against the
+// SLO's period at the series' mean traffic.
+func Simulate(plan planner.Plan, opts SimulateOptions) (SimulateResult, error) {
+	if (opts.Series == nil) == (opts.Scenario == nil) {
+		return SimulateResult{}, errors.New("simulate needs either a series or a scenario")
+	}
+	step := opts.Step
+	source := ""
+	if opts.Series != nil {
+		step = time.Duration(opts.Series.StepSeconds) * time.Second
+		source = "recorded"
+	} else {
+		if step <= 0 {
+			step = time.Minute
+		}
+		source = opts.Scenario.String()
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	slos, err := SpecSLOs(plan)
+	if err != nil {
+		return SimulateResult{}, err
+	}
+	result := SimulateResult{
+		SchemaVersion: SimulateSchemaVersion,
+		Project:       plan.Project,
+		Service:       plan.ServiceID,
+		GeneratedAt:   now.UTC(),
+		Source:        source,
+		StepSeconds:   int64(step.Seconds()),
+	}
+	for i, slo := range slos {
+		sloPlan := plan.SLOs[i]
+		if opts.Only != nil && !opts.Only.MatchString(slo.DisplayName) && !opts.Only.MatchString(slo.Name) {
+			continue
+		}
+		item := SLOSimulation{Name: sloPlan.Name, Objective: sloPlan.Objective}
+		if err := simulateSLO(plan, sloPlan, slo, step, now, opts, &item); err != nil {
+			item.Error = err.Error()
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", sloPlan.Name, err.Error()))
+		}
+		result.SLOs = append(result.SLOs, item)
+	}
+	return result, nil
+}
+
+func simulateSLO(plan planner.Plan, sloPlan planner.SLOPlan, slo SLO, step time.Duration, now time.Time, opts SimulateOptions, item *SLOSimulation) error {
+	policies, err := AlertPolicies(plan.Alerts, sloPlan.Name)
+	if err != nil {
+		return err
+	}
+
+	var steps []Group
+	if opts.Scenario != nil {
+		var longest time.Duration
+		for _, policy := range policies {
+			for _, window := range policy.Windows {
+				if window > longest {
+					longest = window
+				}
+			}
+		}
+		// Lead in and trail off by the longest window so every condition
+		// starts from the baseline and has time to resolve.
+		var onset time.Time
+		steps, onset = opts.Scenario.Steps(now.UTC().Truncate(step), step, longest, longest, opts.Baseline)
+		item.Onset = &onset
+	} else {
+		series, ok := opts.Series.Lookup(sloPlan.Name)
+		if !ok {
+			return fmt.Errorf("not in the series file")
+		}
+		steps = series.Steps(step)
+	}
+	if !hasEvents(steps) {
+		return fmt.Errorf("no events in the series")
+	}
+	item.Start = steps[0].End.Add(-step)
+	item.End = steps[len(steps)-1].End
+
+	period := PeriodLength(slo, item.Start)
+	if period <= 0 {
+		return fmt.Errorf("SLO has no rolling or calendar period")
+	}
+	item.PeriodSeconds = int64(period.Seconds())
+
+	// spent[i] is the bad events in the first i steps.
+	spent := make([]float64, len(steps)+1)
+	var total float64
+	for i, s := range steps {
+		spent[i+1] = spent[i] + s.Total - s.Good
+		total += s.Total
+	}
+	goal := sloPlan.Objective / 100
+	budget := (1 - goal) * total / float64(len(steps)) * float64(period) / float64(step)
+	percent := func(bad float64) float64 { return round4(bad / budget * 100) }
+	item.ConsumedPercent = percent(spent[len(steps)])
+
+	for _, policy := range policies {
+		sim := PolicySimulation{Name: policy.Name, Tier: policy.Tier, BurnRate: policy.BurnRate, Firings: []Firing{}}
+		for _, window := range policy.Windows {
+			label, ok := spec.FormatWindow(window)
+			if !ok {
+				label = window.String()
+			}
+			sim.Windows = append(sim.Windows, label)
+		}
+		for _, firing := range SimulateAlerts(steps, step, goal, []AlertPolicy{policy}) {
+			i := int(firing.FiredAt.Sub(item.Start) / step)
+			firing.BudgetSpentPercent = percent(spent[i])
+			sim.Firings = append(sim.Firings, firing)
+		}
+		item.Policies = append(item.Policies, sim)
+	}
+	return nil
+}
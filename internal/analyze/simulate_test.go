@@ -0,0 +1,182 @@
+package analyze
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bayneri/margin/internal/planner"
+	"github.com/bayneri/margin/internal/spec"
+)
+
+func TestAlertPolicies(t *testing.T) {
+	policies, err := AlertPolicies([]planner.AlertPlan{
+		{DisplayName: "checkout availability fast-burn", SLOName: "availability", Type: "fast-burn", Windows: []string{"5m", "1h"}, BurnRate: 14.4},
+		{DisplayName: "checkout latency fast-burn", SLOName: "latency", Type: "fast-burn", Windows: []string{"5m", "1h"}, BurnRate: 14.4},
+	}, "availability")
+	if err != nil {
+		t.Fatalf("alert policies: %v", err)
+	}
+	if len(policies) != 1 || policies[0].Tier != "fast-burn" || policies[0].Windows[1] != time.Hour {
+		t.Fatalf("unexpected policies %+v", policies)
+	}
+}
+
+func TestSimulateAlerts(t *testing.T) {
+	step := 5 * time.Minute
+	start := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	steps := make([]Group, 48)
+	for i := range steps {
+		steps[i] = Group{End: start.Add(time.Duration(i+1) * step), Good: 1000, Total: 1000}
+		if i >= 10 && i < 34 {
+			steps[i].Good = 950
+		}
+	}
+	policy := AlertPolicy{Name: "fast", Tier: "fast-burn", Windows: []time.Duration{5 * time.Minute, time.Hour}, BurnRate: 14.4}
+
+	firings := SimulateAlerts(steps, step, 0.999, []AlertPolicy{policy})
+	if len(firings) != 1 {
+		t.Fatalf("expected one firing, got %+v", firings)
+	}
+	// The 1h burn rate first exceeds 14.4 four steps in, then has to hold
+	// for another hour.
+	if !firings[0].FiredAt.Equal(steps[24].End) {
+		t.Fatalf("expected the alert to fire at %s, got %s", steps[24].End, firings[0].FiredAt)
+	}
+	if firings[0].ResolvedAt == nil || !firings[0].ResolvedAt.Equal(steps[34].End) {
+		t.Fatalf("expected the alert to resolve at %s, got %v", steps[34].End, firings[0].ResolvedAt)
+	}
+
+	if firings := SimulateAlerts(steps[:20], step, 0.999, []AlertPolicy{policy}); len(firings) != 0 {
+		t.Fatalf("expected no firing before the condition held for its window, got %+v", firings)
+	}
+}
+
+func TestSimulateScenario(t *testing.T) {
+	doc, err := spec.Parse([]byte(recommendSpec))
+	if err != nil {
+		t.Fatalf("parse spec: %v", err)
+	}
+	scenario, err := ParseScenario("step:20%:2h")
+	if err != nil {
+		t.Fatalf("parse scenario: %v", err)
+	}
+	now := time.Date(2026, 3, 2, 0, 0, 30, 0, time.UTC)
+	result, err := Simulate(planner.Build(doc, planner.Options{}), SimulateOptions{
+		Scenario: &scenario,
+		Only:     regexp.MustCompile("availability"),
+		Now:      now,
+	})
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	if result.Source != "step:20%:2h" || len(result.SLOs) != 1 || len(result.Errors) != 0 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	slo := result.SLOs[0]
+	onset := now.Truncate(time.Minute).Add(6 * time.Hour)
+	if slo.Onset == nil || !slo.Onset.Equal(onset) {
+		t.Fatalf("expected onset %s after a 6h lead-in, got %v", onset, slo.Onset)
+	}
+	if len(slo.Policies) != 2 {
+		t.Fatalf("expected fast and slow burn, got %+v", slo.Policies)
+	}
+
+	fast := slo.Policies[0]
+	if fast.Tier != "fast-burn" || len(fast.Firings) != 1 {
+		t.Fatalf("expected one fast-burn firing, got %+v", fast)
+	}
+	// The 1h condition crosses 14.4 five minutes in and must then hold for
+	// an hour; the 5m condition clears five minutes after the incident.
+	firing := fast.Firings[0]
+	if !firing.FiredAt.Equal(onset.Add(64 * time.Minute)) {
+		t.Fatalf("expected the page 64m after onset, got %s", firing.FiredAt.Sub(onset))
+	}
+	if firing.ResolvedAt == nil || !firing.ResolvedAt.Equal(onset.Add(125*time.Minute)) {
+		t.Fatalf("expected the page to resolve 125m after onset, got %v", firing.ResolvedAt)
+	}
+	// 64 minutes at 20% against 7d of 0.1% budget.
+	if firing.BudgetSpentPercent != 126.9841 {
+		t.Fatalf("unexpected budget spent %v", firing.BudgetSpentPercent)
+	}
+
+	// The 30m condition clears before the 6h one has held for 6h.
+	if slow := slo.Policies[1]; slow.Tier != "slow-burn" || len(slow.Firings) != 0 {
+		t.Fatalf("expected no slow-burn firing, got %+v", slow)
+	}
+}
+
+func TestSimulateSeries(t *testing.T) {
+	doc, err := spec.Parse([]byte(recommendSpec))
+	if err != nil {
+		t.Fatalf("parse spec: %v", err)
+	}
+	start := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	var points []string
+	for i := 1; i <= 240; i++ {
+		bad := "0.0005"
+		if i > 60 && i <= 160 {
+			bad = "0.05"
+		}
+		points = append(points, fmt.Sprintf(`{"end": %q, "badFraction": %s, "total": 600}`, start.Add(time.Duration(i)*time.Minute).Format(time.RFC3339), bad))
+	}
+	path := filepath.Join(t.TempDir(), "series.json")
+	data := fmt.Sprintf(`{"stepSeconds": 60, "series": [{"slo": "availability", "points": [%s]}]}`, strings.Join(points, ","))
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("write series: %v", err)
+	}
+	series, err := LoadSeries(path)
+	if err != nil {
+		t.Fatalf("load series: %v", err)
+	}
+
+	result, err := Simulate(planner.Build(doc, planner.Options{}), SimulateOptions{Series: &series})
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	if result.Source != "recorded" || len(result.SLOs) != 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if len(result.Errors) != 1 || result.Errors[0] != "latency: not in the series file" {
+		t.Fatalf("expected the latency SLO to be missing, got %v", result.Errors)
+	}
+	availability := result.SLOs[0]
+	if availability.Onset != nil || !availability.Start.Equal(start) {
+		t.Fatalf("unexpected series bounds %+v", availability)
+	}
+	// The 1h burn rate crosses 14.4 17 minutes into the 5% errors.
+	fast := availability.Policies[0]
+	if len(fast.Firings) != 1 || !fast.Firings[0].FiredAt.Equal(start.Add(136*time.Minute)) {
+		t.Fatalf("unexpected fast-burn firings %+v", fast.Firings)
+	}
+	if resolved := fast.Firings[0].ResolvedAt; resolved == nil || !resolved.Equal(start.Add(164*time.Minute)) {
+		t.Fatalf("expected the page to resolve once the 5m burn rate drops, got %v", resolved)
+	}
+}
+
+func TestParseScenario(t *testing.T) {
+	scenario, err := ParseScenario("ramp:5%:2h")
+	if err != nil {
+		t.Fatalf("parse scenario: %v", err)
+	}
+	if scenario.Kind != "ramp" || scenario.Level != 0.05 || scenario.Duration != 2*time.Hour {
+		t.Fatalf("unexpected scenario %+v", scenario)
+	}
+	steps, onset := scenario.Steps(time.Unix(0, 0).UTC(), time.Hour, time.Hour, time.Hour, 0)
+	if len(steps) != 4 || !onset.Equal(time.Unix(3600, 0).UTC()) {
+		t.Fatalf("unexpected steps %+v", steps)
+	}
+	if steps[1].Good != 0.975 || steps[2].Good != 0.95 || steps[3].Good != 1 {
+		t.Fatalf("expected a ramp to 5%% and back, got %+v", steps)
+	}
+
+	for _, value := range []string{"step:2:20m", "spike:2%:20m", "step:0%:20m", "step:2%"} {
+		if _, err := ParseScenario(value); err == nil {
+			t.Fatalf("expected %q to be rejected", value)
+		}
+	}
+}
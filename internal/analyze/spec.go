@@ -0,0 +1,149 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/bayneri/margin/internal/monitoring"
+	"github.com/bayneri/margin/internal/planner"
+	"github.com/bayneri/margin/internal/spec"
+)
+
+// EventReader reads the metrics behind an SLI directly, without an SLO in
+// Monitoring. GCPReader implements it.
+type EventReader interface {
+	// FetchEvents returns the good and total events per step, with End set.
+	FetchEvents(ctx context.Context, project string, slo SLO, start, end time.Time, step time.Duration) ([]Group, error)
+	FetchBreakdown(ctx context.Context, project string, slo SLO, labels []string, start, end time.Time) ([]Group, error)
+}
+
+// SpecReader is a Reader for the SLOs of specs, applied or not. It builds
+// each SLI the way margin apply would and evaluates it from the raw good,
+// total, or distribution metrics instead of select_slo_* queries.
+type SpecReader struct {
+	events EventReader
+	slos   []SLO
+}
+
+func NewSpecReader(events EventReader, plans []planner.Plan) (*SpecReader, error) {
+	reader := &SpecReader{events: events}
+	for _, plan := range plans {
+		slos, err := SpecSLOs(plan)
+		if err != nil {
+			return nil, err
+		}
+		reader.slos = append(reader.slos, slos...)
+	}
+	return reader, nil
+}
+
+// SpecSLOs builds the SLOs of a plan the way margin apply would, in plan
+// order, named after the resources apply creates.
+func SpecSLOs(plan planner.Plan) ([]SLO, error) {
+	template, err := spec.TemplateForService(plan.Service)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", plan.ServiceName, err)
+	}
+	var out []SLO
+	for _, sloPlan := range plan.SLOs {
+		built, err := monitoring.BuildSLO(monitoring.ApplySLORequest{
+			Project:   plan.Project,
+			ServiceID: plan.ServiceID,
+			SLO:       sloPlan,
+			Template:  template,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", sloPlan.ID, err)
+		}
+		built.Name = fmt.Sprintf("projects/%s/services/%s/serviceLevelObjectives/%s", plan.Project, plan.ServiceID, sloPlan.ResourceID)
+		slo, err := toAnalyzeSLO(built)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", sloPlan.ID, err)
+		}
+		slo.FromSpec = true
+		out = append(out, slo)
+	}
+	return out, nil
+}
+
+func (r *SpecReader) ListServiceLevelObjectives(ctx context.Context, serviceName string, max int) ([]SLO, error) {
+	var out []SLO
+	for _, slo := range r.slos {
+		if !strings.HasPrefix(slo.Name, serviceName+"/serviceLevelObjectives/") {
+			continue
+		}
+		out = append(out, slo)
+		if max > 0 && len(out) >= max {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (r *SpecReader) FetchCompliance(ctx context.Context, project string, sloName string, start, end time.Time) (float64, error) {
+	slo, err := r.lookup(sloName)
+	if err != nil {
+		return 0, err
+	}
+	groups, err := r.events.FetchEvents(ctx, project, slo, start, end, end.Sub(start))
+	if err != nil {
+		return 0, err
+	}
+	var good, total float64
+	for _, group := range groups {
+		good += group.Good
+		total += group.Total
+	}
+	if total <= 0 {
+		return 0, status.Error(codes.NotFound, "no events in window")
+	}
+	return good / total, nil
+}
+
+// FetchSeries derives each step's burn rate from its bad fraction and the
+// spec's goal.
+func (r *SpecReader) FetchSeries(ctx context.Context, project string, sloName string, start, end time.Time, step time.Duration) ([]Sample, error) {
+	slo, err := r.lookup(sloName)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := r.events.FetchEvents(ctx, project, slo, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	var out []Sample
+	for _, group := range groups {
+		if group.Total <= 0 {
+			continue
+		}
+		sample := Sample{End: group.End, Compliance: group.Good / group.Total}
+		if slo.Goal < 1 {
+			sample.BurnRate = (1 - sample.Compliance) / (1 - slo.Goal)
+		}
+		out = append(out, sample)
+	}
+	if len(out) == 0 {
+		return nil, status.Error(codes.NotFound, "no events in window")
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].End.Before(out[j].End) })
+	return out, nil
+}
+
+func (r *SpecReader) FetchBreakdown(ctx context.Context, project string, slo SLO, labels []string, start, end time.Time) ([]Group, error) {
+	return r.events.FetchBreakdown(ctx, project, slo, labels, start, end)
+}
+
+func (r *SpecReader) lookup(sloName string) (SLO, error) {
+	for _, slo := range r.slos {
+		if slo.Name == sloName {
+			return slo, nil
+		}
+	}
+	return SLO{}, fmt.Errorf("SLO %s is not in the spec", sloName)
+}
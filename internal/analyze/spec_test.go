@@ -0,0 +1,108 @@
+package analyze
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bayneri/margin/internal/planner"
+	"github.com/bayneri/margin/internal/spec"
+)
+
+const backtestSpec = `apiVersion: margin/v2
+kind: ServiceSLO
+metadata:
+  name: checkout-api
+  service: cloud-run
+  project: demo
+slos:
+  - name: availability
+    objective: 99.9
+    window: 30d
+    sli:
+      requestBased:
+        good:
+          metric: run.googleapis.com/request_count
+          filter: metric.label.response_code_class = "2xx"
+        total:
+          metric: run.googleapis.com/request_count
+  - name: latency
+    objective: 99
+    window: 30d
+    sli:
+      latency:
+        metric: run.googleapis.com/request_latencies
+        threshold: 500ms
+`
+
+// eventReader returns 1000 events per step, 1 bad in each step.
+type eventReader struct {
+	slos []SLO
+}
+
+func (r *eventReader) FetchEvents(ctx context.Context, project string, slo SLO, start, end time.Time, step time.Duration) ([]Group, error) {
+	r.slos = append(r.slos, slo)
+	var out []Group
+	for at := start.Add(step); !at.After(end); at = at.Add(step) {
+		out = append(out, Group{End: at, Good: 999, Total: 1000})
+	}
+	return out, nil
+}
+
+func (r *eventReader) FetchBreakdown(ctx context.Context, project string, slo SLO, labels []string, start, end time.Time) ([]Group, error) {
+	return nil, nil
+}
+
+func TestSpecReader(t *testing.T) {
+	doc, err := spec.Parse([]byte(backtestSpec))
+	if err != nil {
+		t.Fatalf("parse spec: %v", err)
+	}
+	events := &eventReader{}
+	reader, err := NewSpecReader(events, []planner.Plan{planner.Build(doc, planner.Options{})})
+	if err != nil {
+		t.Fatalf("new spec reader: %v", err)
+	}
+
+	end := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	result, _, _, err := Run(context.Background(), reader, Options{
+		Project:  "demo",
+		Service:  "checkout-api",
+		Start:    end.Add(-6 * time.Hour).Format(time.RFC3339),
+		End:      end.Format(time.RFC3339),
+		Burndown: true,
+		Step:     time.Hour,
+		Explain:  true,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(result.SLOs) != 2 {
+		t.Fatalf("expected both spec SLOs, got %d", len(result.SLOs))
+	}
+
+	availability := result.SLOs[0]
+	if availability.SLOID != "checkout-api-availability" || availability.Compliance != 0.999 || availability.ConsumedPercentOfBudget != 100 {
+		t.Fatalf("unexpected availability result %+v", availability)
+	}
+	if availability.Burndown == nil || len(availability.Burndown.Points) != 6 || availability.Burndown.Points[0].BurnRate != 1 {
+		t.Fatalf("expected a six-step burndown with burn rate 1, got %+v", availability.Burndown)
+	}
+	if availability.Explain == nil || !strings.Contains(availability.Explain.Notes[0], "spec's SLI metrics") {
+		t.Fatalf("expected a note that the SLO came from the spec, got %+v", availability.Explain)
+	}
+
+	var sawGood, sawDistribution bool
+	for _, slo := range events.slos {
+		if strings.Contains(slo.GoodFilter, `metric.type="run.googleapis.com/request_count"`) && strings.Contains(slo.GoodFilter, `response_code_class = "2xx"`) {
+			sawGood = true
+		}
+		if slo.SLIMethod == "distribution-cut" && slo.RangeMax == 0.5 && strings.Contains(slo.DistributionFilter, `resource.type="cloud_run_revision"`) {
+			sawDistribution = true
+		}
+	}
+	if !sawGood || !sawDistribution {
+		t.Fatalf("expected filters built the way apply builds them, got %+v", events.slos)
+	}
+}
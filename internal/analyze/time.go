@@ -39,3 +39,72 @@ func ParseLast(input string) (time.Duration, error) {
 	}
 	return duration, nil
 }
+
+// PeriodWindow returns the compliance period of an SLO that ends at now: the
+// rolling period for rolling SLOs, or the current calendar period so far for
+// calendar SLOs. Calendar periods follow google.type.CalendarPeriod in UTC;
+// weeks start on Monday and fortnights start at ISO week 1.
+func PeriodWindow(slo SLO, now time.Time) (time.Time, time.Time, error) {
+	now = now.UTC()
+	if slo.Calendar == nil {
+		if slo.RollingPeriod <= 0 {
+			return time.Time{}, time.Time{}, fmt.Errorf("SLO %s has no rolling or calendar period", slo.Name)
+		}
+		return now.Add(-slo.RollingPeriod), now, nil
+	}
+
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	weekStart := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	var start time.Time
+	switch *slo.Calendar {
+	case "DAY":
+		start = day
+	case "WEEK":
+		start = weekStart
+	case "FORTNIGHT":
+		_, week := weekStart.ISOWeek()
+		start = weekStart
+		if week%2 == 0 {
+			start = weekStart.AddDate(0, 0, -7)
+		}
+	case "MONTH":
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case "QUARTER":
+		start = time.Date(now.Year(), now.Month()-(now.Month()-1)%3, 1, 0, 0, 0, 0, time.UTC)
+	case "HALF":
+		start = time.Date(now.Year(), now.Month()-(now.Month()-1)%6, 1, 0, 0, 0, 0, time.UTC)
+	case "YEAR":
+		start = time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("SLO %s has unsupported calendar period %q", slo.Name, *slo.Calendar)
+	}
+	return start, now, nil
+}
+
+// PeriodLength is the full length of the compliance period that starts at
+// start: the rolling period, or the calendar period containing start.
+func PeriodLength(slo SLO, start time.Time) time.Duration {
+	if slo.Calendar == nil {
+		return slo.RollingPeriod
+	}
+	var end time.Time
+	switch *slo.Calendar {
+	case "DAY":
+		end = start.AddDate(0, 0, 1)
+	case "WEEK":
+		end = start.AddDate(0, 0, 7)
+	case "FORTNIGHT":
+		end = start.AddDate(0, 0, 14)
+	case "MONTH":
+		end = start.AddDate(0, 1, 0)
+	case "QUARTER":
+		end = start.AddDate(0, 3, 0)
+	case "HALF":
+		end = start.AddDate(0, 6, 0)
+	case "YEAR":
+		end = start.AddDate(1, 0, 0)
+	default:
+		return 0
+	}
+	return end.Sub(start)
+}
@@ -29,3 +29,51 @@ func TestResolveWindowLast(t *testing.T) {
 		t.Fatalf("unexpected duration")
 	}
 }
+
+func TestPeriodWindowRolling(t *testing.T) {
+	now := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	start, end, err := PeriodWindow(SLO{Name: "slo", RollingPeriod: 28 * 24 * time.Hour}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if end != now || start != time.Date(2025, 1, 3, 12, 0, 0, 0, time.UTC) {
+		t.Fatalf("unexpected window %s - %s", start, end)
+	}
+	if _, _, err := PeriodWindow(SLO{Name: "slo"}, now); err == nil {
+		t.Fatalf("expected error for SLO without a period")
+	}
+}
+
+func TestPeriodWindowCalendar(t *testing.T) {
+	// Thursday of ISO week 7.
+	now := time.Date(2025, 2, 13, 15, 30, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"DAY":       time.Date(2025, 2, 13, 0, 0, 0, 0, time.UTC),
+		"WEEK":      time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC),
+		"FORTNIGHT": time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC),
+		"MONTH":     time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		"QUARTER":   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		"HALF":      time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		"YEAR":      time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for period, want := range cases {
+		period := period
+		start, _, err := PeriodWindow(SLO{Name: "slo", Calendar: &period}, now)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", period, err)
+		}
+		if start != want {
+			t.Fatalf("%s: expected start %s, got %s", period, want, start)
+		}
+	}
+}
+
+func TestPeriodLength(t *testing.T) {
+	month := "MONTH"
+	if got := PeriodLength(SLO{Calendar: &month}, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)); got != 29*24*time.Hour {
+		t.Fatalf("unexpected February length: %v", got)
+	}
+	if got := PeriodLength(SLO{RollingPeriod: 28 * 24 * time.Hour}, time.Now()); got != 28*24*time.Hour {
+		t.Fatalf("unexpected rolling length: %v", got)
+	}
+}
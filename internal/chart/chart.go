@@ -0,0 +1,229 @@
+// Package chart renders small time-series figures as SVG or PNG without
+// external dependencies. A figure is laid out once and drawn onto either
+// backend, so both formats show the same panels, thresholds, and labels.
+package chart
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	Width = 800
+
+	titleHeight = 36
+	panelHeight = 190
+	marginLeft  = 64
+	marginRight = 24
+	plotTop     = 28
+	plotBottom  = 30
+)
+
+// Colors shared by callers that want series and thresholds to match across
+// figures.
+const (
+	ColorSeries    = "#1a73e8"
+	ColorObjective = "#188038"
+	ColorPage      = "#d93025"
+	ColorTicket    = "#e37400"
+	ColorBudget    = "#5f6368"
+	ColorChange    = "#9334e6"
+
+	colorText       = "#202124"
+	colorAxis       = "#9aa0a6"
+	colorGrid       = "#e8eaed"
+	colorBackground = "#ffffff"
+)
+
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Line is a horizontal reference line such as an objective or an alert
+// threshold.
+type Line struct {
+	Label string
+	Value float64
+	Color string
+}
+
+// Marker is a vertical line at a point in time such as a deploy. Markers
+// outside the points' time range are not drawn.
+type Marker struct {
+	Time  time.Time
+	Label string
+	Color string
+}
+
+type Panel struct {
+	Title   string
+	Unit    string
+	Points  []Point
+	Lines   []Line
+	Markers []Marker
+	// Min and Max fix the y axis; when Max <= Min the range covers the
+	// points and lines.
+	Min, Max float64
+	// Ceiling caps a derived range, for example at 100%.
+	Ceiling *float64
+}
+
+type Figure struct {
+	Title    string
+	Panels   []Panel
+	Location *time.Location
+}
+
+// Size returns the pixel size of a rendered figure.
+func Size(f Figure) (int, int) {
+	return Width, titleHeight + len(f.Panels)*panelHeight
+}
+
+// canvas is implemented by the SVG and raster backends. Coordinates are in
+// pixels from the top left; text y is the baseline.
+type canvas interface {
+	rect(x, y, w, h float64, fill string)
+	line(x1, y1, x2, y2 float64, stroke string, width float64, dashed bool)
+	polyline(points [][2]float64, stroke string, width float64)
+	text(x, y float64, s string, size float64, fill, anchor string)
+}
+
+func draw(c canvas, f Figure) {
+	width, height := Size(f)
+	c.rect(0, 0, float64(width), float64(height), colorBackground)
+	c.text(marginLeft, 24, f.Title, 16, colorText, "start")
+	loc := f.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	for i, panel := range f.Panels {
+		drawPanel(c, panel, float64(titleHeight+i*panelHeight), loc)
+	}
+}
+
+func drawPanel(c canvas, p Panel, top float64, loc *time.Location) {
+	left, right := float64(marginLeft), float64(Width-marginRight)
+	plotY0, plotY1 := top+plotTop, top+panelHeight-plotBottom
+	c.text(left, top+16, p.Title, 13, colorText, "start")
+
+	lo, hi, step := yRange(p)
+	y := func(v float64) float64 { return plotY1 - (v-lo)/(hi-lo)*(plotY1-plotY0) }
+	for v := lo; v <= hi+step/2; v += step {
+		c.line(left, y(v), right, y(v), colorGrid, 1, false)
+		c.text(left-6, y(v)+4, formatTick(v, step)+p.Unit, 11, colorText, "end")
+	}
+	c.line(left, plotY1, right, plotY1, colorAxis, 1, false)
+
+	if len(p.Points) == 0 {
+		c.text((left+right)/2, (plotY0+plotY1)/2, "no data", 12, colorAxis, "middle")
+		return
+	}
+	first, last := p.Points[0].Time, p.Points[len(p.Points)-1].Time
+	span := last.Sub(first)
+	x := func(t time.Time) float64 {
+		if span <= 0 {
+			return (left + right) / 2
+		}
+		return left + float64(t.Sub(first))/float64(span)*(right-left)
+	}
+	layout := "15:04"
+	if span >= 24*time.Hour {
+		layout = "01-02 15:04"
+	}
+	ticks := []time.Time{first, first.Add(span / 2), last}
+	anchors := []string{"start", "middle", "end"}
+	if span <= 0 {
+		ticks, anchors = ticks[:1], []string{"middle"}
+	}
+	for k, t := range ticks {
+		c.text(x(t), plotY1+16, t.In(loc).Format(layout), 11, colorText, anchors[k])
+	}
+
+	for _, ref := range p.Lines {
+		c.line(left, y(ref.Value), right, y(ref.Value), ref.Color, 1.5, true)
+		c.text(right-4, y(ref.Value)-4, ref.Label, 11, ref.Color, "end")
+	}
+	for _, marker := range p.Markers {
+		if marker.Time.Before(first) || marker.Time.After(last) {
+			continue
+		}
+		c.line(x(marker.Time), plotY0, x(marker.Time), plotY1, marker.Color, 1, true)
+		if marker.Label != "" {
+			c.text(x(marker.Time)+3, plotY0+10, marker.Label, 10, marker.Color, "start")
+		}
+	}
+
+	points := make([][2]float64, 0, len(p.Points))
+	for _, point := range p.Points {
+		points = append(points, [2]float64{x(point.Time), y(clampRange(point.Value, lo, hi))})
+	}
+	if len(points) == 1 {
+		c.rect(points[0][0]-3, points[0][1]-3, 6, 6, ColorSeries)
+		return
+	}
+	c.polyline(points, ColorSeries, 2)
+}
+
+// yRange returns the y axis bounds and tick step. Fixed bounds are used as
+// given; derived bounds are widened to whole multiples of a round step.
+func yRange(p Panel) (float64, float64, float64) {
+	if p.Max > p.Min {
+		return p.Min, p.Max, niceStep((p.Max - p.Min) / 4)
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, point := range p.Points {
+		lo, hi = math.Min(lo, point.Value), math.Max(hi, point.Value)
+	}
+	for _, ref := range p.Lines {
+		lo, hi = math.Min(lo, ref.Value), math.Max(hi, ref.Value)
+	}
+	if math.IsInf(lo, 0) {
+		return 0, 1, 0.25
+	}
+	if hi == lo {
+		lo, hi = lo-1, hi+1
+	}
+	step := niceStep((hi - lo) / 4)
+	lo = math.Floor(lo/step) * step
+	hi = math.Ceil(hi/step) * step
+	if p.Ceiling != nil && hi > *p.Ceiling {
+		hi = *p.Ceiling
+	}
+	if hi <= lo {
+		hi = lo + step
+	}
+	return lo, hi, step
+}
+
+// niceStep rounds a tick step up to 1, 2, 2.5, or 5 times a power of ten.
+func niceStep(raw float64) float64 {
+	if raw <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, factor := range []float64{1, 2, 2.5, 5, 10} {
+		if raw <= factor*magnitude {
+			return factor * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+func clampRange(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
+
+func formatTick(v, step float64) string {
+	decimals := 0
+	for decimals < 4 && math.Abs(step*math.Pow(10, float64(decimals))-math.Round(step*math.Pow(10, float64(decimals)))) > 1e-9 {
+		decimals++
+	}
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
+
+func formatCoord(v float64) string {
+	return fmt.Sprintf("%.1f", v)
+}
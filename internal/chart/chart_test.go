@@ -0,0 +1,101 @@
+package chart
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testFigure() Figure {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	var points []Point
+	for i, value := range []float64{1, 3, 18, 2} {
+		points = append(points, Point{Time: start.Add(time.Duration(i) * 10 * time.Minute), Value: value})
+	}
+	return Figure{
+		Title: "checkout <availability>",
+		Panels: []Panel{
+			{
+				Title:   "Burn rate",
+				Unit:    "x",
+				Points:  points,
+				Lines:   []Line{{Label: "page 14.4x", Value: 14.4, Color: ColorPage}},
+				Markers: []Marker{{Time: start.Add(15 * time.Minute), Label: "deploy", Color: ColorChange}, {Time: start.Add(-time.Hour), Label: "too early", Color: ColorChange}},
+			},
+			{Title: "Empty", Unit: "%"},
+		},
+	}
+}
+
+func TestSVG(t *testing.T) {
+	data := SVG(testFigure())
+	if err := xml.Unmarshal(data, new(struct{})); err != nil {
+		t.Fatalf("invalid SVG: %v\n%s", err, data)
+	}
+	text := string(data)
+	if strings.Contains(string(data), "too early") {
+		t.Fatalf("drew a marker outside the time range:\n%s", data)
+	}
+	for _, want := range []string{
+		`width="800" height="416"`,
+		"checkout &lt;availability&gt;",
+		`stroke-dasharray="6 4"`,
+		">page 14.4x</text>",
+		">deploy</text>",
+		">20x</text>",
+		">10:30</text>",
+		">no data</text>",
+		"<polyline ",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("SVG missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestPNG(t *testing.T) {
+	data, err := PNG(testFigure())
+	if err != nil {
+		t.Fatalf("png: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	width, height := Size(testFigure())
+	if img.Bounds().Dx() != width || img.Bounds().Dy() != height {
+		t.Fatalf("unexpected size %v", img.Bounds())
+	}
+	series := parseColor(ColorSeries)
+	found := false
+	for y := 0; y < height && !found; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if uint8(r>>8) == series.R && uint8(g>>8) == series.G && uint8(b>>8) == series.B {
+				found = true
+				break
+			}
+		}
+	}
+	if !found {
+		t.Fatal("series was not drawn")
+	}
+}
+
+func TestYRange(t *testing.T) {
+	lo, hi, step := yRange(Panel{Points: []Point{{Value: 3}, {Value: 374}}, Lines: []Line{{Value: 100}}})
+	if lo != 0 || hi != 400 || step != 100 {
+		t.Fatalf("unexpected range %v..%v step %v", lo, hi, step)
+	}
+	ceiling := 100.0
+	lo, hi, _ = yRange(Panel{Points: []Point{{Value: 98.1}, {Value: 99.95}}, Ceiling: &ceiling})
+	if lo != 98 || hi != 100 {
+		t.Fatalf("unexpected capped range %v..%v", lo, hi)
+	}
+	if got := formatTick(99.5, 0.5); got != "99.5" {
+		t.Fatalf("unexpected tick %q", got)
+	}
+}
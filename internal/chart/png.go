@@ -0,0 +1,112 @@
+package chart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PNG rasterizes a figure. Text uses a built-in 5x7 bitmap font that only
+// has upper-case letters, digits, and common punctuation; other characters
+// are drawn as spaces.
+func PNG(f Figure) ([]byte, error) {
+	width, height := Size(f)
+	c := &rasterCanvas{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+	draw(c, f)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type rasterCanvas struct {
+	img *image.RGBA
+}
+
+func (c *rasterCanvas) rect(x, y, w, h float64, fill string) {
+	col := parseColor(fill)
+	for py := int(math.Round(y)); py < int(math.Round(y+h)); py++ {
+		for px := int(math.Round(x)); px < int(math.Round(x+w)); px++ {
+			c.img.Set(px, py, col)
+		}
+	}
+}
+
+func (c *rasterCanvas) line(x1, y1, x2, y2 float64, stroke string, width float64, dashed bool) {
+	col := parseColor(stroke)
+	length := math.Hypot(x2-x1, y2-y1)
+	steps := int(math.Ceil(length))
+	if steps == 0 {
+		steps = 1
+	}
+	half := math.Max(width/2, 0.5)
+	for i := 0; i <= steps; i++ {
+		// Dashes match the SVG stroke-dasharray of 6 on, 4 off.
+		if dashed && i%10 >= 6 {
+			continue
+		}
+		t := float64(i) / float64(steps)
+		c.dot(x1+(x2-x1)*t, y1+(y2-y1)*t, half, col)
+	}
+}
+
+func (c *rasterCanvas) polyline(points [][2]float64, stroke string, width float64) {
+	for i := 1; i < len(points); i++ {
+		c.line(points[i-1][0], points[i-1][1], points[i][0], points[i][1], stroke, width, false)
+	}
+}
+
+func (c *rasterCanvas) dot(x, y, half float64, col color.Color) {
+	for py := int(math.Floor(y - half + 0.5)); py < int(math.Floor(y+half+0.5)); py++ {
+		for px := int(math.Floor(x - half + 0.5)); px < int(math.Floor(x+half+0.5)); px++ {
+			c.img.Set(px, py, col)
+		}
+	}
+}
+
+func (c *rasterCanvas) text(x, y float64, s string, size float64, fill, anchor string) {
+	scale := int(math.Max(1, math.Round(size/9)))
+	s = strings.ToUpper(s)
+	advance := (glyphWidth + 1) * scale
+	width := len([]rune(s))*advance - scale
+	left := int(math.Round(x))
+	switch anchor {
+	case "middle":
+		left -= width / 2
+	case "end":
+		left -= width
+	}
+	top := int(math.Round(y)) - glyphHeight*scale
+	col := parseColor(fill)
+	for i, r := range []rune(s) {
+		rows, ok := glyphs[r]
+		if !ok {
+			continue
+		}
+		for row, bits := range rows {
+			for col0 := 0; col0 < glyphWidth; col0++ {
+				if bits[col0] != '1' {
+					continue
+				}
+				for dy := 0; dy < scale; dy++ {
+					for dx := 0; dx < scale; dx++ {
+						c.img.Set(left+i*advance+col0*scale+dx, top+row*scale+dy, col)
+					}
+				}
+			}
+		}
+	}
+}
+
+func parseColor(hex string) color.RGBA {
+	value, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil {
+		return color.RGBA{A: 0xff}
+	}
+	return color.RGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 0xff}
+}
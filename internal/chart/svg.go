@@ -0,0 +1,47 @@
+package chart
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// SVG renders a figure as a standalone SVG document that can be embedded in
+// Markdown by reference or inlined into HTML.
+func SVG(f Figure) []byte {
+	width, height := Size(f)
+	c := &svgCanvas{}
+	fmt.Fprintf(&c.b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Helvetica, Arial, sans-serif">`+"\n", width, height, width, height)
+	fmt.Fprintf(&c.b, "<title>%s</title>\n", html.EscapeString(f.Title))
+	draw(c, f)
+	c.b.WriteString("</svg>\n")
+	return []byte(c.b.String())
+}
+
+type svgCanvas struct {
+	b strings.Builder
+}
+
+func (c *svgCanvas) rect(x, y, w, h float64, fill string) {
+	fmt.Fprintf(&c.b, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`+"\n", formatCoord(x), formatCoord(y), formatCoord(w), formatCoord(h), fill)
+}
+
+func (c *svgCanvas) line(x1, y1, x2, y2 float64, stroke string, width float64, dashed bool) {
+	dash := ""
+	if dashed {
+		dash = ` stroke-dasharray="6 4"`
+	}
+	fmt.Fprintf(&c.b, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s" stroke-width="%s"%s/>`+"\n", formatCoord(x1), formatCoord(y1), formatCoord(x2), formatCoord(y2), stroke, formatCoord(width), dash)
+}
+
+func (c *svgCanvas) polyline(points [][2]float64, stroke string, width float64) {
+	coords := make([]string, 0, len(points))
+	for _, p := range points {
+		coords = append(coords, formatCoord(p[0])+","+formatCoord(p[1]))
+	}
+	fmt.Fprintf(&c.b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%s" stroke-linejoin="round"/>`+"\n", strings.Join(coords, " "), stroke, formatCoord(width))
+}
+
+func (c *svgCanvas) text(x, y float64, s string, size float64, fill, anchor string) {
+	fmt.Fprintf(&c.b, `<text x="%s" y="%s" font-size="%s" fill="%s" text-anchor="%s">%s</text>`+"\n", formatCoord(x), formatCoord(y), formatCoord(size), fill, anchor, html.EscapeString(s))
+}
@@ -54,7 +54,7 @@ func Import(ctx context.Context, client *monitoring.GCPClient, opts Options) (Re
 	}
 
 	specDoc := spec.Spec{
-		APIVersion: spec.APIVersionV1,
+		APIVersion: spec.APIVersionLatest,
 		Kind:       spec.KindServiceSLO,
 		Metadata: spec.Metadata{
 			Name:    opts.ServiceID,
@@ -366,18 +366,11 @@ func durationToWindow(duration *durationpb.Duration) (string, error) {
 		return "", errors.New("missing rolling period")
 	}
 	value := duration.AsDuration()
-	switch {
-	case value%(7*24*time.Hour) == 0:
-		return fmt.Sprintf("%dw", value/(7*24*time.Hour)), nil
-	case value%(24*time.Hour) == 0:
-		return fmt.Sprintf("%dd", value/(24*time.Hour)), nil
-	case value%time.Hour == 0:
-		return fmt.Sprintf("%dh", value/time.Hour), nil
-	case value%time.Minute == 0:
-		return fmt.Sprintf("%dm", value/time.Minute), nil
-	default:
+	window, ok := spec.FormatWindow(value)
+	if !ok || value%time.Minute != 0 {
 		return "", fmt.Errorf("rolling period %s cannot be expressed as window", value)
 	}
+	return window, nil
 }
 
 func calendarToWindow(period calendarperiod.CalendarPeriod) string {
@@ -396,6 +389,30 @@ func calendarToWindow(period calendarperiod.CalendarPeriod) string {
 }
 
 func parseFilter(filter string) (string, string, string) {
+	parsed, err := spec.ParseFilter(filter)
+	if err != nil {
+		return splitFilter(filter)
+	}
+	var metricType, resourceType string
+	var extra []string
+	for _, term := range parsed.Conjuncts() {
+		if cmp, ok := term.(*spec.FilterComparison); ok && cmp.Operator == "=" && cmp.Value.Kind == spec.FilterString {
+			switch cmp.Selector {
+			case "metric.type":
+				metricType = cmp.Value.Literal
+				continue
+			case "resource.type":
+				resourceType = cmp.Value.Literal
+				continue
+			}
+		}
+		extra = append(extra, parsed.Text(term))
+	}
+	return metricType, resourceType, strings.Join(extra, " AND ")
+}
+
+// splitFilter is the best-effort fallback for filters the parser rejects.
+func splitFilter(filter string) (string, string, string) {
 	parts := strings.Split(filter, " AND ")
 	var metricType, resourceType string
 	var extra []string
@@ -434,15 +451,7 @@ func parseFilterPart(part string) (string, string, bool) {
 }
 
 func formatSeconds(seconds float64) string {
-	duration := durationpb.New(secondsToDuration(seconds)).AsDuration()
-	switch {
-	case duration%time.Millisecond == 0 && duration < time.Second:
-		return fmt.Sprintf("%dms", duration/time.Millisecond)
-	case duration%time.Second == 0:
-		return fmt.Sprintf("%ds", duration/time.Second)
-	default:
-		return duration.String()
-	}
+	return spec.FormatThreshold(durationpb.New(secondsToDuration(seconds)).AsDuration())
 }
 
 func secondsToDuration(seconds float64) time.Duration {
@@ -23,6 +23,31 @@ func TestParseFilter(t *testing.T) {
 	}
 }
 
+func TestParseFilterKeepsOrGroups(t *testing.T) {
+	filter := `metric.type = "run.googleapis.com/request_count" AND resource.type = "cloud_run_revision" AND metric.label.response_code = "200" OR metric.label.response_code = "204"`
+	metric, resource, extra := parseFilter(filter)
+	if metric != "run.googleapis.com/request_count" || resource != "cloud_run_revision" {
+		t.Fatalf("unexpected metric/resource: %q %q", metric, resource)
+	}
+	if extra != `metric.label.response_code = "200" OR metric.label.response_code = "204"` {
+		t.Fatalf("extra parse mismatch: %q", extra)
+	}
+}
+
+func TestParseFilterNestedAnd(t *testing.T) {
+	filter := `metric.type="run.googleapis.com/request_count" AND (metric.label.a="x AND y" AND resource.type="cloud_run_revision")`
+	metric, resource, extra := parseFilter(filter)
+	if metric != "run.googleapis.com/request_count" {
+		t.Fatalf("metric parse mismatch: %q", metric)
+	}
+	if resource != "" {
+		t.Fatalf("expected nested resource.type to stay in extra, got %q", resource)
+	}
+	if extra != `(metric.label.a="x AND y" AND resource.type="cloud_run_revision")` {
+		t.Fatalf("extra parse mismatch: %q", extra)
+	}
+}
+
 func TestDurationToWindow(t *testing.T) {
 	window, err := durationToWindow(durationpb.New(30 * 24 * time.Hour))
 	if err != nil {
@@ -0,0 +1,143 @@
+package lint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/bayneri/margin/internal/planner"
+	"github.com/bayneri/margin/internal/spec"
+	"gopkg.in/yaml.v3"
+)
+
+const ConfigFile = ".marginlint.yaml"
+
+const CodeUnknownRule = "unknown-lint-rule"
+
+type Rule struct {
+	ID          string
+	Description string
+	Severity    spec.Severity
+	// Pitfall rules only run for templates that list the rule ID in one of
+	// their spec.Pitfall checks.
+	Pitfall bool
+	Check   func(ctx Context) []Finding
+}
+
+type Context struct {
+	Spec     spec.Spec
+	Template spec.ServiceTemplate
+	Plan     planner.Plan
+}
+
+type Finding struct {
+	Path    string
+	Message string
+}
+
+type Config struct {
+	Disable []string `yaml:"disable"`
+}
+
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("read lint config: %w", err)
+	}
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, fmt.Errorf("parse lint config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func Rules() []Rule {
+	out := make([]Rule, len(rules))
+	copy(out, rules)
+	return out
+}
+
+// Run lints a spec with the built-in rules. Rules disabled in the spec's
+// lint block or in cfg are skipped.
+func Run(s spec.Spec, cfg Config) spec.ValidationErrors {
+	return RunRules(s, cfg, rules)
+}
+
+func RunRules(s spec.Spec, cfg Config, ruleset []Rule) spec.ValidationErrors {
+	known := map[string]bool{}
+	for _, rule := range ruleset {
+		known[rule.ID] = true
+	}
+
+	var out spec.ValidationErrors
+	disabled := map[string]bool{}
+	for _, id := range cfg.Disable {
+		if !known[id] {
+			out = append(out, unknownRule(ConfigFile, id))
+		}
+		disabled[id] = true
+	}
+	for i, id := range s.Lint.Disable {
+		if !known[id] {
+			out = append(out, locate(s, unknownRule(fmt.Sprintf("lint.disable[%d]", i), id)))
+		}
+		disabled[id] = true
+	}
+
+	template, _ := spec.TemplateForService(s.Metadata.Service)
+	pitfalls := map[string]bool{}
+	for _, pitfall := range template.Pitfalls {
+		if pitfall.Check != "" {
+			pitfalls[pitfall.Check] = true
+		}
+	}
+	ctx := Context{
+		Spec:     s,
+		Template: template,
+		Plan:     planner.Build(s, planner.Options{}),
+	}
+
+	for _, rule := range ruleset {
+		if disabled[rule.ID] || (rule.Pitfall && !pitfalls[rule.ID]) {
+			continue
+		}
+		for _, finding := range rule.Check(ctx) {
+			out = append(out, locate(s, spec.ValidationError{
+				Path:     finding.Path,
+				Code:     rule.ID,
+				Severity: rule.Severity,
+				Message:  finding.Message,
+			}))
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Line != out[j].Line {
+			return out[i].Line < out[j].Line
+		}
+		return out[i].Column < out[j].Column
+	})
+	return out
+}
+
+func unknownRule(path, id string) spec.ValidationError {
+	return spec.ValidationError{
+		Path:     path,
+		Code:     CodeUnknownRule,
+		Severity: spec.SeverityWarning,
+		Message:  fmt.Sprintf("%s: unknown lint rule %q", path, id),
+	}
+}
+
+func locate(s spec.Spec, item spec.ValidationError) spec.ValidationError {
+	if pos, ok := s.Position(item.Path); ok {
+		item.Line = pos.Line
+		item.Column = pos.Column
+	}
+	return item
+}
@@ -0,0 +1,157 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bayneri/margin/internal/spec"
+)
+
+const lintSpecYAML = `apiVersion: margin/v1
+kind: ServiceSLO
+metadata:
+  name: checkout-api
+  service: cloud-run
+  project: demo
+  runbook: https://runbooks.example.com/checkout
+slos:
+  - name: availability
+    objective: 99.9
+    window: 30d
+    sli:
+      type: request-based
+      good:
+        metric: run.googleapis.com/request_count
+        filter: resource.type="cloud_run_revision" AND metric.label.response_code_class="2xx"
+      total:
+        metric: run.googleapis.com/request_count
+`
+
+func parse(t *testing.T, doc string) spec.Spec {
+	t.Helper()
+	s, err := spec.Parse([]byte(doc))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return s
+}
+
+func codes(errs spec.ValidationErrors) []string {
+	var out []string
+	for _, item := range errs {
+		out = append(out, item.Code)
+	}
+	return out
+}
+
+func TestRunCleanSpec(t *testing.T) {
+	if got := Run(parse(t, lintSpecYAML), Config{}); len(got) != 0 {
+		t.Fatalf("expected no findings, got %v", got)
+	}
+}
+
+func TestRunFindings(t *testing.T) {
+	cases := []struct {
+		name string
+		old  string
+		new  string
+		code string
+	}{
+		{"budget", "objective: 99.9", "objective: 99.99", RuleBudgetTooSmall},
+		{"runbook", "  runbook: https://runbooks.example.com/checkout\n", "", RuleRunbookForPaging},
+		{"labels", "  project: demo\n", "  project: demo\n  labels:\n    Team: payments\n", RuleLabelKey},
+		{"good-equals-total", ` AND metric.label.response_code_class="2xx"`, "", RuleGoodEqualsTotal},
+		{"retries", `metric.label.response_code_class="2xx"`, `metric.label.retry_attempt="true"`, spec.CheckRetries},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := strings.Replace(lintSpecYAML, tc.old, tc.new, 1)
+			got := Run(parse(t, doc), Config{})
+			if len(got) != 1 || got[0].Code != tc.code {
+				t.Fatalf("expected %s, got %v", tc.code, codes(got))
+			}
+			if got[0].Line == 0 {
+				t.Fatalf("expected position, got %+v", got[0])
+			}
+		})
+	}
+}
+
+func TestLatencyBelowFloor(t *testing.T) {
+	s := parse(t, lintSpecYAML)
+	s.SLOs[0].SLI = spec.SLI{
+		Type:      "latency",
+		Metric:    "run.googleapis.com/request_latencies",
+		Filter:    `resource.type="cloud_run_revision"`,
+		Threshold: "2ms",
+	}
+	got := Run(s, Config{})
+	if len(got) != 1 || got[0].Code != RuleLatencyFloor || got[0].Path != "slos[0].sli.threshold" {
+		t.Fatalf("unexpected findings %v", got)
+	}
+}
+
+func TestPitfallRulesFollowTemplate(t *testing.T) {
+	s := parse(t, lintSpecYAML)
+	s.Metadata.Service = "https-load-balancer"
+	s.SLOs[0].SLI.Good.Filter = `metric.label.response_code_class = one_of("2xx", "4xx")`
+	if got := Run(s, Config{}); len(got) != 1 || got[0].Code != spec.CheckNotFound {
+		t.Fatalf("expected 404 finding, got %v", codes(got))
+	}
+
+	s.Metadata.Service = "cloud-run"
+	if got := Run(s, Config{}); len(got) != 0 {
+		t.Fatalf("cloud-run does not list the 404 pitfall, got %v", codes(got))
+	}
+}
+
+func TestRetriesRunForEveryTemplate(t *testing.T) {
+	s := parse(t, strings.Replace(lintSpecYAML, `metric.label.response_code_class="2xx"`, `metric.label.retry_attempt="true"`, 1))
+	s.Metadata.Service = "gke-service"
+	got := Run(s, Config{})
+	if len(got) != 1 || got[0].Code != spec.CheckRetries || strings.Contains(got[0].Message, "(") {
+		t.Fatalf("expected a plain retries finding for gke-service, got %v", got)
+	}
+
+	s.Metadata.Service = "cloud-run"
+	got = Run(s, Config{})
+	if len(got) != 1 || !strings.HasSuffix(got[0].Message, "(cloud-run: Retries can double-count failed requests unless filters exclude them.)") {
+		t.Fatalf("expected the cloud-run pitfall in the message, got %v", got)
+	}
+}
+
+func TestDisableRules(t *testing.T) {
+	doc := strings.Replace(lintSpecYAML, "objective: 99.9", "objective: 99.99", 1)
+	doc += "lint:\n  disable:\n    - objective-budget-too-small\n"
+	if got := Run(parse(t, doc), Config{}); len(got) != 0 {
+		t.Fatalf("expected rule disabled in spec, got %v", got)
+	}
+
+	s := parse(t, strings.Replace(lintSpecYAML, "objective: 99.9", "objective: 99.99", 1))
+	got := Run(s, Config{Disable: []string{RuleBudgetTooSmall, "no-such-rule"}})
+	if len(got) != 1 || got[0].Code != CodeUnknownRule || got[0].Severity != spec.SeverityWarning {
+		t.Fatalf("expected unknown rule warning, got %v", got)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ConfigFile)
+	if err := os.WriteFile(path, []byte("disable:\n  - good-equals-total\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Disable) != 1 || cfg.Disable[0] != RuleGoodEqualsTotal {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if err := os.WriteFile(path, []byte("disabled: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("expected unknown key error")
+	}
+}
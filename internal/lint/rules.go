@@ -0,0 +1,383 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bayneri/margin/internal/spec"
+)
+
+const (
+	RuleBudgetTooSmall   = "objective-budget-too-small"
+	RuleLatencyFloor     = "latency-below-floor"
+	RuleGoodEqualsTotal  = "good-equals-total"
+	RuleRunbookForPaging = "runbook-missing-for-paging"
+	RuleLabelKey         = "invalid-label-key"
+)
+
+// MinErrorBudget is the smallest error budget per window that a human can
+// realistically respond to before it is exhausted.
+const MinErrorBudget = 5 * time.Minute
+
+const maxLabelLength = 63
+
+var labelKeyRe = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+var labelValueRe = regexp.MustCompile(`^[a-z0-9_-]*$`)
+
+var rules = []Rule{
+	{
+		ID:          RuleBudgetTooSmall,
+		Description: "Objective leaves less than 5 minutes of error budget per window.",
+		Severity:    spec.SeverityWarning,
+		Check:       checkBudgetTooSmall,
+	},
+	{
+		ID:          RuleLatencyFloor,
+		Description: "Latency threshold is below what the service typically achieves.",
+		Severity:    spec.SeverityWarning,
+		Check:       checkLatencyFloor,
+	},
+	{
+		ID:          RuleGoodEqualsTotal,
+		Description: "Good and total select the same time series, so the SLI is always 100%.",
+		Severity:    spec.SeverityWarning,
+		Check:       checkGoodEqualsTotal,
+	},
+	{
+		ID:          RuleRunbookForPaging,
+		Description: "SLO pages on fast burn but metadata.runbook is empty.",
+		Severity:    spec.SeverityWarning,
+		Check:       checkRunbookForPaging,
+	},
+	{
+		ID:          RuleLabelKey,
+		Description: "Label does not meet Cloud Monitoring label rules.",
+		Severity:    spec.SeverityError,
+		Check:       checkLabels,
+	},
+	{
+		ID:          spec.CheckRetries,
+		Description: "Good filter selects retried attempts.",
+		Severity:    spec.SeverityWarning,
+		Check:       checkRetries,
+	},
+	{
+		ID:          spec.CheckNotFound,
+		Description: "Good filter counts 404 responses as good.",
+		Severity:    spec.SeverityWarning,
+		Pitfall:     true,
+		Check:       checkNotFound,
+	},
+	{
+		ID:          spec.CheckHealthChecks,
+		Description: "Filters do not exclude health check traffic.",
+		Severity:    spec.SeverityWarning,
+		Pitfall:     true,
+		Check:       checkHealthChecks,
+	},
+	{
+		ID:          spec.CheckCacheHits,
+		Description: "Good filter does not distinguish cache hits from backend responses.",
+		Severity:    spec.SeverityWarning,
+		Pitfall:     true,
+		Check:       checkCacheHits,
+	},
+}
+
+func checkBudgetTooSmall(ctx Context) []Finding {
+	var out []Finding
+	for i, slo := range ctx.Spec.SLOs {
+		window, err := spec.ParseWindow(slo.Window)
+		if err != nil || slo.Objective <= 0 || slo.Objective >= 100 {
+			continue
+		}
+		budget := time.Duration((1 - slo.Objective/100) * float64(window))
+		if budget >= MinErrorBudget {
+			continue
+		}
+		out = append(out, Finding{
+			Path: fmt.Sprintf("slos[%d].objective", i),
+			Message: fmt.Sprintf("slo %q: objective %g over %s leaves %s of error budget (less than %s)",
+				slo.Name, slo.Objective, slo.Window, budget.Round(time.Second), MinErrorBudget),
+		})
+	}
+	return out
+}
+
+func checkLatencyFloor(ctx Context) []Finding {
+	floor := ctx.Template.LatencyFloor
+	if floor == 0 {
+		return nil
+	}
+	var out []Finding
+	for i, slo := range ctx.Spec.SLOs {
+		if slo.SLI.Type != "latency" {
+			continue
+		}
+		threshold, err := time.ParseDuration(strings.TrimSpace(slo.SLI.Threshold))
+		if err != nil || threshold >= floor {
+			continue
+		}
+		out = append(out, Finding{
+			Path: fmt.Sprintf("slos[%d].sli.threshold", i),
+			Message: fmt.Sprintf("slo %q: threshold %s is below the typical %s floor for %s",
+				slo.Name, threshold, floor, ctx.Template.Name),
+		})
+	}
+	return out
+}
+
+func checkGoodEqualsTotal(ctx Context) []Finding {
+	var out []Finding
+	for i, slo := range ctx.Spec.SLOs {
+		good, total := slo.SLI.Good, slo.SLI.Total
+		if slo.SLI.Type != "request-based" || good == nil || total == nil {
+			continue
+		}
+		if strings.TrimSpace(good.Metric) != strings.TrimSpace(total.Metric) {
+			continue
+		}
+		goodTerms, ok := selectionTerms(good.Filter)
+		if !ok {
+			continue
+		}
+		totalTerms, ok := selectionTerms(total.Filter)
+		if !ok || strings.Join(goodTerms, "\x00") != strings.Join(totalTerms, "\x00") {
+			continue
+		}
+		out = append(out, Finding{
+			Path:    fmt.Sprintf("slos[%d].sli.good.filter", i),
+			Message: fmt.Sprintf("slo %q: good filter selects the same series as total; the SLI will always be 100%%", slo.Name),
+		})
+	}
+	return out
+}
+
+// selectionTerms returns the normalized top-level clauses of a filter,
+// ignoring the resource.type and metric.type clauses that apply adds anyway.
+func selectionTerms(filter string) ([]string, bool) {
+	if strings.TrimSpace(filter) == "" {
+		return nil, true
+	}
+	parsed, err := spec.ParseFilter(filter)
+	if err != nil {
+		return nil, false
+	}
+	var terms []string
+	for _, term := range parsed.Conjuncts() {
+		if cmp, ok := term.(*spec.FilterComparison); ok && cmp.Operator == "=" &&
+			(cmp.Selector == "resource.type" || cmp.Selector == "metric.type") {
+			continue
+		}
+		terms = append(terms, strings.Join(strings.Fields(parsed.Text(term)), " "))
+	}
+	sort.Strings(terms)
+	return terms, true
+}
+
+func checkRunbookForPaging(ctx Context) []Finding {
+	var out []Finding
+	for i, slo := range ctx.Spec.SLOs {
+		for _, alert := range ctx.Plan.Alerts {
+			if alert.SLOName != slo.Name || alert.Severity != "page" || strings.TrimSpace(alert.Runbook) != "" {
+				continue
+			}
+			out = append(out, Finding{
+				Path:    fmt.Sprintf("slos[%d]", i),
+				Message: fmt.Sprintf("slo %q pages on %s but metadata.runbook is empty", slo.Name, alert.Type),
+			})
+			break
+		}
+	}
+	return out
+}
+
+func checkLabels(ctx Context) []Finding {
+	keys := make([]string, 0, len(ctx.Spec.Metadata.Labels))
+	for key := range ctx.Spec.Metadata.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var out []Finding
+	for _, key := range keys {
+		path := "metadata.labels." + key
+		value := ctx.Spec.Metadata.Labels[key]
+		switch {
+		case len(key) > maxLabelLength:
+			out = append(out, Finding{Path: path, Message: fmt.Sprintf("label key %q is longer than %d characters", key, maxLabelLength)})
+		case !labelKeyRe.MatchString(key):
+			out = append(out, Finding{Path: path, Message: fmt.Sprintf("label key %q must start with a lowercase letter and contain only lowercase letters, digits, underscores, and dashes", key)})
+		}
+		switch {
+		case len(value) > maxLabelLength:
+			out = append(out, Finding{Path: path, Message: fmt.Sprintf("label %q value is longer than %d characters", key, maxLabelLength)})
+		case !labelValueRe.MatchString(value):
+			out = append(out, Finding{Path: path, Message: fmt.Sprintf("label %q value %q must contain only lowercase letters, digits, underscores, and dashes", key, value)})
+		}
+	}
+	return out
+}
+
+// checkRetries runs for every template; templates that list the pitfall add
+// their own explanation to the message.
+func checkRetries(ctx Context) []Finding {
+	var why string
+	for _, pitfall := range ctx.Template.Pitfalls {
+		if pitfall.Check == spec.CheckRetries {
+			why = fmt.Sprintf(" (%s: %s)", ctx.Template.Name, pitfall.Text)
+		}
+	}
+	return checkGoodFilters(ctx, func(slo spec.SLO, filter *spec.Filter) string {
+		for _, cmp := range selected(filter) {
+			name := strings.ToLower(cmp.Selector)
+			if !strings.Contains(name, "retr") && !strings.Contains(name, "attempt") {
+				continue
+			}
+			switch strings.ToLower(cmp.Value.Literal) {
+			case "false", "0", "1":
+				continue
+			}
+			return fmt.Sprintf("slo %q: good filter selects retries via %s; retried requests will be counted more than once%s", slo.Name, cmp.Selector, why)
+		}
+		return ""
+	})
+}
+
+func checkNotFound(ctx Context) []Finding {
+	return checkGoodFilters(ctx, func(slo spec.SLO, filter *spec.Filter) string {
+		for _, cmp := range selected(filter) {
+			if !strings.Contains(strings.ToLower(cmp.Selector), "response_code") {
+				continue
+			}
+			if countsNotFound(cmp) {
+				return fmt.Sprintf("slo %q: good filter counts 404 responses as good via %s", slo.Name, cmp.Selector)
+			}
+		}
+		return ""
+	})
+}
+
+func countsNotFound(cmp *spec.FilterComparison) bool {
+	values := []string{cmp.Value.Literal}
+	if cmp.Value.Kind == spec.FilterFunction {
+		values = cmp.Value.Args
+	}
+	for _, value := range values {
+		value = strings.ToLower(value)
+		switch cmp.Operator {
+		case "=", ":":
+			if value == "4xx" || value == "404" {
+				return true
+			}
+		case "<", "<=":
+			if n, err := strconv.Atoi(value); err == nil && (n > 404 || (n == 404 && cmp.Operator == "<=")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func checkHealthChecks(ctx Context) []Finding {
+	var out []Finding
+	for i, slo := range ctx.Spec.SLOs {
+		var filters []string
+		switch slo.SLI.Type {
+		case "request-based":
+			if slo.SLI.Good == nil || slo.SLI.Total == nil {
+				continue
+			}
+			filters = []string{slo.SLI.Good.Filter, slo.SLI.Total.Filter}
+		case "latency":
+			filters = []string{slo.SLI.Filter}
+		default:
+			continue
+		}
+		excluded := false
+		for _, filter := range filters {
+			parsed, err := spec.ParseFilter(filter)
+			if err != nil {
+				continue
+			}
+			spec.WalkFilter(parsed.Root, func(node spec.FilterNode, negated bool) {
+				cmp, ok := node.(*spec.FilterComparison)
+				if !ok || !mentions(cmp, "health") {
+					return
+				}
+				if negated != (cmp.Operator == "!=") {
+					excluded = true
+				}
+			})
+		}
+		if excluded {
+			continue
+		}
+		out = append(out, Finding{
+			Path:    fmt.Sprintf("slos[%d].sli", i),
+			Message: fmt.Sprintf("slo %q: filters do not exclude health check traffic", slo.Name),
+		})
+	}
+	return out
+}
+
+func checkCacheHits(ctx Context) []Finding {
+	return checkGoodFilters(ctx, func(slo spec.SLO, filter *spec.Filter) string {
+		for _, cmp := range filter.Comparisons() {
+			if mentions(cmp, "cache") {
+				return ""
+			}
+		}
+		return fmt.Sprintf("slo %q: good filter does not reference cache_result, so cache hits can mask backend errors", slo.Name)
+	})
+}
+
+// checkGoodFilters runs check against the parsed good filter of every
+// request-based SLO.
+func checkGoodFilters(ctx Context, check func(slo spec.SLO, filter *spec.Filter) string) []Finding {
+	var out []Finding
+	for i, slo := range ctx.Spec.SLOs {
+		if slo.SLI.Type != "request-based" || slo.SLI.Good == nil {
+			continue
+		}
+		parsed, err := spec.ParseFilter(slo.SLI.Good.Filter)
+		if err != nil {
+			continue
+		}
+		if msg := check(slo, parsed); msg != "" {
+			out = append(out, Finding{Path: fmt.Sprintf("slos[%d].sli.good.filter", i), Message: msg})
+		}
+	}
+	return out
+}
+
+// selected returns the comparisons that positively select series, skipping
+// negated terms and inequality operators.
+func selected(filter *spec.Filter) []*spec.FilterComparison {
+	var out []*spec.FilterComparison
+	spec.WalkFilter(filter.Root, func(node spec.FilterNode, negated bool) {
+		if cmp, ok := node.(*spec.FilterComparison); ok && !negated && cmp.Operator != "!=" {
+			out = append(out, cmp)
+		}
+	})
+	return out
+}
+
+func mentions(cmp *spec.FilterComparison, word string) bool {
+	if strings.Contains(strings.ToLower(cmp.Selector), word) {
+		return true
+	}
+	if strings.Contains(strings.ToLower(cmp.Value.Literal), word) {
+		return true
+	}
+	for _, arg := range cmp.Value.Args {
+		if strings.Contains(strings.ToLower(arg), word) {
+			return true
+		}
+	}
+	return false
+}
@@ -130,11 +130,11 @@ func mergeLabels(base, extra map[string]string) map[string]string {
 }
 
 func alertPlans(specDoc spec.Spec, labels map[string]string, burnRateResourceType string) []AlertPlan {
-	return buildAlerts(specDoc, labels, burnRateResourceType, "fast-burn", []string{"5m", "1h"}, 14.4, "page")
+	return buildAlerts(specDoc, labels, burnRateResourceType, "fast-burn", spec.DefaultFastBurn.Windows, spec.DefaultFastBurn.BurnRate, "page")
 }
 
 func slowAlertPlans(specDoc spec.Spec, labels map[string]string, burnRateResourceType string) []AlertPlan {
-	return buildAlerts(specDoc, labels, burnRateResourceType, "slow-burn", []string{"30m", "6h"}, 6.0, "ticket")
+	return buildAlerts(specDoc, labels, burnRateResourceType, "slow-burn", spec.DefaultSlowBurn.Windows, spec.DefaultSlowBurn.BurnRate, "ticket")
 }
 
 func buildAlerts(specDoc spec.Spec, labels map[string]string, burnRateResourceType string, alertType string, defaultWindows []string, defaultBurnRate float64, severity string) []AlertPlan {
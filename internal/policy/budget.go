@@ -0,0 +1,79 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bayneri/margin/internal/analyze"
+	"github.com/bayneri/margin/internal/planner"
+	"github.com/bayneri/margin/internal/spec"
+)
+
+// Budgets reads the live error budget of every SLO in the plan. Each SLO is
+// measured over its own compliance period as configured in Cloud Monitoring,
+// ending at now, against the whole period's budget as margin analyze and
+// margin forecast measure it. SLOs that are missing or have no data get Budget.Error set
+// instead of failing the whole read.
+func Budgets(ctx context.Context, reader analyze.Reader, plan planner.Plan, now time.Time) ([]Budget, error) {
+	serviceName := fmt.Sprintf("projects/%s/services/%s", plan.Project, plan.ServiceID)
+	live, err := reader.ListServiceLevelObjectives(ctx, serviceName, 0)
+	if err != nil {
+		return nil, fmt.Errorf("list SLOs for %s: %w", serviceName, err)
+	}
+	byName := map[string]analyze.SLO{}
+	for _, slo := range live {
+		byName[slo.Name] = slo
+	}
+
+	budgets := make([]Budget, 0, len(plan.SLOs))
+	for _, planned := range plan.SLOs {
+		budget := Budget{
+			SLO:             planned.Name,
+			SLOResourceName: fmt.Sprintf("%s/serviceLevelObjectives/%s", serviceName, planned.ResourceID),
+		}
+		slo, ok := byName[budget.SLOResourceName]
+		if !ok {
+			budget.Error = "SLO not found in Cloud Monitoring; run margin apply"
+			budgets = append(budgets, budget)
+			continue
+		}
+		budget.Period = periodLabel(slo)
+		start, end, err := analyze.PeriodWindow(slo, now)
+		if err != nil {
+			budget.Error = err.Error()
+			budgets = append(budgets, budget)
+			continue
+		}
+		compliance, err := reader.FetchCompliance(ctx, plan.Project, slo.Name, start, end)
+		if err != nil {
+			budget.Error = err.Error()
+			budgets = append(budgets, budget)
+			continue
+		}
+		if slo.Goal >= 1 {
+			budget.Error = "goal is 100%; there is no error budget"
+		}
+		period := analyze.ComputePeriod(analyze.PeriodInput{
+			Goal:       slo.Goal,
+			Start:      start,
+			Now:        end,
+			Length:     analyze.PeriodLength(slo, start),
+			Compliance: compliance,
+		})
+		budget.Consumed = period.ConsumedPercentOfBudget
+		budgets = append(budgets, budget)
+	}
+	return budgets, nil
+}
+
+func periodLabel(slo analyze.SLO) string {
+	if slo.Calendar != nil {
+		return "the current calendar " + strings.ToLower(*slo.Calendar)
+	}
+	if window, ok := spec.FormatWindow(slo.RollingPeriod); ok {
+		return "the rolling " + window
+	}
+	return "the rolling " + slo.RollingPeriod.String()
+}
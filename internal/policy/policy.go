@@ -0,0 +1,206 @@
+// Package policy evaluates error budget policies: rules that turn the budget
+// an SLO has consumed over its compliance period into a deploy decision.
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	APIVersionV1 = "margin/v1"
+	Kind         = "ErrorBudgetPolicy"
+)
+
+// Actions ordered from least to most restrictive.
+const (
+	ActionAllow           = "allow"
+	ActionRequireApproval = "require-approval"
+	ActionFreeze          = "freeze"
+)
+
+var actionRank = map[string]int{
+	ActionAllow:           0,
+	ActionRequireApproval: 1,
+	ActionFreeze:          2,
+}
+
+type Policy struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	// OnMissingData is the action taken for an SLO whose budget cannot be
+	// read. Defaults to require-approval.
+	OnMissingData string `yaml:"onMissingData,omitempty"`
+	Rules         []Rule `yaml:"rules"`
+}
+
+// Rule applies Action when an SLO has consumed more than ConsumedAbove
+// percent of its error budget. SLOs limits the rule to named SLOs.
+type Rule struct {
+	Name          string   `yaml:"name"`
+	ConsumedAbove float64  `yaml:"consumedAbove"`
+	Action        string   `yaml:"action"`
+	Reason        string   `yaml:"reason,omitempty"`
+	SLOs          []string `yaml:"slos,omitempty"`
+}
+
+func Load(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Policy{}, fmt.Errorf("read policy: %w", err)
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return Policy{}, fmt.Errorf("policy %s: %w", path, err)
+	}
+	return p, nil
+}
+
+func Parse(data []byte) (Policy, error) {
+	var p Policy
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return Policy{}, err
+	}
+	if p.OnMissingData == "" {
+		p.OnMissingData = ActionRequireApproval
+	}
+	if err := p.Validate(); err != nil {
+		return Policy{}, err
+	}
+	return p, nil
+}
+
+func (p Policy) Validate() error {
+	var problems []string
+	if p.APIVersion != APIVersionV1 {
+		problems = append(problems, fmt.Sprintf("apiVersion must be %s", APIVersionV1))
+	}
+	if p.Kind != Kind {
+		problems = append(problems, fmt.Sprintf("kind must be %s", Kind))
+	}
+	if _, ok := actionRank[p.OnMissingData]; !ok {
+		problems = append(problems, fmt.Sprintf("onMissingData must be one of %s", strings.Join(Actions(), ", ")))
+	}
+	if len(p.Rules) == 0 {
+		problems = append(problems, "rules must not be empty")
+	}
+	names := map[string]bool{}
+	for i, rule := range p.Rules {
+		path := fmt.Sprintf("rules[%d]", i)
+		if strings.TrimSpace(rule.Name) == "" {
+			problems = append(problems, path+".name is required")
+		} else if names[rule.Name] {
+			problems = append(problems, fmt.Sprintf("%s.name %q is defined more than once", path, rule.Name))
+		}
+		names[rule.Name] = true
+		if rule.ConsumedAbove < 0 {
+			problems = append(problems, path+".consumedAbove must be >= 0")
+		}
+		if _, ok := actionRank[rule.Action]; !ok {
+			problems = append(problems, fmt.Sprintf("%s.action must be one of %s", path, strings.Join(Actions(), ", ")))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func Actions() []string {
+	return []string{ActionAllow, ActionRequireApproval, ActionFreeze}
+}
+
+// Budget is the error budget an SLO has consumed over its current
+// compliance period. Error is set when the budget could not be read.
+type Budget struct {
+	SLO             string  `json:"slo"`
+	SLOResourceName string  `json:"sloResourceName"`
+	Period          string  `json:"period"`
+	Consumed        float64 `json:"consumedPercentOfBudget"`
+	Error           string  `json:"error,omitempty"`
+}
+
+type Reason struct {
+	SLO     string `json:"slo"`
+	Rule    string `json:"rule,omitempty"`
+	Action  string `json:"action"`
+	Message string `json:"message"`
+}
+
+type Decision struct {
+	Action  string   `json:"action"`
+	Reasons []Reason `json:"reasons"`
+	Budgets []Budget `json:"budgets"`
+}
+
+// Evaluate picks the most restrictive action across all SLOs. For each SLO
+// only the matching rule with the highest threshold applies, so a policy can
+// list escalating thresholds without the lower ones repeating as reasons.
+func Evaluate(p Policy, budgets []Budget) Decision {
+	decision := Decision{Action: ActionAllow, Budgets: budgets}
+	for _, budget := range budgets {
+		if budget.Error != "" {
+			decision.add(Reason{
+				SLO:     budget.SLO,
+				Action:  p.OnMissingData,
+				Message: fmt.Sprintf("budget unavailable: %s", budget.Error),
+			})
+			continue
+		}
+		rule, ok := matchRule(p.Rules, budget)
+		if !ok {
+			continue
+		}
+		message := fmt.Sprintf("consumed %.1f%% of the error budget over %s (rule %q: above %g%%)", budget.Consumed, budget.Period, rule.Name, rule.ConsumedAbove)
+		if rule.Reason != "" {
+			message += ": " + rule.Reason
+		}
+		decision.add(Reason{SLO: budget.SLO, Rule: rule.Name, Action: rule.Action, Message: message})
+	}
+	sort.SliceStable(decision.Reasons, func(i, j int) bool {
+		return actionRank[decision.Reasons[i].Action] > actionRank[decision.Reasons[j].Action]
+	})
+	return decision
+}
+
+func (d *Decision) add(reason Reason) {
+	d.Reasons = append(d.Reasons, reason)
+	if actionRank[reason.Action] > actionRank[d.Action] {
+		d.Action = reason.Action
+	}
+}
+
+func matchRule(rules []Rule, budget Budget) (Rule, bool) {
+	var best Rule
+	found := false
+	for _, rule := range rules {
+		if !appliesTo(rule, budget.SLO) || budget.Consumed <= rule.ConsumedAbove {
+			continue
+		}
+		if !found || rule.ConsumedAbove > best.ConsumedAbove {
+			best, found = rule, true
+		}
+	}
+	return best, found
+}
+
+func appliesTo(rule Rule, slo string) bool {
+	if len(rule.SLOs) == 0 {
+		return true
+	}
+	for _, name := range rule.SLOs {
+		if name == slo {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bayneri/margin/internal/analyze"
+	"github.com/bayneri/margin/internal/planner"
+)
+
+const policyYAML = `apiVersion: margin/v1
+kind: ErrorBudgetPolicy
+rules:
+  - name: approval
+    consumedAbove: 50
+    action: require-approval
+    reason: Deploys need SRE approval.
+  - name: freeze
+    consumedAbove: 100
+    action: freeze
+    reason: Freeze non-critical deploys.
+`
+
+func TestParseDefaults(t *testing.T) {
+	p, err := Parse([]byte(policyYAML))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if p.OnMissingData != ActionRequireApproval || len(p.Rules) != 2 {
+		t.Fatalf("unexpected policy %+v", p)
+	}
+}
+
+func TestParseRejectsInvalidPolicy(t *testing.T) {
+	doc := strings.Replace(policyYAML, "action: freeze", "action: panic", 1)
+	doc = strings.Replace(doc, "name: approval", "name: freeze", 1)
+	_, err := Parse([]byte(doc))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, want := range []string{"rules[1].action must be one of", `rules[1].name "freeze" is defined more than once`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in %v", want, err)
+		}
+	}
+	if _, err := Parse([]byte(policyYAML + "extra: true\n")); err == nil {
+		t.Fatal("expected unknown field error")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	p, err := Parse([]byte(policyYAML))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	cases := []struct {
+		name    string
+		budgets []Budget
+		action  string
+		reasons int
+	}{
+		{"healthy", []Budget{{SLO: "availability", Consumed: 20}}, ActionAllow, 0},
+		{"approval", []Budget{{SLO: "availability", Consumed: 75}}, ActionRequireApproval, 1},
+		{"boundary", []Budget{{SLO: "availability", Consumed: 100}}, ActionRequireApproval, 1},
+		{"freeze wins", []Budget{{SLO: "availability", Consumed: 60}, {SLO: "latency", Consumed: 140}}, ActionFreeze, 2},
+		{"missing data", []Budget{{SLO: "availability", Error: "no data"}}, ActionRequireApproval, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := Evaluate(p, tc.budgets)
+			if d.Action != tc.action || len(d.Reasons) != tc.reasons {
+				t.Fatalf("unexpected decision %+v", d)
+			}
+		})
+	}
+
+	d := Evaluate(p, []Budget{{SLO: "latency", Consumed: 140, Period: "the rolling 30d"}})
+	if d.Reasons[0].Rule != "freeze" || !strings.Contains(d.Reasons[0].Message, "140.0% of the error budget over the rolling 30d") {
+		t.Fatalf("expected only the highest matching rule, got %+v", d.Reasons)
+	}
+}
+
+func TestEvaluateScopedRule(t *testing.T) {
+	p, err := Parse([]byte(policyYAML + "  - name: latency-freeze\n    consumedAbove: 10\n    action: freeze\n    slos: [latency]\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if d := Evaluate(p, []Budget{{SLO: "availability", Consumed: 20}}); d.Action != ActionAllow {
+		t.Fatalf("scoped rule applied to another SLO: %+v", d)
+	}
+	if d := Evaluate(p, []Budget{{SLO: "latency", Consumed: 20}}); d.Action != ActionFreeze {
+		t.Fatalf("scoped rule did not apply: %+v", d)
+	}
+}
+
+type fakeReader struct {
+	slos       []analyze.SLO
+	compliance map[string]float64
+	windows    map[string]time.Duration
+}
+
+func (f *fakeReader) ListServiceLevelObjectives(ctx context.Context, serviceName string, max int) ([]analyze.SLO, error) {
+	return f.slos, nil
+}
+
+func (f *fakeReader) FetchCompliance(ctx context.Context, project string, sloName string, start, end time.Time) (float64, error) {
+	f.windows[sloName] = end.Sub(start)
+	value, ok := f.compliance[sloName]
+	if !ok {
+		return 0, errors.New("no compliance points returned")
+	}
+	return value, nil
+}
+
+func (f *fakeReader) FetchSeries(ctx context.Context, project string, sloName string, start, end time.Time, step time.Duration) ([]analyze.Sample, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeReader) FetchBreakdown(ctx context.Context, project string, slo analyze.SLO, labels []string, start, end time.Time) ([]analyze.Group, error) {
+	return nil, nil
+}
+
+func TestBudgetsUseSLOPeriod(t *testing.T) {
+	plan := planner.Plan{
+		Project:   "demo",
+		ServiceID: "checkout-api",
+		SLOs: []planner.SLOPlan{
+			{Name: "availability", ResourceID: "checkout-api-availability"},
+			{Name: "latency", ResourceID: "checkout-api-latency"},
+			{Name: "errors", ResourceID: "checkout-api-errors"},
+		},
+	}
+	prefix := "projects/demo/services/checkout-api/serviceLevelObjectives/"
+	month := "MONTH"
+	reader := &fakeReader{
+		slos: []analyze.SLO{
+			{Name: prefix + "checkout-api-availability", Goal: 0.999, RollingPeriod: 28 * 24 * time.Hour},
+			{Name: prefix + "checkout-api-latency", Goal: 0.99, Calendar: &month},
+		},
+		compliance: map[string]float64{prefix + "checkout-api-availability": 0.9985},
+		windows:    map[string]time.Duration{},
+	}
+	now := time.Date(2025, 2, 13, 0, 0, 0, 0, time.UTC)
+	budgets, err := Budgets(context.Background(), reader, plan, now)
+	if err != nil {
+		t.Fatalf("budgets: %v", err)
+	}
+	if len(budgets) != 3 {
+		t.Fatalf("expected 3 budgets, got %+v", budgets)
+	}
+	if got := reader.windows[prefix+"checkout-api-availability"]; got != 28*24*time.Hour {
+		t.Fatalf("expected the rolling period as window, got %s", got)
+	}
+	if got := reader.windows[prefix+"checkout-api-latency"]; got != 12*24*time.Hour {
+		t.Fatalf("expected the calendar month so far as window, got %s", got)
+	}
+	if b := budgets[0]; b.Error != "" || b.Consumed < 149.9 || b.Consumed > 150.1 || b.Period != "the rolling 4w" {
+		t.Fatalf("unexpected availability budget %+v", b)
+	}
+	if b := budgets[1]; b.Error == "" || b.Period != "the current calendar month" {
+		t.Fatalf("expected missing data for latency, got %+v", b)
+	}
+	if b := budgets[2]; !strings.Contains(b.Error, "not found") {
+		t.Fatalf("expected missing SLO for errors, got %+v", b)
+	}
+}
+
+func TestBudgetsCalendarPeriod(t *testing.T) {
+	plan := planner.Plan{
+		Project:   "demo",
+		ServiceID: "checkout-api",
+		SLOs:      []planner.SLOPlan{{Name: "availability", ResourceID: "checkout-api-availability"}},
+	}
+	name := "projects/demo/services/checkout-api/serviceLevelObjectives/checkout-api-availability"
+	month := "MONTH"
+	reader := &fakeReader{
+		slos:       []analyze.SLO{{Name: name, Goal: 0.999, Calendar: &month}},
+		compliance: map[string]float64{name: 0.998},
+		windows:    map[string]time.Duration{},
+	}
+	// Three days into a 30-day month at twice the allowed bad fraction.
+	budgets, err := Budgets(context.Background(), reader, plan, time.Date(2025, 4, 4, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("budgets: %v", err)
+	}
+	if b := budgets[0]; b.Error != "" || b.Consumed != 20 {
+		t.Fatalf("expected 20%% of the month's budget consumed, got %+v", b)
+	}
+}
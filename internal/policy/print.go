@@ -0,0 +1,29 @@
+package policy
+
+import (
+	"fmt"
+	"io"
+)
+
+func Render(w io.Writer, d Decision) {
+	fmt.Fprintf(w, "Decision: %s\n", d.Action)
+	fmt.Fprintln(w, "")
+
+	fmt.Fprintln(w, "Reasons:")
+	if len(d.Reasons) == 0 {
+		fmt.Fprintln(w, "- no policy rule matched")
+	}
+	for _, reason := range d.Reasons {
+		fmt.Fprintf(w, "- %s: %s [%s]\n", reason.SLO, reason.Message, reason.Action)
+	}
+
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Budgets:")
+	for _, budget := range d.Budgets {
+		if budget.Error != "" {
+			fmt.Fprintf(w, "- %s: unavailable (%s)\n", budget.SLO, budget.Error)
+			continue
+		}
+		fmt.Fprintf(w, "- %s: %.1f%% consumed over %s\n", budget.SLO, budget.Consumed, budget.Period)
+	}
+}
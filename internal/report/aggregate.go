@@ -15,6 +15,8 @@ import (
 
 type AggregateOptions struct {
 	Timezone *time.Location
+	// Charts maps ChartKey to a chart path relative to the summary.
+	Charts map[string]string
 }
 
 type AggregateResult struct {
@@ -31,7 +33,9 @@ type ServiceAggregate struct {
 	Status  string              `json:"status"`
 	Window  analyze.Window      `json:"window"`
 	SLOs    []analyze.SLOResult `json:"slos"`
-	Errors  []string            `json:"errors"`
+	// Exclusions are the excluded intervals of the service's inputs.
+	Exclusions []analyze.Exclusion `json:"exclusions,omitempty"`
+	Errors     []string            `json:"errors"`
 }
 
 func ReadResults(paths []string) ([]analyze.Result, error) {
@@ -80,6 +84,7 @@ func Aggregate(results []analyze.Result, inputs []string) (AggregateResult, erro
 			item.Status = mergeStatus(item.Status, result.Status)
 		}
 		item.SLOs = append(item.SLOs, result.SLOs...)
+		item.Exclusions = mergeExclusions(item.Exclusions, result.Exclusions)
 		item.Errors = append(item.Errors, result.Errors...)
 		item.Status = mergeStatus(item.Status, statusFromSLOs(result.SLOs))
 		if len(result.Errors) > 0 && len(inputs) > i {
@@ -113,6 +118,26 @@ func Aggregate(results []analyze.Result, inputs []string) (AggregateResult, erro
 	}, nil
 }
 
+// mergeExclusions adds the exclusions of another input of the same service,
+// skipping ones already listed.
+func mergeExclusions(existing, more []analyze.Exclusion) []analyze.Exclusion {
+	out := existing
+	for _, exclusion := range more {
+		seen := false
+		for _, have := range out {
+			if have.Start.Equal(exclusion.Start) && have.End.Equal(exclusion.End) && have.Reason == exclusion.Reason {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			out = append(out, exclusion)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
 func mergeStatus(a, b string) string {
 	score := func(value string) int {
 		switch value {
@@ -152,7 +177,7 @@ func WriteAggregateJSON(path string, result AggregateResult) error {
 	return os.WriteFile(path, data, 0644)
 }
 
-func WriteAggregateMarkdown(path string, result AggregateResult) error {
+func WriteAggregateMarkdown(path string, result AggregateResult, opts AggregateOptions) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 		return err
 	}
@@ -170,6 +195,12 @@ func WriteAggregateMarkdown(path string, result AggregateResult) error {
 			fmt.Fprintf(&b, "| %s | %.4f | %.4f | %.4f | %.4f | %.2f%% | %s |\n",
 				slo.DisplayName, slo.Goal, slo.Compliance, slo.BadFraction, slo.AllowedBadFraction, slo.ConsumedPercentOfBudget, slo.Status)
 		}
+		writeExclusions(&b, "###", service.Exclusions, service.SLOs, time.UTC)
+		for _, slo := range service.SLOs {
+			if chartPath := opts.Charts[ChartKey(slo)]; chartPath != "" {
+				fmt.Fprintf(&b, "\n![%s burndown](%s)\n", slo.DisplayName, chartPath)
+			}
+		}
 		if len(service.Errors) > 0 {
 			fmt.Fprintf(&b, "\nErrors:\n")
 			for _, err := range service.Errors {
@@ -66,7 +66,7 @@ func TestAggregateResults(t *testing.T) {
 
 	dir := t.TempDir()
 	md := filepath.Join(dir, "summary.md")
-	if err := WriteAggregateMarkdown(md, agg); err != nil {
+	if err := WriteAggregateMarkdown(md, agg, AggregateOptions{}); err != nil {
 		t.Fatalf("write markdown: %v", err)
 	}
 	if _, err := os.Stat(md); err != nil {
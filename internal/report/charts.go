@@ -0,0 +1,142 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bayneri/margin/internal/analyze"
+	"github.com/bayneri/margin/internal/chart"
+	"github.com/bayneri/margin/internal/spec"
+)
+
+// ChartFormats are the chart file formats analyze and report can write.
+var ChartFormats = []string{"svg", "png"}
+
+// BurndownFigure charts an SLO's burndown: compliance per step against the
+// objective, burn rate against the default paging and ticket thresholds, and
+// cumulative budget consumed against the whole budget. Changes in the
+// burndown are drawn as markers on every panel and labeled on the burn rate.
+func BurndownFigure(slo analyze.SLOResult, loc *time.Location) chart.Figure {
+	var compliance, burn, cumulative []chart.Point
+	var markers, labeled []chart.Marker
+	if slo.Burndown != nil {
+		for _, point := range slo.Burndown.Points {
+			compliance = append(compliance, chart.Point{Time: point.End, Value: (1 - point.BadFraction) * 100})
+			burn = append(burn, chart.Point{Time: point.End, Value: point.BurnRate})
+			cumulative = append(cumulative, chart.Point{Time: point.End, Value: point.CumulativeConsumedPercent})
+			for _, change := range point.Changes {
+				markers = append(markers, chart.Marker{Time: change.Time, Color: chart.ColorChange})
+				labeled = append(labeled, chart.Marker{Time: change.Time, Label: change.Kind, Color: chart.ColorChange})
+			}
+		}
+	}
+	fast, slow := spec.DefaultFastBurn.BurnRate, spec.DefaultSlowBurn.BurnRate
+	full := 100.0
+	return chart.Figure{
+		Title:    slo.DisplayName + " burndown",
+		Location: loc,
+		Panels: []chart.Panel{
+			{
+				Title:   "Compliance per step",
+				Unit:    "%",
+				Points:  compliance,
+				Ceiling: &full,
+				Lines:   []chart.Line{{Label: fmt.Sprintf("objective %g%%", slo.Goal*100), Value: slo.Goal * 100, Color: chart.ColorObjective}},
+				Markers: markers,
+			},
+			{
+				Title:  "Burn rate",
+				Unit:   "x",
+				Points: burn,
+				Lines: []chart.Line{
+					{Label: fmt.Sprintf("page %gx", fast), Value: fast, Color: chart.ColorPage},
+					{Label: fmt.Sprintf("ticket %gx", slow), Value: slow, Color: chart.ColorTicket},
+				},
+				Markers: labeled,
+			},
+			{
+				Title:   "Cumulative budget consumed",
+				Unit:    "%",
+				Points:  cumulative,
+				Lines:   []chart.Line{{Label: "budget 100%", Value: 100, Color: chart.ColorBudget}},
+				Markers: markers,
+			},
+		},
+	}
+}
+
+// WriteCharts writes one chart per SLO with a burndown into dir/charts and
+// returns the chart to embed for each SLO, keyed by ChartKey and relative to
+// dir. SVG is preferred for embedding when both formats are written.
+func WriteCharts(dir, prefix string, slos []analyze.SLOResult, formats []string, loc *time.Location) (map[string]string, error) {
+	charts := map[string]string{}
+	if len(formats) == 0 {
+		return charts, nil
+	}
+	for _, slo := range slos {
+		if slo.Burndown == nil {
+			continue
+		}
+		figure := BurndownFigure(slo, loc)
+		name := chartFileName(prefix, slo)
+		for _, format := range formats {
+			var data []byte
+			switch format {
+			case "svg":
+				data = chart.SVG(figure)
+			case "png":
+				var err error
+				if data, err = chart.PNG(figure); err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fmt.Errorf("unknown chart format %q (want %s)", format, strings.Join(ChartFormats, ", "))
+			}
+			rel := filepath.ToSlash(filepath.Join("charts", name+"."+format))
+			if err := os.MkdirAll(filepath.Join(dir, "charts"), 0755); err != nil {
+				return nil, err
+			}
+			if err := os.WriteFile(filepath.Join(dir, rel), data, 0644); err != nil {
+				return nil, err
+			}
+			if _, ok := charts[ChartKey(slo)]; !ok || format == "svg" {
+				charts[ChartKey(slo)] = rel
+			}
+		}
+	}
+	return charts, nil
+}
+
+// ChartKey identifies an SLO's chart across services.
+func ChartKey(slo analyze.SLOResult) string {
+	if slo.SLOResourceName != "" {
+		return slo.SLOResourceName
+	}
+	return slo.DisplayName
+}
+
+func chartFileName(prefix string, slo analyze.SLOResult) string {
+	id := slo.SLOID
+	if id == "" {
+		id = slo.DisplayName
+	}
+	if prefix != "" {
+		id = prefix + "-" + id
+	}
+	var out []rune
+	for _, r := range strings.ToLower(id) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_':
+			out = append(out, r)
+		default:
+			out = append(out, '-')
+		}
+	}
+	if len(out) == 0 {
+		return "slo"
+	}
+	return string(out)
+}
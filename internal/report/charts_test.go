@@ -0,0 +1,51 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bayneri/margin/internal/analyze"
+)
+
+func TestWriteCharts(t *testing.T) {
+	end := time.Date(2025, 1, 1, 10, 10, 0, 0, time.UTC)
+	slos := []analyze.SLOResult{
+		{
+			SLOResourceName: "projects/demo/services/checkout/serviceLevelObjectives/availability",
+			SLOID:           "availability",
+			DisplayName:     "availability",
+			Goal:            0.999,
+			Burndown: &analyze.Burndown{
+				StepSeconds: 600,
+				Points:      []analyze.BurndownPoint{{Start: end.Add(-10 * time.Minute), End: end, BadFraction: 0.002, BurnRate: 2, CumulativeConsumedPercent: 200}},
+			},
+		},
+		{DisplayName: "latency", SLOID: "latency"},
+	}
+	dir := t.TempDir()
+	charts, err := WriteCharts(dir, "checkout", slos, []string{"png", "svg"}, time.UTC)
+	if err != nil {
+		t.Fatalf("write charts: %v", err)
+	}
+	if len(charts) != 1 || charts[slos[0].SLOResourceName] != "charts/checkout-availability.svg" {
+		t.Fatalf("unexpected charts %v", charts)
+	}
+	svg, err := os.ReadFile(filepath.Join(dir, "charts", "checkout-availability.svg"))
+	if err != nil {
+		t.Fatalf("read svg: %v", err)
+	}
+	for _, want := range []string{"objective 99.9%", "page 14.4x", "ticket 6x", "budget 100%"} {
+		if !strings.Contains(string(svg), want) {
+			t.Fatalf("chart missing %q", want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "charts", "checkout-availability.png")); err != nil {
+		t.Fatalf("png missing: %v", err)
+	}
+	if _, err := WriteCharts(dir, "", slos, []string{"gif"}, time.UTC); err == nil {
+		t.Fatal("expected unknown format error")
+	}
+}
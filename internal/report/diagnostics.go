@@ -0,0 +1,185 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/bayneri/margin/internal/spec"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	toolURI      = "https://github.com/bayneri/margin"
+)
+
+type DiagnosticsOptions struct {
+	Format      string
+	File        string
+	ToolVersion string
+}
+
+func WriteDiagnostics(w io.Writer, problems []spec.ValidationError, opts DiagnosticsOptions) error {
+	switch opts.Format {
+	case "", "text":
+		return writeDiagnosticsText(w, problems, opts)
+	case "json":
+		return writeDiagnosticsJSON(w, problems, opts)
+	case "sarif":
+		return writeDiagnosticsSARIF(w, problems, opts)
+	default:
+		return fmt.Errorf("unknown output format %q (want text, json, or sarif)", opts.Format)
+	}
+}
+
+func writeDiagnosticsText(w io.Writer, problems []spec.ValidationError, opts DiagnosticsOptions) error {
+	for _, problem := range problems {
+		location := opts.File
+		if problem.Line > 0 {
+			location = fmt.Sprintf("%s:%d:%d", opts.File, problem.Line, problem.Column)
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s: %s [%s]\n", location, problem.Severity, problem.Message, problem.Code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type diagnosticsJSON struct {
+	File     string                 `json:"file"`
+	Valid    bool                   `json:"valid"`
+	Problems []spec.ValidationError `json:"problems"`
+}
+
+func writeDiagnosticsJSON(w io.Writer, problems []spec.ValidationError, opts DiagnosticsOptions) error {
+	payload := diagnosticsJSON{
+		File:     opts.File,
+		Valid:    !spec.ValidationErrors(problems).HasErrors(),
+		Problems: problems,
+	}
+	if payload.Problems == nil {
+		payload.Problems = []spec.ValidationError{}
+	}
+	return encodeIndented(w, payload)
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           sarifRegion   `json:"region"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+func writeDiagnosticsSARIF(w io.Writer, problems []spec.ValidationError, opts DiagnosticsOptions) error {
+	seen := map[string]bool{}
+	var rules []sarifRule
+	results := []sarifResult{}
+	for _, problem := range problems {
+		if !seen[problem.Code] {
+			seen[problem.Code] = true
+			rules = append(rules, sarifRule{ID: problem.Code, ShortDescription: sarifMessage{Text: problem.Code}})
+		}
+		line := problem.Line
+		if line <= 0 {
+			// Code scanning requires a region; anchor file-level problems to the first line.
+			line = 1
+		}
+		results = append(results, sarifResult{
+			RuleID:  problem.Code,
+			Level:   sarifLevel(problem.Severity),
+			Message: sarifMessage{Text: problem.Message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifact{URI: opts.File},
+					Region:           sarifRegion{StartLine: line, StartColumn: problem.Column},
+				},
+			}},
+		})
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	if rules == nil {
+		rules = []sarifRule{}
+	}
+
+	return encodeIndented(w, sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "margin",
+				Version:        opts.ToolVersion,
+				InformationURI: toolURI,
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	})
+}
+
+func sarifLevel(severity spec.Severity) string {
+	if severity == spec.SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+func encodeIndented(w io.Writer, payload interface{}) error {
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,65 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bayneri/margin/internal/spec"
+)
+
+var sampleProblems = []spec.ValidationError{
+	{Path: "slos[0].objetive", Line: 9, Column: 5, Code: spec.CodeUnknownField, Severity: spec.SeverityError, Message: `slos[0]: unknown field "objetive"`},
+	{Path: "slos", Code: spec.CodeRequired, Severity: spec.SeverityError, Message: "at least one SLO is required"},
+}
+
+func TestWriteDiagnosticsText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteDiagnostics(&buf, sampleProblems, DiagnosticsOptions{Format: "text", File: "slo.yaml"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "slo.yaml:9:5: error: slos[0]: unknown field \"objetive\" [unknown-field]\n" +
+		"slo.yaml: error: at least one SLO is required [required]\n"
+	if buf.String() != want {
+		t.Fatalf("text mismatch\n--- got ---\n%s\n--- want ---\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteDiagnosticsSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteDiagnostics(&buf, sampleProblems, DiagnosticsOptions{Format: "sarif", File: "slo.yaml", ToolVersion: "test"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected sarif envelope: %+v", log)
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(run.Tool.Driver.Rules))
+	}
+	if len(run.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(run.Results))
+	}
+	region := run.Results[0].Locations[0].PhysicalLocation.Region
+	if region.StartLine != 9 || region.StartColumn != 5 {
+		t.Fatalf("unexpected region %+v", region)
+	}
+	if run.Results[1].Locations[0].PhysicalLocation.Region.StartLine != 1 {
+		t.Fatalf("expected file-level problem anchored to line 1")
+	}
+}
+
+func TestWriteDiagnosticsJSONValid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteDiagnostics(&buf, nil, DiagnosticsOptions{Format: "json", File: "slo.yaml"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"valid": true`) || !strings.Contains(buf.String(), `"problems": []`) {
+		t.Fatalf("unexpected json: %s", buf.String())
+	}
+}
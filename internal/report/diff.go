@@ -0,0 +1,238 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bayneri/margin/internal/analyze"
+)
+
+const (
+	DiffSchemaVersion = "1.0"
+	// DefaultRegressionThreshold is how many points of budget consumed an
+	// SLO may gain before it counts as a regression.
+	DefaultRegressionThreshold = 10.0
+
+	DiffStatusRegressed = "regressed"
+)
+
+// DiffInput is one side of a diff: an analyze summary, or a margin report
+// summary of several services.
+type DiffInput struct {
+	Path     string              `json:"path"`
+	Services []string            `json:"services"`
+	Window   analyze.Window      `json:"window"`
+	SLOs     []analyze.SLOResult `json:"-"`
+}
+
+type DiffResult struct {
+	SchemaVersion    string    `json:"schemaVersion"`
+	Baseline         DiffInput `json:"baseline"`
+	Current          DiffInput `json:"current"`
+	ThresholdPercent float64   `json:"regressionThresholdPercent"`
+	Status           string    `json:"status"`
+	SLOs             []SLODiff `json:"slos"`
+}
+
+// SLODiff lines up one SLO across both inputs by resource name. Baseline or
+// Current is nil when the SLO is only in the other input, and the deltas
+// are nil unless both sides were evaluated.
+type SLODiff struct {
+	SLOResourceName  string       `json:"sloResourceName"`
+	DisplayName      string       `json:"displayName"`
+	Baseline         *SLOSnapshot `json:"baseline"`
+	Current          *SLOSnapshot `json:"current"`
+	ComplianceDelta  *float64     `json:"complianceDelta"`
+	BadFractionDelta *float64     `json:"badFractionDelta"`
+	ConsumedDelta    *float64     `json:"consumedPercentOfBudgetDelta"`
+	Regression       bool         `json:"regression"`
+	Reasons          []string     `json:"reasons,omitempty"`
+}
+
+type SLOSnapshot struct {
+	Status                  string  `json:"status"`
+	Compliance              float64 `json:"compliance"`
+	BadFraction             float64 `json:"badFraction"`
+	ConsumedPercentOfBudget float64 `json:"consumedPercentOfBudget"`
+	Error                   string  `json:"error,omitempty"`
+}
+
+// ReadDiffInput reads an analyze or margin report summary.json.
+func ReadDiffInput(path string) (DiffInput, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return DiffInput{}, fmt.Errorf("read %s: %w", path, err)
+	}
+	var summary struct {
+		analyze.Result
+		Services []ServiceAggregate `json:"services"`
+	}
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return DiffInput{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if summary.SchemaVersion == "" {
+		return DiffInput{}, fmt.Errorf("missing schemaVersion in %s", path)
+	}
+	input := DiffInput{Path: path}
+	if len(summary.Services) == 0 {
+		input.Services = []string{fmt.Sprintf("%s/%s", summary.Project, summary.Service)}
+		input.Window = summary.Window
+		input.SLOs = summary.SLOs
+		return input, nil
+	}
+	input.Window = summary.Services[0].Window
+	for _, service := range summary.Services {
+		input.Services = append(input.Services, fmt.Sprintf("%s/%s", service.Project, service.Service))
+		input.SLOs = append(input.SLOs, service.SLOs...)
+	}
+	return input, nil
+}
+
+// Diff compares current against baseline. An SLO regresses when its budget
+// consumed grows by more than threshold points or it newly breaches. Budget
+// consumed is relative to the window's own length, so windows of different
+// lengths compare.
+func Diff(baseline, current DiffInput, threshold float64) DiffResult {
+	result := DiffResult{
+		SchemaVersion:    DiffSchemaVersion,
+		Baseline:         baseline,
+		Current:          current,
+		ThresholdPercent: threshold,
+		Status:           analyze.StatusOK,
+	}
+	index := map[string]int{}
+	add := func(slo analyze.SLOResult) *SLODiff {
+		if i, ok := index[slo.SLOResourceName]; ok {
+			return &result.SLOs[i]
+		}
+		index[slo.SLOResourceName] = len(result.SLOs)
+		result.SLOs = append(result.SLOs, SLODiff{SLOResourceName: slo.SLOResourceName, DisplayName: slo.DisplayName})
+		return &result.SLOs[len(result.SLOs)-1]
+	}
+	for _, slo := range baseline.SLOs {
+		add(slo).Baseline = snapshot(slo)
+	}
+	for _, slo := range current.SLOs {
+		add(slo).Current = snapshot(slo)
+	}
+
+	for i := range result.SLOs {
+		item := &result.SLOs[i]
+		if !item.Baseline.comparable() || !item.Current.comparable() {
+			continue
+		}
+		item.ComplianceDelta = delta(item.Current.Compliance, item.Baseline.Compliance)
+		item.BadFractionDelta = delta(item.Current.BadFraction, item.Baseline.BadFraction)
+		item.ConsumedDelta = delta(item.Current.ConsumedPercentOfBudget, item.Baseline.ConsumedPercentOfBudget)
+		if *item.ConsumedDelta > threshold {
+			item.Reasons = append(item.Reasons, fmt.Sprintf("budget consumed up %.2f points", *item.ConsumedDelta))
+		}
+		if item.Current.Status == analyze.StatusBreach && item.Baseline.Status != analyze.StatusBreach {
+			item.Reasons = append(item.Reasons, "newly in breach")
+		}
+		if len(item.Reasons) > 0 {
+			item.Regression = true
+			result.Status = DiffStatusRegressed
+		}
+	}
+	return result
+}
+
+// Regressions counts the SLOs that regressed.
+func (r DiffResult) Regressions() int {
+	count := 0
+	for _, slo := range r.SLOs {
+		if slo.Regression {
+			count++
+		}
+	}
+	return count
+}
+
+func snapshot(slo analyze.SLOResult) *SLOSnapshot {
+	return &SLOSnapshot{
+		Status:                  slo.Status,
+		Compliance:              slo.Compliance,
+		BadFraction:             slo.BadFraction,
+		ConsumedPercentOfBudget: slo.ConsumedPercentOfBudget,
+		Error:                   slo.Error,
+	}
+}
+
+func delta(current, baseline float64) *float64 {
+	value := math.Round((current-baseline)*1e6) / 1e6
+	return &value
+}
+
+func WriteDiffJSON(path string, result DiffResult) error {
+	return WriteJSON(path, result)
+}
+
+// WriteDiffMarkdown writes the two windows and a row per SLO with both
+// values and the change.
+func WriteDiffMarkdown(path string, result DiffResult, loc *time.Location) error {
+	if loc == nil {
+		loc = time.UTC
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Analysis diff\n\n")
+	writeDiffInput(&b, "Baseline", result.Baseline, loc)
+	writeDiffInput(&b, "Current", result.Current, loc)
+	fmt.Fprintf(&b, "- Regression: budget consumed up more than %v points, or newly in breach\n", result.ThresholdPercent)
+	status := result.Status
+	if n := result.Regressions(); n > 0 {
+		status = fmt.Sprintf("%s (%d SLO(s))", status, n)
+	}
+	fmt.Fprintf(&b, "- Status: %s\n\n", status)
+
+	fmt.Fprintf(&b, "| SLO | Compliance | Change | Bad fraction | Change | Budget consumed | Change | Status |\n")
+	fmt.Fprintf(&b, "| --- | --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, slo := range result.SLOs {
+		switch {
+		case slo.Baseline == nil:
+			c, bad, used := snapshotCells(slo.Current)
+			fmt.Fprintf(&b, "| %s | %s | - | %s | - | %s | - | only in current |\n", slo.DisplayName, c, bad, used)
+		case slo.Current == nil:
+			c, bad, used := snapshotCells(slo.Baseline)
+			fmt.Fprintf(&b, "| %s | %s | - | %s | - | %s | - | only in baseline |\n", slo.DisplayName, c, bad, used)
+		case slo.ConsumedDelta == nil:
+			c0, bad0, used0 := snapshotCells(slo.Baseline)
+			c1, bad1, used1 := snapshotCells(slo.Current)
+			fmt.Fprintf(&b, "| %s | %s -> %s | - | %s -> %s | - | %s -> %s | - | not comparable |\n", slo.DisplayName, c0, c1, bad0, bad1, used0, used1)
+		default:
+			verdict := "ok"
+			if slo.Regression {
+				verdict = "**regression**: " + strings.Join(slo.Reasons, "; ")
+			}
+			fmt.Fprintf(&b, "| %s | %.4f -> %.4f | %+.4f | %.4f -> %.4f | %+.4f | %.2f%% -> %.2f%% | %+.2f | %s |\n", slo.DisplayName,
+				slo.Baseline.Compliance, slo.Current.Compliance, *slo.ComplianceDelta,
+				slo.Baseline.BadFraction, slo.Current.BadFraction, *slo.BadFractionDelta,
+				slo.Baseline.ConsumedPercentOfBudget, slo.Current.ConsumedPercentOfBudget, *slo.ConsumedDelta, verdict)
+		}
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+func writeDiffInput(b *strings.Builder, label string, input DiffInput, loc *time.Location) {
+	fmt.Fprintf(b, "- %s: %s, %s to %s (%s), %s\n", label, strings.Join(input.Services, ", "),
+		input.Window.Start.In(loc).Format(time.RFC3339), input.Window.End.In(loc).Format(time.RFC3339),
+		formatDuration(input.Window.DurationSeconds), input.Path)
+}
+
+// comparable reports whether the side was evaluated. A breached SLO carries
+// an Error too, so only the status tells them apart.
+func (s *SLOSnapshot) comparable() bool {
+	return s != nil && s.Status != analyze.StatusError && s.Status != analyze.StatusPartial
+}
+
+// snapshotCells formats one side, or its status when it was not evaluated.
+func snapshotCells(s *SLOSnapshot) (compliance, badFraction, consumed string) {
+	if !s.comparable() {
+		return s.Status, s.Status, s.Status
+	}
+	return fmt.Sprintf("%.4f", s.Compliance), fmt.Sprintf("%.4f", s.BadFraction), fmt.Sprintf("%.2f%%", s.ConsumedPercentOfBudget)
+}
@@ -0,0 +1,110 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bayneri/margin/internal/analyze"
+)
+
+func TestDiff(t *testing.T) {
+	start := time.Date(2025, 1, 8, 10, 0, 0, 0, time.UTC)
+	slo := func(name string, compliance, consumed float64, status string) analyze.SLOResult {
+		out := analyze.SLOResult{
+			SLOResourceName:         "projects/demo/services/checkout/serviceLevelObjectives/" + name,
+			DisplayName:             name,
+			Compliance:              compliance,
+			BadFraction:             1 - compliance,
+			ConsumedPercentOfBudget: consumed,
+			Status:                  status,
+		}
+		if status == analyze.StatusBreach {
+			// As evaluate reports a breach.
+			out.Error = "error budget exceeded in window"
+		}
+		return out
+	}
+	baseline := DiffInput{
+		Path:     "last-week/summary.json",
+		Services: []string{"demo/checkout"},
+		Window:   analyze.Window{Start: start.AddDate(0, 0, -7), End: start.AddDate(0, 0, -7).Add(time.Hour), DurationSeconds: 3600},
+		SLOs: []analyze.SLOResult{
+			slo("availability", 0.9995, 50, analyze.StatusOK),
+			slo("latency", 0.995, 50, analyze.StatusOK),
+			slo("retired", 0.999, 100, analyze.StatusOK),
+		},
+	}
+	current := DiffInput{
+		Path:     "incident/summary.json",
+		Services: []string{"demo/checkout"},
+		Window:   analyze.Window{Start: start, End: start.Add(time.Hour), DurationSeconds: 3600},
+		SLOs: []analyze.SLOResult{
+			slo("availability", 0.992, 800, analyze.StatusBreach),
+			slo("latency", 0.9945, 55, analyze.StatusOK),
+			{SLOResourceName: "projects/demo/services/checkout/serviceLevelObjectives/freshness", DisplayName: "freshness", Status: analyze.StatusError, Error: "permission denied"},
+		},
+	}
+
+	result := Diff(baseline, current, DefaultRegressionThreshold)
+	if result.Status != DiffStatusRegressed || result.Regressions() != 1 || len(result.SLOs) != 4 {
+		t.Fatalf("unexpected diff %+v", result)
+	}
+	availability := result.SLOs[0]
+	if !availability.Regression || *availability.ConsumedDelta != 750 || *availability.ComplianceDelta != -0.0075 {
+		t.Fatalf("unexpected availability diff %+v", availability)
+	}
+	if len(availability.Reasons) != 2 || availability.Reasons[1] != "newly in breach" {
+		t.Fatalf("unexpected reasons %v", availability.Reasons)
+	}
+	if latency := result.SLOs[1]; latency.Regression || *latency.ConsumedDelta != 5 {
+		t.Fatalf("expected 5 points to stay under the threshold, got %+v", latency)
+	}
+	if retired := result.SLOs[2]; retired.Current != nil || retired.ConsumedDelta != nil {
+		t.Fatalf("expected retired to be only in the baseline, got %+v", retired)
+	}
+
+	path := filepath.Join(t.TempDir(), "diff.md")
+	if err := WriteDiffMarkdown(path, result, time.UTC); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	for _, want := range []string{
+		"- Baseline: demo/checkout, 2025-01-01T10:00:00Z to 2025-01-01T11:00:00Z (1h0m0s), last-week/summary.json",
+		"- Status: regressed (1 SLO(s))",
+		"| availability | 0.9995 -> 0.9920 | -0.0075 | 0.0005 -> 0.0080 | +0.0075 | 50.00% -> 800.00% | +750.00 | **regression**: budget consumed up 750.00 points; newly in breach |",
+		"| latency | 0.9950 -> 0.9945 | -0.0005 | 0.0050 -> 0.0055 | +0.0005 | 50.00% -> 55.00% | +5.00 | ok |",
+		"| retired | 0.9990 | - | 0.0010 | - | 100.00% | - | only in baseline |",
+		"| freshness | error | - | error | - | error | - | only in current |",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("missing %q in:\n%s", want, data)
+		}
+	}
+}
+
+func TestReadDiffInputAggregate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.json")
+	agg := AggregateResult{
+		SchemaVersion: "1.0",
+		Services: []ServiceAggregate{
+			{Project: "demo", Service: "checkout", SLOs: []analyze.SLOResult{{SLOResourceName: "a"}}},
+			{Project: "demo", Service: "payments", SLOs: []analyze.SLOResult{{SLOResourceName: "b"}}},
+		},
+	}
+	if err := WriteJSON(path, agg); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	input, err := ReadDiffInput(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if strings.Join(input.Services, ",") != "demo/checkout,demo/payments" || len(input.SLOs) != 2 {
+		t.Fatalf("unexpected input %+v", input)
+	}
+}
@@ -0,0 +1,70 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bayneri/margin/internal/analyze"
+)
+
+func WriteForecastJSON(path string, result analyze.ForecastResult) error {
+	return WriteJSON(path, result)
+}
+
+// WriteForecastMarkdown writes one table per SLO with each model's
+// projection and its confidence band.
+func WriteForecastMarkdown(path string, result analyze.ForecastResult, loc *time.Location) error {
+	if loc == nil {
+		loc = time.UTC
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Error budget forecast\n\n")
+	fmt.Fprintf(&b, "- Service: %s\n", result.Service)
+	fmt.Fprintf(&b, "- Project: %s\n", result.Project)
+	fmt.Fprintf(&b, "- Generated: %s\n", result.GeneratedAt.In(loc).Format(time.RFC3339))
+	fmt.Fprintf(&b, "- History: %s in %s steps\n", formatDuration(result.LookbackSeconds), formatDuration(result.StepSeconds))
+	fmt.Fprintf(&b, "- Status: %s\n\n", result.Status)
+
+	fmt.Fprintf(&b, "| SLO | Goal | Budget remaining | Forecast until | Status |\n")
+	fmt.Fprintf(&b, "| --- | --- | --- | --- | --- |\n")
+	for _, slo := range result.SLOs {
+		if slo.Error != "" {
+			fmt.Fprintf(&b, "| %s | %.4f | n/a | n/a | %s |\n", slo.DisplayName, slo.Goal, slo.Status)
+			continue
+		}
+		fmt.Fprintf(&b, "| %s | %.4f | %.2f%% | %s | %s |\n",
+			slo.DisplayName, slo.Goal, slo.RemainingPercentOfBudget, slo.HorizonEnd.In(loc).Format(time.RFC3339), slo.Status)
+	}
+
+	for _, slo := range result.SLOs {
+		if len(slo.Models) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", slo.DisplayName)
+		fmt.Fprintf(&b, "| Model | Burn rate (90%% band) | Exhaustion | Earliest | Latest | Left at horizon |\n")
+		fmt.Fprintf(&b, "| --- | --- | --- | --- | --- | --- |\n")
+		for _, model := range slo.Models {
+			fmt.Fprintf(&b, "| %s | %.2fx (%.2fx to %.2fx) | %s | %s | %s | %.2f%% |\n",
+				model.Model, model.BurnRate, model.BurnRateLow, model.BurnRateHigh,
+				forecastTime(model.Exhaustion, loc), forecastTime(model.ExhaustionEarliest, loc), forecastTime(model.ExhaustionLatest, loc),
+				model.RemainingAtHorizonPercent)
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		fmt.Fprintf(&b, "\n## Notes & assumptions\n")
+		for _, err := range result.Errors {
+			fmt.Fprintf(&b, "- %s\n", err)
+		}
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+func forecastTime(t *time.Time, loc *time.Location) string {
+	if t == nil {
+		return "after horizon"
+	}
+	return t.In(loc).Format(time.RFC3339)
+}
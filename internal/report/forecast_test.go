@@ -0,0 +1,63 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bayneri/margin/internal/analyze"
+)
+
+func TestWriteForecastMarkdown(t *testing.T) {
+	now := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	exhaustion := now.Add(90 * time.Hour)
+	earliest := now.Add(60 * time.Hour)
+	result := analyze.ForecastResult{
+		Project:         "demo",
+		Service:         "checkout",
+		GeneratedAt:     now,
+		LookbackSeconds: 7 * 24 * 3600,
+		StepSeconds:     3600,
+		Status:          analyze.ForecastOffTrack,
+		SLOs: []analyze.SLOForecast{
+			{
+				DisplayName:              "availability",
+				Goal:                     0.999,
+				HorizonEnd:               now.Add(7 * 24 * time.Hour),
+				RemainingPercentOfBudget: 50,
+				Status:                   analyze.ForecastOffTrack,
+				Models: []analyze.ModelForecast{{
+					Model:              analyze.ModelEWMA,
+					BurnRate:           4,
+					BurnRateLow:        2,
+					BurnRateHigh:       6,
+					Exhaustion:         &exhaustion,
+					ExhaustionEarliest: &earliest,
+				}},
+			},
+			{DisplayName: "latency", Goal: 0.99, Status: analyze.StatusError, Error: "no data"},
+		},
+		Errors: []string{"latency: no data"},
+	}
+
+	path := filepath.Join(t.TempDir(), "forecast.md")
+	if err := WriteForecastMarkdown(path, result, time.UTC); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	for _, want := range []string{
+		"| availability | 0.9990 | 50.00% | 2025-02-07T12:00:00Z | off-track |",
+		"| latency | 0.9900 | n/a | n/a | error |",
+		"| ewma | 4.00x (2.00x to 6.00x) | 2025-02-04T06:00:00Z | 2025-02-03T00:00:00Z | after horizon | 0.00% |",
+		"- latency: no data",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("missing %q in:\n%s", want, data)
+		}
+	}
+}
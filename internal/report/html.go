@@ -0,0 +1,433 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bayneri/margin/internal/analyze"
+	"github.com/bayneri/margin/internal/chart"
+)
+
+// ConsoleServiceURL links to the Cloud Console page that lists a service's
+// SLOs and their burn-rate charts.
+func ConsoleServiceURL(project, serviceID string) string {
+	return fmt.Sprintf("https://console.cloud.google.com/monitoring/services/%s?project=%s", serviceID, project)
+}
+
+// ConsoleSLOURL links to the Cloud Console page of one SLO, given its
+// resource name. It returns "" when the name is not an SLO resource name.
+func ConsoleSLOURL(sloResourceName string) string {
+	parts := strings.Split(sloResourceName, "/")
+	if len(parts) != 6 || parts[0] != "projects" || parts[2] != "services" || parts[4] != "serviceLevelObjectives" {
+		return ""
+	}
+	return fmt.Sprintf("https://console.cloud.google.com/monitoring/services/%s/serviceLevelObjectives/%s?project=%s", parts[3], parts[5], parts[1])
+}
+
+// WriteHTMLSummary writes an analyze result as a single self-contained HTML
+// file: styles, the table sorter, and charts are all inline.
+func WriteHTMLSummary(path string, result analyze.Result, opts Options) error {
+	if opts.Timezone == nil {
+		opts.Timezone = time.UTC
+	}
+	page := htmlPage{
+		Title: "Incident window analysis",
+		Sections: []htmlSection{
+			newHTMLSection(result.Project, result.Service, result.Status, result.Window, result.SLOs, result.Errors, opts.TopIntervals, opts.Explain, opts.Timezone),
+		},
+		Formula: budgetFormulaText,
+		Explain: opts.Explain,
+	}
+	return writeHTML(path, page)
+}
+
+// WriteAggregateHTML writes a margin report aggregate as HTML with one
+// section per service.
+func WriteAggregateHTML(path string, result AggregateResult, opts AggregateOptions) error {
+	if opts.Timezone == nil {
+		opts.Timezone = time.UTC
+	}
+	page := htmlPage{
+		Title:    "margin report",
+		Inputs:   result.Inputs,
+		Status:   result.Status,
+		Warnings: result.Errors,
+	}
+	for _, service := range result.Services {
+		page.Sections = append(page.Sections, newHTMLSection(service.Project, service.Service, service.Status, service.Window, service.SLOs, service.Errors, 5, false, opts.Timezone))
+	}
+	return writeHTML(path, page)
+}
+
+const budgetFormulaText = "allowedBad = 1 - goal; bad = 1 - compliance; consumedPercent = (bad / allowedBad) * 100"
+
+type htmlPage struct {
+	Title    string
+	Inputs   []string
+	Status   string
+	Warnings []string
+	Sections []htmlSection
+	Formula  string
+	Explain  bool
+}
+
+type htmlSection struct {
+	Project    string
+	Service    string
+	Status     string
+	ConsoleURL string
+	Start, End string
+	Duration   string
+	SLOs       []htmlSLO
+	Periods    []htmlPeriod
+	Notes      []string
+}
+
+type htmlPeriod struct {
+	DisplayName string
+	Start, End  string
+	Exhaustion  string
+	analyze.Period
+}
+
+type htmlSLO struct {
+	analyze.SLOResult
+	ConsoleURL    string
+	Chart         template.HTML
+	PeakBurn      string
+	Intervals     []htmlInterval
+	CustomFormula bool
+	FirstBurn     string
+	Alerts        []htmlAlert
+	Contributors  []htmlContributor
+}
+
+type htmlContributor struct {
+	analyze.Contributor
+	Values []string
+}
+
+type htmlAlert struct {
+	analyze.AlertDetection
+	Windows      string
+	Incidents    string
+	Expected     string
+	TimeToDetect string
+}
+
+type htmlInterval struct {
+	Start, End string
+	analyze.BurndownPoint
+}
+
+func newHTMLSection(project, service, status string, window analyze.Window, slos []analyze.SLOResult, notes []string, top int, explain bool, loc *time.Location) htmlSection {
+	section := htmlSection{
+		Project:    project,
+		Service:    service,
+		Status:     status,
+		ConsoleURL: ConsoleServiceURL(project, service),
+		Start:      window.Start.In(loc).Format(time.RFC3339),
+		End:        window.End.In(loc).Format(time.RFC3339),
+		Duration:   formatDuration(window.DurationSeconds),
+		Notes:      append(append([]string{}, notes...), sloNotes(slos)...),
+	}
+	if section.Status == "" {
+		section.Status = statusFromSLOs(slos)
+	}
+	for _, slo := range slos {
+		item := htmlSLO{SLOResult: slo, ConsoleURL: ConsoleSLOURL(slo.SLOResourceName)}
+		if !explain {
+			item.Explain = nil
+		}
+		item.CustomFormula = customFormula(item.SLOResult)
+		if slo.Burndown != nil {
+			// chart.SVG escapes all text it draws, so inlining it is safe.
+			item.Chart = template.HTML(chart.SVG(BurndownFigure(slo, loc)))
+			if slo.Burndown.PeakAt != nil {
+				item.PeakBurn = fmt.Sprintf("%.2fx at %s", slo.Burndown.PeakBurnRate, slo.Burndown.PeakAt.In(loc).Format(time.RFC3339))
+			}
+			for _, point := range analyze.WorstIntervals(slo.Burndown, top) {
+				item.Intervals = append(item.Intervals, htmlInterval{
+					Start:         point.Start.In(loc).Format(time.RFC3339),
+					End:           point.End.In(loc).Format(time.RFC3339),
+					BurndownPoint: point,
+				})
+			}
+		}
+		if slo.Breakdown != nil {
+			for _, contributor := range slo.Breakdown.Contributors {
+				item.Contributors = append(item.Contributors, htmlContributor{Contributor: contributor, Values: labelValues(contributor, slo.Breakdown.Labels)})
+			}
+		}
+		if slo.Detection != nil {
+			item.FirstBurn = formatOptionalTime(slo.Detection.FirstBurnAt, loc)
+			for _, alert := range slo.Detection.Alerts {
+				ttd := "n/a"
+				if alert.TimeToDetectSeconds != nil {
+					ttd = formatDuration(*alert.TimeToDetectSeconds)
+				}
+				item.Alerts = append(item.Alerts, htmlAlert{
+					AlertDetection: alert,
+					Windows:        strings.Join(alert.Windows, ", "),
+					Incidents:      incidentsLabel(alert.Incidents, loc),
+					Expected:       formatOptionalTime(alert.ExpectedAt, loc),
+					TimeToDetect:   ttd,
+				})
+			}
+		}
+		section.SLOs = append(section.SLOs, item)
+		if slo.Period != nil {
+			section.Periods = append(section.Periods, htmlPeriod{
+				DisplayName: slo.DisplayName,
+				Start:       slo.Period.Start.In(loc).Format(time.RFC3339),
+				End:         slo.Period.End.In(loc).Format(time.RFC3339),
+				Exhaustion:  exhaustionLabel(slo.Period, loc),
+				Period:      *slo.Period,
+			})
+		}
+	}
+	return section
+}
+
+func writeHTML(path string, page htmlPage) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	var b strings.Builder
+	if err := htmlTemplate.Execute(&b, page); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"f4":       func(v float64) string { return fmt.Sprintf("%.4f", v) },
+	"f2":       func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"lookback": lookbackLabel,
+	"seconds":  formatDuration,
+	"hasWindows": func(slos []htmlSLO) bool {
+		for _, slo := range slos {
+			if slo.Windows != nil {
+				return true
+			}
+		}
+		return false
+	},
+	"hasData": func(slo htmlSLO) bool { return slo.Status == analyze.StatusOK || slo.Status == analyze.StatusBreach },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; color: #202124; margin: 2rem auto; max-width: 60rem; padding: 0 1rem; }
+h1, h2, h3 { font-weight: 600; }
+table { border-collapse: collapse; width: 100%; margin: 1rem 0; font-size: 0.9rem; }
+th, td { border-bottom: 1px solid #e8eaed; padding: 0.4rem 0.6rem; text-align: left; }
+th { background: #f8f9fa; }
+table.sortable th { cursor: pointer; user-select: none; }
+table.sortable th::after { content: " \2195"; color: #9aa0a6; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+.badge { display: inline-block; border-radius: 0.75rem; padding: 0.1rem 0.6rem; font-size: 0.8rem; font-weight: 600; color: #fff; background: #5f6368; }
+.badge-ok { background: #188038; }
+.badge-breach { background: #d93025; }
+.badge-partial, .badge-error { background: #e37400; }
+.meta { list-style: none; padding: 0; }
+.meta li { margin: 0.2rem 0; }
+.chart svg { max-width: 100%; height: auto; }
+.notes { background: #fef7e0; padding: 0.5rem 1.5rem; border-radius: 0.25rem; }
+code { background: #f1f3f4; padding: 0.1rem 0.3rem; border-radius: 0.2rem; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- if .Status}}
+<p>Status: <span class="badge badge-{{.Status}}">{{.Status}}</span> &middot; Inputs: {{len .Inputs}}</p>
+{{- end}}
+{{- range .Sections}}
+<section>
+<h2>{{.Service}} <small>({{.Project}})</small></h2>
+<ul class="meta">
+<li>Status: <span class="badge badge-{{.Status}}">{{.Status}}</span></li>
+<li>Window: {{.Start}} to {{.End}} ({{.Duration}})</li>
+<li><a href="{{.ConsoleURL}}">Open in Cloud Console</a></li>
+</ul>
+<table class="sortable">
+<thead><tr><th>SLO</th><th>Goal</th><th>Compliance</th><th>Bad fraction</th><th>Allowed bad</th><th>Budget consumed</th><th>Status</th></tr></thead>
+<tbody>
+{{- range .SLOs}}
+<tr>
+<td data-sort="{{.DisplayName}}">{{if .ConsoleURL}}<a href="{{.ConsoleURL}}">{{.DisplayName}}</a>{{else}}{{.DisplayName}}{{end}}</td>
+<td class="num" data-sort="{{.Goal}}">{{f4 .Goal}}</td>
+{{- if hasData .}}
+<td class="num" data-sort="{{.Compliance}}">{{f4 .Compliance}}</td>
+<td class="num" data-sort="{{.BadFraction}}">{{f4 .BadFraction}}</td>
+<td class="num" data-sort="{{.AllowedBadFraction}}">{{f4 .AllowedBadFraction}}</td>
+<td class="num" data-sort="{{.ConsumedPercentOfBudget}}">{{f2 .ConsumedPercentOfBudget}}%</td>
+{{- else}}
+<td class="num" data-sort="-1">n/a</td><td class="num" data-sort="-1">n/a</td><td class="num" data-sort="-1">n/a</td><td class="num" data-sort="-1">n/a</td>
+{{- end}}
+<td data-sort="{{.Status}}"><span class="badge badge-{{.Status}}">{{.Status}}</span>{{if .Error}} {{.Error}}{{end}}</td>
+</tr>
+{{- end}}
+</tbody>
+</table>
+{{- if hasWindows .SLOs}}
+<h3>Windows-based SLIs</h3>
+<table class="sortable">
+<thead><tr><th>SLO</th><th>Window</th><th>Windows</th><th>Good</th><th>Bad</th><th>Allowed bad</th></tr></thead>
+<tbody>
+{{- range .SLOs}}
+{{- if .Windows}}
+<tr><td data-sort="{{.DisplayName}}">{{.DisplayName}}</td><td data-sort="{{.Windows.WindowSeconds}}">{{seconds .Windows.WindowSeconds}}</td><td class="num" data-sort="{{.Windows.Total}}">{{.Windows.Total}}</td><td class="num" data-sort="{{.Windows.Good}}">{{.Windows.Good}}</td><td class="num" data-sort="{{.Windows.Bad}}">{{.Windows.Bad}}</td><td class="num" data-sort="{{.Windows.AllowedBad}}">{{f2 .Windows.AllowedBad}}</td></tr>
+{{- end}}
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- if .Periods}}
+<h3>Compliance period budget</h3>
+<table class="sortable">
+<thead><tr><th>SLO</th><th>Period</th><th>Compliance</th><th>Budget consumed</th><th>Budget remaining</th><th>Consumed in window</th><th>Burn rate (last {{lookback}})</th><th>Projected exhaustion</th></tr></thead>
+<tbody>
+{{- range .Periods}}
+<tr>
+<td data-sort="{{.DisplayName}}">{{.DisplayName}}</td>
+<td data-sort="{{.Start}}">{{.Start}} to {{.End}}</td>
+<td class="num" data-sort="{{.Compliance}}">{{f4 .Compliance}}</td>
+<td class="num" data-sort="{{.ConsumedPercentOfBudget}}">{{f2 .ConsumedPercentOfBudget}}%</td>
+<td class="num" data-sort="{{.RemainingPercentOfBudget}}">{{f2 .RemainingPercentOfBudget}}%</td>
+<td class="num" data-sort="{{.WindowConsumedPercentOfBudget}}">{{f2 .WindowConsumedPercentOfBudget}}%</td>
+<td class="num" data-sort="{{.CurrentBurnRate}}">{{f2 .CurrentBurnRate}}x</td>
+<td data-sort="{{.Exhaustion}}">{{.Exhaustion}}</td>
+</tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- range .SLOs}}
+{{- if .Chart}}
+<h3>{{.DisplayName}}</h3>
+{{- if .PeakBurn}}
+<p>Peak burn rate: {{.PeakBurn}}</p>
+{{- end}}
+<div class="chart">
+{{.Chart}}</div>
+{{- if .Intervals}}
+<table class="sortable">
+<thead><tr><th>Interval</th><th>Bad fraction</th><th>Burn rate</th><th>Budget consumed so far</th></tr></thead>
+<tbody>
+{{- range .Intervals}}
+<tr><td data-sort="{{.Start}}">{{.Start}} to {{.End}}</td><td class="num" data-sort="{{.BadFraction}}">{{f4 .BadFraction}}</td><td class="num" data-sort="{{.BurnRate}}">{{f2 .BurnRate}}x</td><td class="num" data-sort="{{.CumulativeConsumedPercent}}">{{f2 .CumulativeConsumedPercent}}%</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- end}}
+{{- if .Breakdown}}
+<h3>{{.DisplayName}} breakdown</h3>
+{{- if .Contributors}}
+<table class="sortable">
+<thead><tr>{{range .Breakdown.Labels}}<th>{{.}}</th>{{end}}<th>Bad events</th><th>Share of bad</th><th>Bad fraction</th><th>Budget consumed</th></tr></thead>
+<tbody>
+{{- range .Contributors}}
+<tr>{{range .Values}}<td data-sort="{{.}}">{{.}}</td>{{end}}<td class="num" data-sort="{{.BadEvents}}">{{printf "%.0f" .BadEvents}}</td><td class="num" data-sort="{{.ShareOfBadPercent}}">{{f2 .ShareOfBadPercent}}%</td><td class="num" data-sort="{{.BadFraction}}">{{f4 .BadFraction}}</td><td class="num" data-sort="{{.ConsumedPercentOfBudget}}">{{f2 .ConsumedPercentOfBudget}}%</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- if .Breakdown.OmittedGroups}}
+<p>{{.Breakdown.OmittedGroups}} more group(s) with bad events not shown.</p>
+{{- end}}
+{{- else}}
+<p>No bad events in the window.</p>
+{{- end}}
+{{- end}}
+{{- if .Detection}}
+<h3>{{.DisplayName}} detection</h3>
+{{- if .Detection.Note}}
+<p>{{.Detection.Note}}</p>
+{{- else}}
+<p>First budget burn: {{.FirstBurn}}</p>
+<table class="sortable">
+<thead><tr><th>Alert</th><th>Threshold</th><th>Windows</th><th>Incidents</th><th>Expected to fire</th><th>Time to detect</th><th>Missed</th></tr></thead>
+<tbody>
+{{- range .Alerts}}
+<tr><td data-sort="{{.DisplayName}}">{{.DisplayName}}</td><td class="num" data-sort="{{.BurnRate}}">{{f2 .BurnRate}}x</td><td>{{.Windows}}</td><td>{{.Incidents}}</td><td data-sort="{{.Expected}}">{{.Expected}}</td><td data-sort="{{.TimeToDetect}}">{{.TimeToDetect}}</td><td data-sort="{{.Missed}}">{{if .Missed}}<span class="badge badge-breach">missed</span>{{else}}no{{end}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if .Notes}}
+<div class="notes">
+<h3>Notes &amp; assumptions</h3>
+<ul>
+{{- range .Notes}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+</div>
+{{- end}}
+</section>
+{{- end}}
+{{- if .Explain}}
+<section>
+<h2>How computed</h2>
+<p>Formula: <code>{{.Formula}}</code></p>
+{{- range .Sections}}
+{{- range .SLOs}}
+{{- if .Explain}}
+{{- if or .Explain.Notes .CustomFormula}}
+<h3>{{.DisplayName}}</h3>
+<ul>
+{{- if .CustomFormula}}
+<li>Formula: <code>{{.Explain.Formula}}</code></li>
+{{- end}}
+{{- range .Explain.Notes}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}
+</section>
+{{- end}}
+{{- if .Warnings}}
+<section>
+<h2>Warnings</h2>
+<ul>
+{{- range .Warnings}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+</section>
+{{- end}}
+<script>
+document.querySelectorAll("table.sortable").forEach(function (table) {
+  table.querySelectorAll("th").forEach(function (th, column) {
+    th.addEventListener("click", function () {
+      var body = table.tBodies[0];
+      var ascending = th.dataset.order !== "asc";
+      th.dataset.order = ascending ? "asc" : "desc";
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function (a, b) {
+        var x = a.cells[column].dataset.sort, y = b.cells[column].dataset.sort;
+        var nx = parseFloat(x), ny = parseFloat(y);
+        var cmp = (!isNaN(nx) && !isNaN(ny)) ? nx - ny : x.localeCompare(y);
+        return ascending ? cmp : -cmp;
+      });
+      rows.forEach(function (row) { body.appendChild(row); });
+    });
+  });
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,109 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bayneri/margin/internal/analyze"
+)
+
+func htmlTestResult() analyze.Result {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	peak := start.Add(10 * time.Minute)
+	return analyze.Result{
+		Project: "demo",
+		Service: "checkout",
+		Window:  analyze.Window{Start: start, End: start.Add(20 * time.Minute), DurationSeconds: 1200},
+		Status:  analyze.StatusPartial,
+		SLOs: []analyze.SLOResult{
+			{
+				SLOResourceName:         "projects/demo/services/checkout/serviceLevelObjectives/availability",
+				DisplayName:             "availability <api>",
+				Goal:                    0.999,
+				Compliance:              0.995,
+				BadFraction:             0.005,
+				AllowedBadFraction:      0.001,
+				ConsumedPercentOfBudget: 500,
+				Status:                  analyze.StatusBreach,
+				Explain:                 &analyze.Explain{Notes: []string{"error budget exceeded in window"}},
+				Burndown: &analyze.Burndown{
+					StepSeconds:  600,
+					PeakBurnRate: 9,
+					PeakAt:       &peak,
+					Points: []analyze.BurndownPoint{
+						{Start: start, End: peak, BadFraction: 0.009, BurnRate: 9, CumulativeConsumedPercent: 450},
+						{Start: peak, End: start.Add(20 * time.Minute), BadFraction: 0.001, BurnRate: 1, CumulativeConsumedPercent: 500},
+					},
+				},
+			},
+			{DisplayName: "freshness", Goal: 0.99, Status: analyze.StatusPartial, Error: `unsupported SLI type "windows-based"`},
+		},
+		Errors: []string{`freshness: unsupported SLI type "windows-based"`},
+	}
+}
+
+func TestWriteHTMLSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.html")
+	if err := WriteHTMLSummary(path, htmlTestResult(), Options{Explain: true, Timezone: time.UTC, TopIntervals: 1}); err != nil {
+		t.Fatalf("write html: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	text := string(data)
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		`<span class="badge badge-partial">partial</span>`,
+		`<span class="badge badge-breach">breach</span>`,
+		`<td data-sort="availability &lt;api&gt;"><a href="https://console.cloud.google.com/monitoring/services/checkout/serviceLevelObjectives/availability?project=demo">availability &lt;api&gt;</a></td>`,
+		`<td data-sort="freshness">freshness</td>`,
+		`<td class="num" data-sort="500">500.00%</td>`,
+		`<a href="https://console.cloud.google.com/monitoring/services/checkout?project=demo">`,
+		"<svg xmlns=",
+		"availability &lt;api&gt; burndown",
+		"2025-01-01T10:00:00Z to 2025-01-01T10:10:00Z",
+		"Peak burn rate: 9.00x at 2025-01-01T10:10:00Z",
+		"<li>error budget exceeded in window</li>",
+		"unsupported SLI type &#34;windows-based&#34;",
+		`table.sortable`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("html missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "2025-01-01T10:10:00Z to 2025-01-01T10:20:00Z") {
+		t.Fatal("expected only the worst interval with TopIntervals 1")
+	}
+	if strings.Contains(text, "<link") || strings.Contains(text, "<script src") || strings.Contains(text, "<img") {
+		t.Fatal("html must be self-contained")
+	}
+}
+
+func TestWriteAggregateHTML(t *testing.T) {
+	result := htmlTestResult()
+	agg, err := Aggregate([]analyze.Result{result}, []string{"a/summary.json"})
+	if err != nil {
+		t.Fatalf("aggregate: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "report", "summary.html")
+	if err := WriteAggregateHTML(path, agg, AggregateOptions{}); err != nil {
+		t.Fatalf("write html: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	text := string(data)
+	for _, want := range []string{"<h1>margin report</h1>", "Inputs: 1", "<h2>checkout <small>(demo)</small></h2>", "<h2>Warnings</h2>", "a/summary.json: 1 error(s)"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("html missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "How computed") {
+		t.Fatal("aggregate html should not include explain notes")
+	}
+}
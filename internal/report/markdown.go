@@ -7,11 +7,16 @@ import (
 	"time"
 
 	"github.com/bayneri/margin/internal/analyze"
+	"github.com/bayneri/margin/internal/spec"
 )
 
 type Options struct {
 	Explain  bool
 	Timezone *time.Location
+	// TopIntervals is the number of worst burndown intervals listed per SLO.
+	TopIntervals int
+	// Charts maps ChartKey to a chart path relative to the summary.
+	Charts map[string]string
 }
 
 func WriteMarkdownSummary(path string, result analyze.Result, opts Options) error {
@@ -52,21 +57,32 @@ func WriteMarkdownSummary(path string, result analyze.Result, opts Options) erro
 			slo.DisplayName, slo.Goal, slo.Compliance, slo.BadFraction, slo.AllowedBadFraction, slo.ConsumedPercentOfBudget, slo.Status)
 	}
 
-	if len(result.Errors) > 0 {
+	writeExclusions(&b, "##", result.Exclusions, result.SLOs, opts.Timezone)
+	writeWindowCounts(&b, result.SLOs)
+	writePeriodBudget(&b, result.SLOs, opts)
+	writeWorstIntervals(&b, result.SLOs, opts)
+	writeBreakdown(&b, result.SLOs)
+	writeDetection(&b, result.SLOs, opts)
+	writeChanges(&b, result, opts)
+
+	if notes := append(append([]string{}, result.Errors...), sloNotes(result.SLOs)...); len(notes) > 0 {
 		fmt.Fprintf(&b, "\n## Notes & assumptions\n")
-		for _, err := range result.Errors {
-			fmt.Fprintf(&b, "- %s\n", err)
+		for _, note := range notes {
+			fmt.Fprintf(&b, "- %s\n", note)
 		}
 	}
 
 	if opts.Explain {
 		fmt.Fprintf(&b, "\n## How computed\n")
-		fmt.Fprintf(&b, "\nFormula: allowedBad = 1 - goal; bad = 1 - compliance; consumedPercent = (bad / allowedBad) * 100\n")
+		fmt.Fprintf(&b, "\nFormula: %s\n", budgetFormulaText)
 		for _, slo := range result.SLOs {
-			if slo.Explain == nil || len(slo.Explain.Notes) == 0 {
+			if slo.Explain == nil || (len(slo.Explain.Notes) == 0 && !customFormula(slo)) {
 				continue
 			}
 			fmt.Fprintf(&b, "\n### %s\n", slo.DisplayName)
+			if customFormula(slo) {
+				fmt.Fprintf(&b, "- Formula: %s\n", slo.Explain.Formula)
+			}
 			for _, note := range slo.Explain.Notes {
 				fmt.Fprintf(&b, "- %s\n", note)
 			}
@@ -76,6 +92,286 @@ func WriteMarkdownSummary(path string, result analyze.Result, opts Options) erro
 	return os.WriteFile(path, []byte(b.String()), 0644)
 }
 
+// sloNotes lists the sections each SLO could not read, by SLO.
+func sloNotes(slos []analyze.SLOResult) []string {
+	var out []string
+	for _, slo := range slos {
+		for _, note := range slo.Notes {
+			out = append(out, fmt.Sprintf("%s: %s", slo.DisplayName, note))
+		}
+	}
+	return out
+}
+
+// customFormula reports whether an SLO's budget math differs from the
+// request-based formula printed for the whole report.
+func customFormula(slo analyze.SLOResult) bool {
+	return slo.Explain != nil && slo.Explain.Formula != "" && slo.Explain.Formula != budgetFormulaText
+}
+
+// writeExclusions lists the excluded intervals and why, then each SLO's raw
+// and adjusted budget.
+func writeExclusions(b *strings.Builder, heading string, exclusions []analyze.Exclusion, slos []analyze.SLOResult, loc *time.Location) {
+	if len(exclusions) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n%s Excluded intervals\n\n", heading)
+	fmt.Fprintf(b, "The adjusted budget leaves these intervals out; the raw budget covers the whole window.\n\n")
+	fmt.Fprintf(b, "| Start | End | Duration | Reason |\n")
+	fmt.Fprintf(b, "| --- | --- | --- | --- |\n")
+	for _, exclusion := range exclusions {
+		reason := exclusion.Reason
+		if reason == "" {
+			reason = "not given"
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | %s |\n", exclusion.Start.In(loc).Format(time.RFC3339), exclusion.End.In(loc).Format(time.RFC3339),
+			formatDuration(int64(exclusion.End.Sub(exclusion.Start).Seconds())), reason)
+	}
+	fmt.Fprintf(b, "\n| SLO | Excluded | Compliance (adjusted) | Budget consumed (raw) | Budget consumed (adjusted) | Status (adjusted) |\n")
+	fmt.Fprintf(b, "| --- | --- | --- | --- | --- | --- |\n")
+	for _, slo := range slos {
+		if slo.Adjusted == nil {
+			fmt.Fprintf(b, "| %s | n/a | n/a | %s | n/a | n/a |\n", slo.DisplayName, rawConsumed(slo))
+			continue
+		}
+		a := slo.Adjusted
+		fmt.Fprintf(b, "| %s | %s | %.4f | %s | %.2f%% | %s |\n",
+			slo.DisplayName, formatDuration(a.ExcludedSeconds), a.Compliance, rawConsumed(slo), a.ConsumedPercentOfBudget, a.Status)
+	}
+}
+
+func rawConsumed(slo analyze.SLOResult) string {
+	if slo.Status != analyze.StatusOK && slo.Status != analyze.StatusBreach {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.2f%%", slo.ConsumedPercentOfBudget)
+}
+
+func writeWindowCounts(b *strings.Builder, slos []analyze.SLOResult) {
+	header := false
+	for _, slo := range slos {
+		if slo.Windows == nil {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(b, "\n## Windows-based SLIs\n\n")
+			fmt.Fprintf(b, "| SLO | Window | Windows | Good | Bad | Allowed bad |\n")
+			fmt.Fprintf(b, "| --- | --- | --- | --- | --- | --- |\n")
+			header = true
+		}
+		fmt.Fprintf(b, "| %s | %s | %d | %d | %d | %.2f |\n",
+			slo.DisplayName, formatDuration(slo.Windows.WindowSeconds), slo.Windows.Total, slo.Windows.Good, slo.Windows.Bad, slo.Windows.AllowedBad)
+	}
+}
+
+func writePeriodBudget(b *strings.Builder, slos []analyze.SLOResult, opts Options) {
+	header := false
+	for _, slo := range slos {
+		if slo.Period == nil {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(b, "\n## Compliance period budget\n\n")
+			fmt.Fprintf(b, "| SLO | Period | Compliance | Budget consumed | Budget remaining | Consumed in window | Burn rate (last %s) | Projected exhaustion |\n", lookbackLabel())
+			fmt.Fprintf(b, "| --- | --- | --- | --- | --- | --- | --- | --- |\n")
+			header = true
+		}
+		p := slo.Period
+		fmt.Fprintf(b, "| %s | %s to %s | %.4f | %.2f%% | %.2f%% | %.2f%% | %.2fx | %s |\n",
+			slo.DisplayName, p.Start.In(opts.Timezone).Format(time.RFC3339), p.End.In(opts.Timezone).Format(time.RFC3339),
+			p.Compliance, p.ConsumedPercentOfBudget, p.RemainingPercentOfBudget, p.WindowConsumedPercentOfBudget,
+			p.CurrentBurnRate, exhaustionLabel(p, opts.Timezone))
+	}
+}
+
+func lookbackLabel() string {
+	label, _ := spec.FormatWindow(analyze.BurnRateLookback)
+	return label
+}
+
+// exhaustionLabel describes when the period budget runs out.
+func exhaustionLabel(p *analyze.Period, loc *time.Location) string {
+	switch {
+	case p.RemainingPercentOfBudget <= 0:
+		return "exhausted"
+	case p.ProjectedExhaustion == nil:
+		return "not burning"
+	default:
+		return p.ProjectedExhaustion.In(loc).Format(time.RFC3339)
+	}
+}
+
+func writeWorstIntervals(b *strings.Builder, slos []analyze.SLOResult, opts Options) {
+	header := false
+	for _, slo := range slos {
+		if slo.Burndown == nil {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(b, "\n## Worst intervals\n")
+			header = true
+		}
+		peak := "n/a"
+		if slo.Burndown.PeakAt != nil {
+			peak = fmt.Sprintf("%.2fx at %s", slo.Burndown.PeakBurnRate, slo.Burndown.PeakAt.In(opts.Timezone).Format(time.RFC3339))
+		}
+		fmt.Fprintf(b, "\n### %s\n\n", slo.DisplayName)
+		fmt.Fprintf(b, "- Step: %s\n", formatDuration(slo.Burndown.StepSeconds))
+		fmt.Fprintf(b, "- Peak burn rate: %s\n", peak)
+		if path := opts.Charts[ChartKey(slo)]; path != "" {
+			fmt.Fprintf(b, "\n![%s burndown](%s)\n", slo.DisplayName, path)
+		}
+		worst := analyze.WorstIntervals(slo.Burndown, opts.TopIntervals)
+		if len(worst) == 0 {
+			fmt.Fprintf(b, "\nNo bad events in the window.\n")
+			continue
+		}
+		fmt.Fprintf(b, "\n| Interval | Bad fraction | Burn rate | Budget consumed so far |\n")
+		fmt.Fprintf(b, "| --- | --- | --- | --- |\n")
+		for _, point := range worst {
+			fmt.Fprintf(b, "| %s to %s | %.4f | %.2fx | %.2f%% |\n",
+				point.Start.In(opts.Timezone).Format(time.RFC3339), point.End.In(opts.Timezone).Format(time.RFC3339),
+				point.BadFraction, point.BurnRate, point.CumulativeConsumedPercent)
+		}
+	}
+}
+
+func writeBreakdown(b *strings.Builder, slos []analyze.SLOResult) {
+	header := false
+	for _, slo := range slos {
+		if slo.Breakdown == nil {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(b, "\n## Breakdown\n")
+			header = true
+		}
+		fmt.Fprintf(b, "\n### %s\n\n", slo.DisplayName)
+		if len(slo.Breakdown.Contributors) == 0 {
+			fmt.Fprintf(b, "No bad events in the window.\n")
+			continue
+		}
+		columns := append(append([]string{}, slo.Breakdown.Labels...), "Bad events", "Share of bad", "Bad fraction", "Budget consumed")
+		fmt.Fprintf(b, "| %s |\n", strings.Join(columns, " | "))
+		fmt.Fprintf(b, "|%s\n", strings.Repeat(" --- |", len(columns)))
+		for _, contributor := range slo.Breakdown.Contributors {
+			fmt.Fprintf(b, "| %s | %.0f | %.2f%% | %.4f | %.2f%% |\n",
+				strings.Join(labelValues(contributor, slo.Breakdown.Labels), " | "),
+				contributor.BadEvents, contributor.ShareOfBadPercent, contributor.BadFraction, contributor.ConsumedPercentOfBudget)
+		}
+		if slo.Breakdown.OmittedGroups > 0 {
+			fmt.Fprintf(b, "\n%d more group(s) with bad events not shown.\n", slo.Breakdown.OmittedGroups)
+		}
+	}
+}
+
+func labelValues(contributor analyze.Contributor, labels []string) []string {
+	values := make([]string, len(labels))
+	for i, label := range labels {
+		values[i] = contributor.Labels[label]
+		if values[i] == "" {
+			values[i] = "(none)"
+		}
+	}
+	return values
+}
+
+func writeDetection(b *strings.Builder, slos []analyze.SLOResult, opts Options) {
+	header := false
+	for _, slo := range slos {
+		if slo.Detection == nil {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(b, "\n## Detection\n")
+			header = true
+		}
+		fmt.Fprintf(b, "\n### %s\n\n", slo.DisplayName)
+		if slo.Detection.Note != "" {
+			fmt.Fprintf(b, "- %s\n", slo.Detection.Note)
+			continue
+		}
+		fmt.Fprintf(b, "- First budget burn: %s\n", formatOptionalTime(slo.Detection.FirstBurnAt, opts.Timezone))
+		fmt.Fprintf(b, "\n| Alert | Threshold | Windows | Incidents | Expected to fire | Time to detect | Missed |\n")
+		fmt.Fprintf(b, "| --- | --- | --- | --- | --- | --- | --- |\n")
+		for _, alert := range slo.Detection.Alerts {
+			ttd := "n/a"
+			if alert.TimeToDetectSeconds != nil {
+				ttd = formatDuration(*alert.TimeToDetectSeconds)
+			}
+			missed := "no"
+			if alert.Missed {
+				missed = "yes"
+			}
+			fmt.Fprintf(b, "| %s | %.1fx | %s | %s | %s | %s | %s |\n",
+				alert.DisplayName, alert.BurnRate, strings.Join(alert.Windows, ", "), incidentsLabel(alert.Incidents, opts.Timezone),
+				formatOptionalTime(alert.ExpectedAt, opts.Timezone), ttd, missed)
+		}
+	}
+}
+
+// writeChanges lists the changes of the window and, per SLO with a
+// burndown, the burn spikes and the changes that preceded them.
+func writeChanges(b *strings.Builder, result analyze.Result, opts Options) {
+	if len(result.Changes) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## Changes\n\n")
+	fmt.Fprintf(b, "| Time | Kind | Name | Source |\n")
+	fmt.Fprintf(b, "| --- | --- | --- | --- |\n")
+	for _, change := range result.Changes {
+		fmt.Fprintf(b, "| %s | %s | %s | %s |\n", change.Time.In(opts.Timezone).Format(time.RFC3339), change.Kind, change.Name, change.Source)
+	}
+	fmt.Fprintf(b, "\nBurn spikes are runs of burndown steps at or above %gx. A change precedes a spike when it happened at most %s before the spike's first step, or during it.\n",
+		analyze.SpikeBurnRate, formatDuration(result.ChangeLagSeconds))
+	for _, slo := range result.SLOs {
+		if slo.Burndown == nil {
+			continue
+		}
+		fmt.Fprintf(b, "\n### %s\n\n", slo.DisplayName)
+		if len(slo.Spikes) == 0 {
+			fmt.Fprintf(b, "No burn spikes in the window.\n")
+			continue
+		}
+		fmt.Fprintf(b, "| Spike | Peak burn rate | Preceding changes |\n")
+		fmt.Fprintf(b, "| --- | --- | --- |\n")
+		for _, spike := range slo.Spikes {
+			preceding := "none"
+			if len(spike.PrecedingChanges) > 0 {
+				var parts []string
+				for _, change := range spike.PrecedingChanges {
+					parts = append(parts, fmt.Sprintf("%s %s at %s", change.Kind, change.Name, change.Time.In(opts.Timezone).Format(time.RFC3339)))
+				}
+				preceding = strings.Join(parts, "; ")
+			}
+			fmt.Fprintf(b, "| %s to %s | %.2fx | %s |\n", spike.Start.In(opts.Timezone).Format(time.RFC3339), spike.End.In(opts.Timezone).Format(time.RFC3339),
+				spike.PeakBurnRate, preceding)
+		}
+	}
+}
+
+func incidentsLabel(incidents []analyze.Incident, loc *time.Location) string {
+	if len(incidents) == 0 {
+		return "none"
+	}
+	var parts []string
+	for _, incident := range incidents {
+		closed := "open"
+		if incident.CloseTime != nil {
+			closed = incident.CloseTime.In(loc).Format(time.RFC3339)
+		}
+		parts = append(parts, fmt.Sprintf("%s to %s", incident.OpenTime.In(loc).Format(time.RFC3339), closed))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func formatOptionalTime(t *time.Time, loc *time.Location) string {
+	if t == nil {
+		return "n/a"
+	}
+	return t.In(loc).Format(time.RFC3339)
+}
+
 func formatDuration(seconds int64) string {
 	return (time.Duration(seconds) * time.Second).String()
 }
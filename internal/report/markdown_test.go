@@ -2,6 +2,8 @@ package report
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -46,3 +48,306 @@ func TestWriteMarkdownSummary(t *testing.T) {
 		t.Fatalf("markdown mismatch")
 	}
 }
+
+func TestWriteMarkdownSummaryWorstIntervals(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	peak := start.Add(20 * time.Minute)
+	result := analyze.Result{
+		Project: "demo",
+		Service: "checkout",
+		Window: analyze.Window{
+			Start:           start,
+			End:             start.Add(30 * time.Minute),
+			DurationSeconds: 1800,
+		},
+		SLOs: []analyze.SLOResult{{
+			DisplayName:             "availability",
+			Goal:                    0.999,
+			Compliance:              0.9963,
+			BadFraction:             0.0037,
+			AllowedBadFraction:      0.001,
+			ConsumedPercentOfBudget: 366.6667,
+			Status:                  analyze.StatusBreach,
+			Burndown: &analyze.Burndown{
+				StepSeconds:  600,
+				PeakBurnRate: 10,
+				PeakAt:       &peak,
+				Points: []analyze.BurndownPoint{
+					{Start: start, End: start.Add(10 * time.Minute), BadFraction: 0.001, BurnRate: 1, CumulativeConsumedPercent: 33.3333},
+					{Start: start.Add(10 * time.Minute), End: peak, BadFraction: 0.01, BurnRate: 10, CumulativeConsumedPercent: 366.6667},
+					{Start: peak, End: start.Add(30 * time.Minute), BadFraction: 0, BurnRate: 0, CumulativeConsumedPercent: 366.6667},
+				},
+			},
+		}},
+	}
+
+	path := "./test-summary-burndown.md"
+	defer os.Remove(path)
+
+	if err := WriteMarkdownSummary(path, result, Options{Timezone: time.UTC, TopIntervals: 5, Charts: map[string]string{"availability": "charts/availability.svg"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	golden, err := os.ReadFile("./testdata/summary-burndown.md")
+	if err != nil {
+		t.Fatalf("read golden: %v", err)
+	}
+	if string(data) != string(golden) {
+		t.Fatalf("markdown mismatch\n--- got ---\n%s\n--- want ---\n%s", string(data), string(golden))
+	}
+}
+
+func TestWriteMarkdownSummaryPeriod(t *testing.T) {
+	start := time.Date(2025, 1, 31, 11, 0, 0, 0, time.UTC)
+	now := start.Add(time.Hour)
+	exhaustion := now.Add(180 * time.Hour)
+	result := analyze.Result{
+		Project: "demo",
+		Service: "checkout",
+		Window:  analyze.Window{Start: start, End: now, DurationSeconds: 3600},
+		SLOs: []analyze.SLOResult{{
+			DisplayName: "availability",
+			Goal:        0.999,
+			Status:      analyze.StatusOK,
+			Period: &analyze.Period{
+				Start:                         now.Add(-30 * 24 * time.Hour),
+				End:                           now,
+				Compliance:                    0.9995,
+				ConsumedPercentOfBudget:       50,
+				RemainingPercentOfBudget:      50,
+				WindowConsumedPercentOfBudget: 1.3889,
+				CurrentBurnRate:               2,
+				ProjectedExhaustion:           &exhaustion,
+			},
+		}},
+	}
+
+	path := filepath.Join(t.TempDir(), "summary.md")
+	if err := WriteMarkdownSummary(path, result, Options{Timezone: time.UTC}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	want := "| availability | 2025-01-01T12:00:00Z to 2025-01-31T12:00:00Z | 0.9995 | 50.00% | 50.00% | 1.39% | 2.00x | 2025-02-08T00:00:00Z |"
+	if !strings.Contains(string(data), "## Compliance period budget") || !strings.Contains(string(data), want) {
+		t.Fatalf("missing period budget table:\n%s", data)
+	}
+}
+
+func TestWriteMarkdownSummaryExclusions(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	result := analyze.Result{
+		Project: "demo",
+		Service: "checkout",
+		Window:  analyze.Window{Start: start, End: start.Add(4 * time.Hour), DurationSeconds: 4 * 3600},
+		Exclusions: []analyze.Exclusion{
+			{Start: start.Add(time.Hour), End: start.Add(2 * time.Hour), Reason: "database upgrade"},
+			{Start: start.Add(3 * time.Hour), End: start.Add(4 * time.Hour)},
+		},
+		SLOs: []analyze.SLOResult{
+			{
+				DisplayName:             "availability",
+				Goal:                    0.999,
+				ConsumedPercentOfBudget: 1000,
+				Status:                  analyze.StatusBreach,
+				Adjusted:                &analyze.Adjusted{ExcludedSeconds: 7200, Compliance: 0.9995, BadFraction: 0.0005, ConsumedPercentOfBudget: 50, Status: analyze.StatusOK},
+			},
+			{DisplayName: "latency", Goal: 0.99, Status: analyze.StatusError, Error: "permission denied"},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "summary.md")
+	if err := WriteMarkdownSummary(path, result, Options{Timezone: time.UTC}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	for _, want := range []string{
+		"## Excluded intervals",
+		"| 2025-01-01T11:00:00Z | 2025-01-01T12:00:00Z | 1h0m0s | database upgrade |",
+		"| 2025-01-01T13:00:00Z | 2025-01-01T14:00:00Z | 1h0m0s | not given |",
+		"| availability | 2h0m0s | 0.9995 | 1000.00% | 50.00% | ok |",
+		"| latency | n/a | n/a | n/a | n/a | n/a |",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("missing %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestWriteMarkdownSummaryChanges(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	deploy := analyze.ChangeEvent{Time: start.Add(40 * time.Minute), Kind: analyze.ChangeDeploy, Name: "checkout-00042", Source: "cloud-run"}
+	result := analyze.Result{
+		Project:          "demo",
+		Service:          "checkout",
+		Window:           analyze.Window{Start: start, End: start.Add(4 * time.Hour), DurationSeconds: 4 * 3600},
+		Changes:          []analyze.ChangeEvent{deploy},
+		ChangeLagSeconds: 1800,
+		SLOs: []analyze.SLOResult{
+			{
+				DisplayName: "availability",
+				Goal:        0.999,
+				Status:      analyze.StatusBreach,
+				Burndown:    &analyze.Burndown{StepSeconds: 3600},
+				Spikes:      []analyze.BurnSpike{{Start: start.Add(time.Hour), End: start.Add(2 * time.Hour), PeakBurnRate: 14, PrecedingChanges: []analyze.ChangeEvent{deploy}}},
+			},
+			{DisplayName: "latency", Goal: 0.99, Status: analyze.StatusOK, Burndown: &analyze.Burndown{StepSeconds: 3600}},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "summary.md")
+	if err := WriteMarkdownSummary(path, result, Options{Timezone: time.UTC}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	for _, want := range []string{
+		"## Changes",
+		"| 2025-01-01T10:40:00Z | deploy | checkout-00042 | cloud-run |",
+		"at most 30m0s before",
+		"| 2025-01-01T11:00:00Z to 2025-01-01T12:00:00Z | 14.00x | deploy checkout-00042 at 2025-01-01T10:40:00Z |",
+		"No burn spikes in the window.",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("missing %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestWriteMarkdownSummaryDetection(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	firstBurn := start.Add(10 * time.Minute)
+	opened := start.Add(25 * time.Minute)
+	closed := start.Add(50 * time.Minute)
+	expected := start.Add(20 * time.Minute)
+	ttd := int64(900)
+	result := analyze.Result{
+		Project: "demo",
+		Service: "checkout",
+		Window:  analyze.Window{Start: start, End: start.Add(time.Hour), DurationSeconds: 3600},
+		SLOs: []analyze.SLOResult{{
+			DisplayName: "availability",
+			Goal:        0.999,
+			Status:      analyze.StatusBreach,
+			Detection: &analyze.Detection{
+				FirstBurnAt: &firstBurn,
+				Alerts: []analyze.AlertDetection{
+					{
+						DisplayName:         "checkout availability fast-burn",
+						BurnRate:            14.4,
+						Windows:             []string{"5m", "1h"},
+						Incidents:           []analyze.Incident{{OpenTime: opened, CloseTime: &closed}},
+						ExpectedAt:          &expected,
+						TimeToDetectSeconds: &ttd,
+					},
+					{DisplayName: "checkout availability slow-burn", BurnRate: 6, Windows: []string{"6h"}, ExpectedAt: &expected, Missed: true},
+				},
+			},
+		}},
+	}
+
+	path := filepath.Join(t.TempDir(), "summary.md")
+	if err := WriteMarkdownSummary(path, result, Options{Timezone: time.UTC}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	for _, want := range []string{
+		"- First budget burn: 2025-01-01T10:10:00Z",
+		"| checkout availability fast-burn | 14.4x | 5m, 1h | 2025-01-01T10:25:00Z to 2025-01-01T10:50:00Z | 2025-01-01T10:20:00Z | 15m0s | no |",
+		"| checkout availability slow-burn | 6.0x | 6h | none | 2025-01-01T10:20:00Z | n/a | yes |",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("missing %q in:\n%s", want, data)
+		}
+	}
+}
+
+func TestWriteMarkdownSummaryBreakdown(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	labels := []string{"metric.label.response_code", "resource.label.revision_name"}
+	result := analyze.Result{
+		Project: "demo",
+		Service: "checkout",
+		Window:  analyze.Window{Start: start, End: start.Add(time.Hour), DurationSeconds: 3600},
+		SLOs: []analyze.SLOResult{{
+			DisplayName: "availability",
+			Goal:        0.999,
+			Status:      analyze.StatusBreach,
+			Breakdown: &analyze.Breakdown{
+				Labels: labels,
+				Contributors: []analyze.Contributor{
+					{Labels: map[string]string{labels[0]: "503", labels[1]: "checkout-00042"}, TotalEvents: 1000, BadEvents: 100, BadFraction: 0.1, ShareOfBadPercent: 90.9091, ConsumedPercentOfBudget: 500},
+					{Labels: map[string]string{labels[0]: "500"}, TotalEvents: 10000, BadEvents: 10, BadFraction: 0.001, ShareOfBadPercent: 9.0909, ConsumedPercentOfBudget: 50},
+				},
+				OmittedGroups: 2,
+			},
+		}},
+	}
+
+	path := filepath.Join(t.TempDir(), "summary.md")
+	if err := WriteMarkdownSummary(path, result, Options{Timezone: time.UTC}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	for _, want := range []string{
+		"| metric.label.response_code | resource.label.revision_name | Bad events | Share of bad | Bad fraction | Budget consumed |\n| --- | --- | --- | --- | --- | --- |",
+		"| 503 | checkout-00042 | 100 | 90.91% | 0.1000 | 500.00% |",
+		"| 500 | (none) | 10 | 9.09% | 0.0010 | 50.00% |",
+		"2 more group(s) with bad events not shown.",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("missing %q in:\n%s", want, data)
+		}
+	}
+}
+
+func TestWriteMarkdownSummaryWindowsBased(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	formula := "windows = duration / windowPeriod; badWindows = (1 - compliance) * windows; allowedBadWindows = (1 - goal) * windows; consumedPercent = (badWindows / allowedBadWindows) * 100"
+	result := analyze.Result{
+		Project: "demo",
+		Service: "checkout",
+		Window:  analyze.Window{Start: start, End: start.Add(time.Hour), DurationSeconds: 3600},
+		SLOs: []analyze.SLOResult{{
+			DisplayName: "uptime",
+			Goal:        0.99,
+			SLIType:     analyze.SLITypeWindowsBased,
+			Status:      analyze.StatusBreach,
+			Windows:     &analyze.WindowCounts{WindowSeconds: 60, Total: 60, Good: 57, Bad: 3, AllowedBad: 0.6},
+			Explain:     &analyze.Explain{Formula: formula},
+		}},
+	}
+
+	path := filepath.Join(t.TempDir(), "summary.md")
+	if err := WriteMarkdownSummary(path, result, Options{Timezone: time.UTC, Explain: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	for _, want := range []string{
+		"| uptime | 1m0s | 60 | 57 | 3 | 0.60 |",
+		"### uptime\n- Formula: " + formula,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("missing %q in:\n%s", want, data)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bayneri/margin/internal/analyze"
+	"github.com/bayneri/margin/internal/spec"
+)
+
+func WriteRecommendJSON(path string, result analyze.RecommendResult) error {
+	return WriteJSON(path, result)
+}
+
+// WriteRecommendMarkdown writes the recommendation per SLO, followed by how
+// every candidate objective and threshold would have fared.
+func WriteRecommendMarkdown(path string, result analyze.RecommendResult) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Objective recommendations\n\n")
+	fmt.Fprintf(&b, "- Service: %s\n", result.Service)
+	fmt.Fprintf(&b, "- Project: %s\n", result.Project)
+	fmt.Fprintf(&b, "- Generated: %s\n", result.GeneratedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "- History: %s in %s steps\n", windowLabel(result.LookbackSeconds), formatDuration(result.StepSeconds))
+	fmt.Fprintf(&b, "- Target: met in at least %.0f%% of periods\n\n", result.TargetPercent)
+
+	fmt.Fprintf(&b, "| SLO | Periods | Objective | Recommended | Threshold | Recommended threshold |\n")
+	fmt.Fprintf(&b, "| --- | --- | --- | --- | --- | --- |\n")
+	for _, slo := range result.SLOs {
+		if slo.Error != "" {
+			fmt.Fprintf(&b, "| %s | n/a | %v | n/a | %s | n/a |\n", slo.Name, slo.Objective, orNone(slo.Threshold))
+			continue
+		}
+		recommended := "none met the target"
+		if slo.RecommendedObjective != nil {
+			recommended = fmt.Sprintf("%v", *slo.RecommendedObjective)
+		}
+		recommendedThreshold := slo.RecommendedThreshold
+		if slo.Threshold != "" && recommendedThreshold == "" {
+			recommendedThreshold = "none met the target"
+		}
+		fmt.Fprintf(&b, "| %s | %d x %s | %v | %s | %s | %s |\n",
+			slo.Name, slo.Periods, windowLabel(slo.PeriodSeconds), slo.Objective, recommended, orNone(slo.Threshold), orNone(recommendedThreshold))
+	}
+
+	for _, slo := range result.SLOs {
+		if len(slo.Objectives) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", slo.Name)
+		writeCandidates(&b, slo.Objectives)
+		if len(slo.Thresholds) > 0 {
+			fmt.Fprintf(&b, "\n")
+			writeCandidates(&b, slo.Thresholds)
+		}
+		for _, note := range slo.Notes {
+			fmt.Fprintf(&b, "\n- %s", note)
+		}
+		if len(slo.Notes) > 0 {
+			fmt.Fprintf(&b, "\n")
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		fmt.Fprintf(&b, "\n## Errors\n")
+		for _, err := range result.Errors {
+			fmt.Fprintf(&b, "- %s\n", err)
+		}
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+func writeCandidates(b *strings.Builder, candidates []analyze.Candidate) {
+	fmt.Fprintf(b, "| Objective | Threshold | Periods met | Budget exhaustions | Alert firings | Worst compliance |\n")
+	fmt.Fprintf(b, "| --- | --- | --- | --- | --- | --- |\n")
+	for _, c := range candidates {
+		fmt.Fprintf(b, "| %v | %s | %.1f%% | %d | %d | %.4f%% |\n",
+			c.Objective, orNone(c.Threshold), c.MetPercent, c.Exhaustions, c.AlertFirings, c.WorstCompliance*100)
+	}
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// windowLabel renders durations in window units such as 90d when they divide
+// evenly.
+func windowLabel(seconds int64) string {
+	if label, ok := spec.FormatWindow(time.Duration(seconds) * time.Second); ok {
+		return label
+	}
+	return formatDuration(seconds)
+}
@@ -0,0 +1,61 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bayneri/margin/internal/analyze"
+)
+
+func TestWriteRecommendMarkdown(t *testing.T) {
+	recommended := 99.5
+	result := analyze.RecommendResult{
+		Project:         "demo",
+		Service:         "checkout-api",
+		GeneratedAt:     time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC),
+		LookbackSeconds: 90 * 24 * 3600,
+		StepSeconds:     3600,
+		TargetPercent:   90,
+		SLOs: []analyze.SLORecommendation{
+			{
+				Name:                 "latency",
+				PeriodSeconds:        30 * 24 * 3600,
+				Periods:              61,
+				Objective:            99,
+				RecommendedObjective: &recommended,
+				Threshold:            "500ms",
+				RecommendedThreshold: "400ms",
+				Objectives:           []analyze.Candidate{{Objective: 99.5, Threshold: "500ms", MetPercent: 95.08, Exhaustions: 1, AlertFirings: 2, WorstCompliance: 0.9941}},
+				Thresholds:           []analyze.Candidate{{Objective: 99.5, Threshold: "400ms", MetPercent: 91.8, AlertFirings: 3, WorstCompliance: 0.9932}},
+				Notes:                []string{"latency buckets that straddle a threshold count as bad"},
+			},
+			{Name: "availability", Objective: 99.9, Error: "no events in the lookback"},
+		},
+		Errors: []string{"availability: no events in the lookback"},
+	}
+
+	path := filepath.Join(t.TempDir(), "recommend.md")
+	if err := WriteRecommendMarkdown(path, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	for _, want := range []string{
+		"- History: 90d in 1h0m0s steps",
+		"| latency | 61 x 30d | 99 | 99.5 | 500ms | 400ms |",
+		"| availability | n/a | 99.9 | n/a | - | n/a |",
+		"| 99.5 | 500ms | 95.1% | 1 | 2 | 99.4100% |",
+		"| 99.5 | 400ms | 91.8% | 0 | 3 | 99.3200% |",
+		"- latency buckets that straddle a threshold count as bad",
+		"- availability: no events in the lookback",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("missing %q in:\n%s", want, data)
+		}
+	}
+}
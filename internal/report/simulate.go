@@ -0,0 +1,77 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bayneri/margin/internal/analyze"
+)
+
+func WriteSimulateJSON(path string, result analyze.SimulateResult) error {
+	return WriteJSON(path, result)
+}
+
+// WriteSimulateMarkdown writes, per SLO, when each alert policy would have
+// fired and resolved. Scenario runs also show times relative to the onset.
+func WriteSimulateMarkdown(path string, result analyze.SimulateResult) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Alert simulation\n\n")
+	fmt.Fprintf(&b, "- Service: %s\n", result.Service)
+	fmt.Fprintf(&b, "- Project: %s\n", result.Project)
+	fmt.Fprintf(&b, "- Generated: %s\n", result.GeneratedAt.Format(time.RFC3339))
+	if result.Source == "recorded" {
+		fmt.Fprintf(&b, "- Series: recorded, %s steps\n", formatDuration(result.StepSeconds))
+	} else {
+		fmt.Fprintf(&b, "- Scenario: %s, %s steps\n", result.Source, formatDuration(result.StepSeconds))
+	}
+
+	for _, slo := range result.SLOs {
+		fmt.Fprintf(&b, "\n## %s (%v)\n\n", slo.Name, slo.Objective)
+		if slo.Error != "" {
+			fmt.Fprintf(&b, "Not simulated: %s\n", slo.Error)
+			continue
+		}
+		fmt.Fprintf(&b, "- Series: %s to %s\n", slo.Start.Format(time.RFC3339), slo.End.Format(time.RFC3339))
+		if slo.Onset != nil {
+			fmt.Fprintf(&b, "- Onset: %s\n", slo.Onset.Format(time.RFC3339))
+		}
+		fmt.Fprintf(&b, "- Budget consumed by the series: %.2f%% of the %s period\n\n", slo.ConsumedPercent, windowLabel(slo.PeriodSeconds))
+
+		fmt.Fprintf(&b, "| Policy | Windows | Burn rate | Fired | Resolved | Budget spent before firing |\n")
+		fmt.Fprintf(&b, "| --- | --- | --- | --- | --- | --- |\n")
+		for _, policy := range slo.Policies {
+			windows := strings.Join(policy.Windows, ", ")
+			if len(policy.Firings) == 0 {
+				fmt.Fprintf(&b, "| %s | %s | %v | did not fire | - | - |\n", policy.Tier, windows, policy.BurnRate)
+				continue
+			}
+			for _, firing := range policy.Firings {
+				resolved := "still firing"
+				if firing.ResolvedAt != nil {
+					resolved = simulatedTime(*firing.ResolvedAt, slo.Onset)
+				}
+				fmt.Fprintf(&b, "| %s | %s | %v | %s | %s | %.2f%% |\n",
+					policy.Tier, windows, policy.BurnRate, simulatedTime(firing.FiredAt, slo.Onset), resolved, firing.BudgetSpentPercent)
+			}
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		fmt.Fprintf(&b, "\n## Errors\n")
+		for _, err := range result.Errors {
+			fmt.Fprintf(&b, "- %s\n", err)
+		}
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// simulatedTime shows synthetic times as offsets from the onset, which is
+// all they mean.
+func simulatedTime(at time.Time, onset *time.Time) string {
+	if onset == nil {
+		return at.Format(time.RFC3339)
+	}
+	return "onset + " + at.Sub(*onset).String()
+}
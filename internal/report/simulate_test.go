@@ -0,0 +1,60 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bayneri/margin/internal/analyze"
+)
+
+func TestWriteSimulateMarkdown(t *testing.T) {
+	onset := time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC)
+	resolved := onset.Add(125 * time.Minute)
+	result := analyze.SimulateResult{
+		Project:     "demo",
+		Service:     "checkout-api",
+		GeneratedAt: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+		Source:      "step:20%:2h",
+		StepSeconds: 60,
+		SLOs: []analyze.SLOSimulation{
+			{
+				Name:            "availability",
+				Objective:       99.9,
+				PeriodSeconds:   7 * 24 * 3600,
+				Start:           onset.Add(-6 * time.Hour),
+				End:             onset.Add(8 * time.Hour),
+				Onset:           &onset,
+				ConsumedPercent: 238.0952,
+				Policies: []analyze.PolicySimulation{
+					{Tier: "fast-burn", Windows: []string{"5m", "1h"}, BurnRate: 14.4, Firings: []analyze.Firing{{FiredAt: onset.Add(64 * time.Minute), ResolvedAt: &resolved, BudgetSpentPercent: 126.9841}}},
+					{Tier: "slow-burn", Windows: []string{"30m", "6h"}, BurnRate: 6},
+				},
+			},
+			{Name: "latency", Objective: 99, Error: "not in the series file"},
+		},
+		Errors: []string{"latency: not in the series file"},
+	}
+
+	path := filepath.Join(t.TempDir(), "simulate.md")
+	if err := WriteSimulateMarkdown(path, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	for _, want := range []string{
+		"- Scenario: step:20%:2h, 1m0s steps",
+		"- Budget consumed by the series: 238.10% of the 1w period",
+		"| fast-burn | 5m, 1h | 14.4 | onset + 1h4m0s | onset + 2h5m0s | 126.98% |",
+		"| slow-burn | 30m, 6h | 6 | did not fire | - | - |",
+		"Not simulated: not in the series file",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("missing %q in:\n%s", want, data)
+		}
+	}
+}
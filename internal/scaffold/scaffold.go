@@ -0,0 +1,198 @@
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bayneri/margin/internal/spec"
+)
+
+const (
+	DefaultWindow       = "30d"
+	DefaultAvailability = 99.9
+	DefaultLatency      = 99.0
+)
+
+type Options struct {
+	Service string
+	Name    string
+	Project string
+	Runbook string
+	Window  string
+	// Availability and Latency are objectives in percent; zero uses the
+	// defaults. Threshold zero uses the template's starter threshold.
+	Availability float64
+	Latency      float64
+	Threshold    time.Duration
+}
+
+type starterSLO struct {
+	Name       string
+	Objective  string
+	Window     string
+	Type       string
+	Metric     string
+	GoodFilter string
+	Filter     string
+	Threshold  string
+}
+
+type document struct {
+	Options
+	Template spec.ServiceTemplate
+	SLOs     []starterSLO
+	Skipped  []string
+}
+
+var specTemplate = template.Must(template.New("spec").Funcs(template.FuncMap{
+	"quote": quote,
+}).Parse(`# yaml-language-server: $schema=` + spec.SchemaURL(spec.APIVersionV2) + `
+# margin ServiceSLO for {{ .Name }} ({{ .Service }}), generated by margin init.
+{{- if .Template.Pitfalls }}
+#
+# Known pitfalls for {{ .Service }}:
+{{- range .Template.Pitfalls }}
+# - {{ .Text }}{{ if .Check }} (margin lint: {{ .Check }}){{ end }}
+{{- end }}
+{{- end }}
+{{- range .Skipped }}
+#
+# {{ . }}
+{{- end }}
+apiVersion: margin/v2
+kind: ServiceSLO
+metadata:
+  name: {{ quote .Name }}
+  service: {{ quote .Service }}
+  project: {{ quote .Project }}
+{{- if .Runbook }}
+  runbook: {{ quote .Runbook }}
+{{- else }}
+  # Paging alerts should link to a runbook:
+  # runbook: https://runbooks.example.com/{{ .Name }}
+{{- end }}
+
+slos:
+{{- range $i, $slo := .SLOs }}
+{{- if $i }}
+{{ end }}
+  - name: {{ $slo.Name }}
+    objective: {{ $slo.Objective }}
+    window: {{ $slo.Window }}
+    sli:
+{{- if eq $slo.Type "request-based" }}
+      requestBased:
+        good:
+          metric: {{ $slo.Metric }}
+          filter: {{ quote $slo.GoodFilter }}
+        total:
+          metric: {{ $slo.Metric }}
+          filter: {{ quote $slo.Filter }}
+{{- else }}
+      latency:
+        metric: {{ $slo.Metric }}
+        filter: {{ quote $slo.Filter }}
+        threshold: {{ $slo.Threshold }}
+{{- end }}
+{{- end }}
+`))
+
+// Render writes a starter spec for opts.Service with an availability SLO and
+// a latency SLO where the template supports them. The result is checked with
+// spec.Parse and Spec.Check and is already in margin fmt form.
+func Render(opts Options) ([]byte, error) {
+	tpl, err := spec.TemplateForService(opts.Service)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(opts.Name) == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if strings.TrimSpace(opts.Project) == "" {
+		return nil, fmt.Errorf("project is required")
+	}
+	if opts.Window == "" {
+		opts.Window = DefaultWindow
+	}
+	if opts.Availability == 0 {
+		opts.Availability = DefaultAvailability
+	}
+	if opts.Latency == 0 {
+		opts.Latency = DefaultLatency
+	}
+	if opts.Threshold == 0 {
+		opts.Threshold = tpl.LatencyThreshold
+	}
+
+	doc := document{Options: opts, Template: tpl}
+	resource := fmt.Sprintf("resource.type=%q", tpl.ResourceType)
+	if tpl.RequestMetric != "" && tpl.GoodFilter != "" {
+		doc.SLOs = append(doc.SLOs, starterSLO{
+			Name:       "availability",
+			Objective:  formatObjective(opts.Availability),
+			Window:     opts.Window,
+			Type:       "request-based",
+			Metric:     tpl.RequestMetric,
+			GoodFilter: resource + " AND " + tpl.GoodFilter,
+			Filter:     resource,
+		})
+	} else {
+		doc.Skipped = append(doc.Skipped, fmt.Sprintf("No availability SLO: %s has no label that marks successful events; add one by hand.", opts.Service))
+	}
+	if tpl.LatencyMetric != "" {
+		doc.SLOs = append(doc.SLOs, starterSLO{
+			Name:      "latency",
+			Objective: formatObjective(opts.Latency),
+			Window:    opts.Window,
+			Type:      "latency",
+			Metric:    tpl.LatencyMetric,
+			Filter:    resource,
+			Threshold: opts.Threshold.String(),
+		})
+	}
+	if len(doc.SLOs) == 0 {
+		return nil, fmt.Errorf("service %q has no starter SLOs; write the spec by hand", opts.Service)
+	}
+
+	var buf bytes.Buffer
+	if err := specTemplate.Execute(&buf, doc); err != nil {
+		return nil, err
+	}
+	data, err := spec.Format(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := spec.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := parsed.Validate(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func formatObjective(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// quote renders a YAML scalar, single-quoting it when it would not survive as
+// a plain string.
+func quote(value string) string {
+	if value != "" && strings.IndexAny(value, `:#'"{}[],&*!|>%@`+"`") < 0 &&
+		value == strings.TrimSpace(value) {
+		_, numErr := strconv.ParseFloat(value, 64)
+		switch strings.ToLower(value) {
+		case "true", "false", "yes", "no", "on", "off", "null", "~":
+		default:
+			if numErr != nil {
+				return value
+			}
+		}
+	}
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,79 @@
+package scaffold
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bayneri/margin/internal/spec"
+)
+
+func TestRenderEveryTemplate(t *testing.T) {
+	for _, service := range spec.TemplateNames() {
+		t.Run(service, func(t *testing.T) {
+			data, err := Render(Options{Service: service, Name: "checkout-api", Project: "demo"})
+			if service == "gce-uptime" {
+				if err == nil {
+					t.Fatal("expected gce-uptime to have no starter SLOs")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			s, err := spec.Parse(data)
+			if err != nil {
+				t.Fatalf("parse: %v\n%s", err, data)
+			}
+			if errs := s.Check(); len(errs) != 0 {
+				t.Fatalf("starter spec is invalid: %v\n%s", errs, data)
+			}
+		})
+	}
+}
+
+func TestRenderCloudRun(t *testing.T) {
+	data, err := Render(Options{
+		Service:      "cloud-run",
+		Name:         "checkout-api",
+		Project:      "demo",
+		Runbook:      "https://runbooks.example.com/checkout",
+		Availability: 99.5,
+		Threshold:    300 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	s, err := spec.Parse(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(s.SLOs) != 2 || s.SLOs[0].Objective != 99.5 || s.SLOs[1].Objective != DefaultLatency {
+		t.Fatalf("unexpected slos %+v", s.SLOs)
+	}
+	if s.APIVersion != spec.APIVersionLatest || len(s.Deprecations()) != 0 {
+		t.Fatalf("expected a %s spec, got %s", spec.APIVersionLatest, s.APIVersion)
+	}
+	if s.SLOs[1].SLI.Threshold != "300ms" || s.Metadata.Runbook == "" {
+		t.Fatalf("unexpected spec %+v", s)
+	}
+	text := string(data)
+	if !strings.Contains(text, "# - Retries can double-count failed requests unless filters exclude them. (margin lint: good-filter-counts-retries)") {
+		t.Fatalf("expected pitfall comments:\n%s", text)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	cases := map[string]string{
+		"checkout-api":                  "checkout-api",
+		"99":                            "'99'",
+		"yes":                           "'yes'",
+		`resource.type="x" AND a='b'`:   `'resource.type="x" AND a=''b'''`,
+		"https://runbooks.example.com/": "'https://runbooks.example.com/'",
+	}
+	for in, want := range cases {
+		if got := quote(in); got != want {
+			t.Fatalf("quote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
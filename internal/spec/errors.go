@@ -0,0 +1,144 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+const (
+	CodeSyntax             = "syntax"
+	CodeUnknownField       = "unknown-field"
+	CodeInvalidType        = "invalid-type"
+	CodeRequired           = "required"
+	CodeUnsupportedVersion = "unsupported-api-version"
+	CodeUnsupportedKind    = "unsupported-kind"
+	CodeUnknownService     = "unknown-service"
+	CodeInvalidURL         = "invalid-url"
+	CodeInvalidObjective   = "invalid-objective"
+	CodeInvalidPeriod      = "invalid-period"
+	CodeInvalidWindow      = "invalid-window"
+	CodeInvalidAlerting    = "invalid-alerting"
+	CodeInvalidSLIType     = "invalid-sli-type"
+	CodeUnsupportedMetric  = "unsupported-metric"
+	CodeInvalidFilter      = "invalid-filter"
+	CodeResourceType       = "resource-type-mismatch"
+	CodeFilterConflict     = "filter-conflict"
+	CodeInvalidThreshold   = "invalid-threshold"
+	CodeInvalidMaintenance = "invalid-maintenance"
+)
+
+type Position struct {
+	Line   int
+	Column int
+}
+
+type ValidationError struct {
+	Path     string   `json:"path"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Code     string   `json:"code"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	return e.Message
+}
+
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, item := range e {
+		messages = append(messages, item.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e ValidationErrors) HasErrors() bool {
+	for _, item := range e {
+		if item.Severity != SeverityWarning {
+			return true
+		}
+	}
+	return false
+}
+
+func (e ValidationErrors) prefixed(path string) ValidationErrors {
+	out := make(ValidationErrors, 0, len(e))
+	for _, item := range e {
+		item.Path = joinPath(path, item.Path)
+		item.Message = fmt.Sprintf("%s: %s", path, item.Message)
+		out = append(out, item)
+	}
+	return out
+}
+
+func newError(path, code, message string) ValidationError {
+	return ValidationError{Path: path, Code: code, Severity: SeverityError, Message: message}
+}
+
+func joinPath(parent, child string) string {
+	switch {
+	case parent == "":
+		return child
+	case child == "":
+		return parent
+	case strings.HasPrefix(child, "["):
+		return parent + child
+	default:
+		return parent + "." + child
+	}
+}
+
+func indexPath(parent string, index int) string {
+	return fmt.Sprintf("%s[%d]", parent, index)
+}
+
+func parentPath(path string) string {
+	dot := strings.LastIndex(path, ".")
+	bracket := strings.LastIndex(path, "[")
+	cut := dot
+	if bracket > cut {
+		cut = bracket
+	}
+	if cut < 0 {
+		return ""
+	}
+	return path[:cut]
+}
+
+// Position returns the source location recorded by Load for a spec path such
+// as "slos[0].sli.good.filter", falling back to the closest parent that was
+// present in the document.
+func (s Spec) Position(path string) (Position, bool) {
+	for {
+		if pos, ok := s.positions[path]; ok {
+			return pos, true
+		}
+		if path == "" {
+			return Position{}, false
+		}
+		path = parentPath(path)
+	}
+}
+
+func (s Spec) locate(errs ValidationErrors) ValidationErrors {
+	for i := range errs {
+		if errs[i].Line != 0 {
+			continue
+		}
+		if pos, ok := s.Position(errs[i].Path); ok {
+			errs[i].Line = pos.Line
+			errs[i].Column = pos.Column
+		}
+	}
+	return errs
+}
@@ -0,0 +1,489 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Filter is a parsed Cloud Monitoring time series filter. Monitoring binds
+// NOT tighter than OR, and OR tighter than AND, so `a AND b OR c` reads as
+// `a AND (b OR c)`; this is what lets margin prepend generated clauses with a
+// plain " AND ".
+type Filter struct {
+	Source string
+	Root   FilterNode
+}
+
+type FilterNode interface {
+	Span() FilterSpan
+}
+
+type FilterSpan struct {
+	Start int
+	End   int
+}
+
+func (s FilterSpan) Span() FilterSpan {
+	return s
+}
+
+type FilterAnd struct {
+	FilterSpan
+	Terms []FilterNode
+}
+
+type FilterOr struct {
+	FilterSpan
+	Terms []FilterNode
+}
+
+type FilterNot struct {
+	FilterSpan
+	Term FilterNode
+}
+
+type FilterGroup struct {
+	FilterSpan
+	Expr FilterNode
+}
+
+type FilterComparison struct {
+	FilterSpan
+	Selector string
+	Operator string
+	Value    FilterValue
+}
+
+type FilterValueKind int
+
+const (
+	FilterString FilterValueKind = iota
+	FilterNumber
+	FilterIdent
+	FilterFunction
+)
+
+type FilterValue struct {
+	Kind     FilterValueKind
+	Literal  string
+	Function string
+	Args     []string
+}
+
+type FilterError struct {
+	Offset  int
+	Message string
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("%s at column %d", e.Message, e.Offset+1)
+}
+
+var filterOperators = map[string]bool{
+	"=":  true,
+	"!=": true,
+	"<":  true,
+	"<=": true,
+	">":  true,
+	">=": true,
+	":":  true,
+}
+
+var filterRoots = map[string]bool{
+	"metric":   true,
+	"resource": true,
+	"project":  true,
+	"metadata": true,
+	"group":    true,
+}
+
+func ParseFilter(source string) (*Filter, error) {
+	tokens, err := lexFilter(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, &FilterError{Offset: 0, Message: "filter is empty"}
+	}
+	root, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		if tok.kind == tokRParen {
+			return nil, &FilterError{Offset: tok.start, Message: "unbalanced parenthesis"}
+		}
+		if err := lowercaseKeyword(tok); err != nil {
+			return nil, err
+		}
+		return nil, &FilterError{Offset: tok.start, Message: fmt.Sprintf("unexpected %s", tok.describe())}
+	}
+	return &Filter{Source: source, Root: root}, nil
+}
+
+func (f *Filter) Text(node FilterNode) string {
+	span := node.Span()
+	return f.Source[span.Start:span.End]
+}
+
+// Conjuncts returns the top-level AND terms of the filter, looking through
+// redundant parentheses.
+func (f *Filter) Conjuncts() []FilterNode {
+	root := unwrapGroup(f.Root)
+	if and, ok := root.(*FilterAnd); ok {
+		return and.Terms
+	}
+	return []FilterNode{f.Root}
+}
+
+// Equality returns the value of a top-level `selector = "value"` clause.
+func (f *Filter) Equality(selector string) (string, bool) {
+	for _, term := range f.Conjuncts() {
+		cmp, ok := unwrapGroup(term).(*FilterComparison)
+		if !ok || cmp.Operator != "=" || cmp.Selector != selector {
+			continue
+		}
+		if cmp.Value.Kind == FilterFunction {
+			continue
+		}
+		return cmp.Value.Literal, true
+	}
+	return "", false
+}
+
+func (f *Filter) Comparisons() []*FilterComparison {
+	var out []*FilterComparison
+	WalkFilter(f.Root, func(node FilterNode, negated bool) {
+		if cmp, ok := node.(*FilterComparison); ok {
+			out = append(out, cmp)
+		}
+	})
+	return out
+}
+
+// WalkFilter visits every node, reporting whether it sits under an odd number
+// of NOT operators.
+func WalkFilter(node FilterNode, visit func(node FilterNode, negated bool)) {
+	var walk func(FilterNode, bool)
+	walk = func(node FilterNode, negated bool) {
+		visit(node, negated)
+		switch n := node.(type) {
+		case *FilterAnd:
+			for _, term := range n.Terms {
+				walk(term, negated)
+			}
+		case *FilterOr:
+			for _, term := range n.Terms {
+				walk(term, negated)
+			}
+		case *FilterNot:
+			walk(n.Term, !negated)
+		case *FilterGroup:
+			walk(n.Expr, negated)
+		}
+	}
+	walk(node, false)
+}
+
+func unwrapGroup(node FilterNode) FilterNode {
+	for {
+		group, ok := node.(*FilterGroup)
+		if !ok {
+			return node
+		}
+		node = group.Expr
+	}
+}
+
+func selectorRoot(selector string) string {
+	return strings.SplitN(selector, ".", 2)[0]
+}
+
+type filterTokenKind int
+
+const (
+	tokEOF filterTokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOperator
+	tokLParen
+	tokRParen
+	tokComma
+	tokAnd
+	tokOr
+	tokNot
+)
+
+type filterToken struct {
+	kind  filterTokenKind
+	text  string
+	start int
+	end   int
+}
+
+func (t filterToken) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of filter"
+	case tokString:
+		return fmt.Sprintf("string %q", t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+func lexFilter(source string) ([]filterToken, error) {
+	var tokens []filterToken
+	i := 0
+	for i < len(source) {
+		c := source[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, filterToken{kind: tokLParen, text: "(", start: i, end: i + 1})
+			i++
+		case c == ')':
+			tokens = append(tokens, filterToken{kind: tokRParen, text: ")", start: i, end: i + 1})
+			i++
+		case c == ',':
+			tokens = append(tokens, filterToken{kind: tokComma, text: ",", start: i, end: i + 1})
+			i++
+		case c == '"':
+			start := i
+			var b strings.Builder
+			i++
+			closed := false
+			for i < len(source) {
+				if source[i] == '\\' && i+1 < len(source) {
+					b.WriteByte(source[i+1])
+					i += 2
+					continue
+				}
+				if source[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+				b.WriteByte(source[i])
+				i++
+			}
+			if !closed {
+				return nil, &FilterError{Offset: start, Message: "unbalanced quote"}
+			}
+			tokens = append(tokens, filterToken{kind: tokString, text: b.String(), start: start, end: i})
+		case strings.IndexByte("=!<>:~", c) >= 0:
+			start := i
+			for i < len(source) && strings.IndexByte("=!<>:~", source[i]) >= 0 {
+				i++
+			}
+			op := source[start:i]
+			if !filterOperators[op] {
+				return nil, &FilterError{Offset: start, Message: fmt.Sprintf("invalid operator %q", op)}
+			}
+			tokens = append(tokens, filterToken{kind: tokOperator, text: op, start: start, end: i})
+		case isFilterDigit(c) || (c == '-' && i+1 < len(source) && isFilterDigit(source[i+1])):
+			start := i
+			i++
+			for i < len(source) && (isFilterDigit(source[i]) || source[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, filterToken{kind: tokNumber, text: source[start:i], start: start, end: i})
+		case isFilterIdentStart(c):
+			start := i
+			for i < len(source) && isFilterIdentPart(source[i]) {
+				i++
+			}
+			word := source[start:i]
+			kind := tokIdent
+			switch word {
+			case "AND":
+				kind = tokAnd
+			case "OR":
+				kind = tokOr
+			case "NOT":
+				kind = tokNot
+			}
+			tokens = append(tokens, filterToken{kind: kind, text: word, start: start, end: i})
+		default:
+			return nil, &FilterError{Offset: i, Message: fmt.Sprintf("unexpected character %q", string(c))}
+		}
+	}
+	tokens = append(tokens, filterToken{kind: tokEOF, start: len(source), end: len(source)})
+	return tokens, nil
+}
+
+func isFilterDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isFilterIdentStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+}
+
+func isFilterIdentPart(c byte) bool {
+	return isFilterIdentStart(c) || isFilterDigit(c) || c == '.' || c == '-' || c == '/'
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *filterParser) parseAnd() (FilterNode, error) {
+	first, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	terms := []FilterNode{first}
+	for p.peek().kind == tokAnd {
+		p.next()
+		term, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 1 {
+		return first, nil
+	}
+	return &FilterAnd{FilterSpan: spanOf(terms), Terms: terms}, nil
+}
+
+func (p *filterParser) parseOr() (FilterNode, error) {
+	first, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	terms := []FilterNode{first}
+	for p.peek().kind == tokOr {
+		p.next()
+		term, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 1 {
+		return first, nil
+	}
+	return &FilterOr{FilterSpan: spanOf(terms), Terms: terms}, nil
+}
+
+func (p *filterParser) parseUnary() (FilterNode, error) {
+	if tok := p.peek(); tok.kind == tokNot {
+		p.next()
+		term, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &FilterNot{FilterSpan: FilterSpan{Start: tok.start, End: term.Span().End}, Term: term}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *filterParser) parsePrimary() (FilterNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokLParen:
+		expr, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		closing := p.next()
+		if closing.kind != tokRParen {
+			return nil, &FilterError{Offset: tok.start, Message: "unbalanced parenthesis"}
+		}
+		return &FilterGroup{FilterSpan: FilterSpan{Start: tok.start, End: closing.end}, Expr: expr}, nil
+	case tokIdent:
+		return p.parseComparison(tok)
+	case tokEOF:
+		return nil, &FilterError{Offset: tok.start, Message: "expected a comparison"}
+	default:
+		return nil, &FilterError{Offset: tok.start, Message: fmt.Sprintf("expected a selector, found %s", tok.describe())}
+	}
+}
+
+func (p *filterParser) parseComparison(selector filterToken) (FilterNode, error) {
+	if err := lowercaseKeyword(selector); err != nil {
+		return nil, err
+	}
+	op := p.next()
+	if op.kind != tokOperator {
+		return nil, &FilterError{Offset: op.start, Message: fmt.Sprintf("expected an operator after %q, found %s", selector.text, op.describe())}
+	}
+	value, end, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return &FilterComparison{
+		FilterSpan: FilterSpan{Start: selector.start, End: end},
+		Selector:   selector.text,
+		Operator:   op.text,
+		Value:      value,
+	}, nil
+}
+
+func (p *filterParser) parseValue() (FilterValue, int, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokString:
+		return FilterValue{Kind: FilterString, Literal: tok.text}, tok.end, nil
+	case tokNumber:
+		return FilterValue{Kind: FilterNumber, Literal: tok.text}, tok.end, nil
+	case tokIdent:
+		if p.peek().kind != tokLParen {
+			return FilterValue{Kind: FilterIdent, Literal: tok.text}, tok.end, nil
+		}
+		open := p.next()
+		value := FilterValue{Kind: FilterFunction, Function: tok.text}
+		if p.peek().kind == tokRParen {
+			return value, p.next().end, nil
+		}
+		for {
+			arg := p.next()
+			if arg.kind != tokString && arg.kind != tokNumber && arg.kind != tokIdent {
+				return FilterValue{}, 0, &FilterError{Offset: arg.start, Message: fmt.Sprintf("expected a function argument, found %s", arg.describe())}
+			}
+			value.Args = append(value.Args, arg.text)
+			sep := p.next()
+			if sep.kind == tokRParen {
+				return value, sep.end, nil
+			}
+			if sep.kind != tokComma {
+				return FilterValue{}, 0, &FilterError{Offset: open.start, Message: "unbalanced parenthesis"}
+			}
+		}
+	default:
+		return FilterValue{}, 0, &FilterError{Offset: tok.start, Message: fmt.Sprintf("expected a value, found %s", tok.describe())}
+	}
+}
+
+func lowercaseKeyword(tok filterToken) error {
+	if tok.kind != tokIdent {
+		return nil
+	}
+	switch tok.text {
+	case "and", "or", "not", "And", "Or", "Not":
+		return &FilterError{Offset: tok.start, Message: fmt.Sprintf("operator %q must be uppercase", tok.text)}
+	}
+	return nil
+}
+
+func spanOf(terms []FilterNode) FilterSpan {
+	return FilterSpan{Start: terms[0].Span().Start, End: terms[len(terms)-1].Span().End}
+}
@@ -0,0 +1,97 @@
+package spec
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFilterPrecedence(t *testing.T) {
+	f, err := ParseFilter(`resource.type = "cloud_run_revision" AND metric.label.response_code = "200" OR metric.label.response_code = "204"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	terms := f.Conjuncts()
+	if len(terms) != 2 {
+		t.Fatalf("expected 2 conjuncts, got %d", len(terms))
+	}
+	if _, ok := terms[1].(*FilterOr); !ok {
+		t.Fatalf("expected OR to bind tighter than AND, got %T", terms[1])
+	}
+	if got := f.Text(terms[1]); got != `metric.label.response_code = "200" OR metric.label.response_code = "204"` {
+		t.Fatalf("unexpected conjunct text %q", got)
+	}
+	if value, ok := f.Equality("resource.type"); !ok || value != "cloud_run_revision" {
+		t.Fatalf("expected resource.type equality, got %q (ok=%v)", value, ok)
+	}
+}
+
+func TestParseFilterFunctionsAndGroups(t *testing.T) {
+	f, err := ParseFilter(`NOT (metric.label.path = starts_with("/healthz")) AND metric.label.response_code_class = one_of("2xx", "3xx") AND metric.label.code < 500`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cmps := f.Comparisons()
+	if len(cmps) != 3 {
+		t.Fatalf("expected 3 comparisons, got %d", len(cmps))
+	}
+	if cmps[1].Value.Function != "one_of" || len(cmps[1].Value.Args) != 2 {
+		t.Fatalf("unexpected function value %+v", cmps[1].Value)
+	}
+	if cmps[2].Operator != "<" || cmps[2].Value.Kind != FilterNumber {
+		t.Fatalf("unexpected numeric comparison %+v", cmps[2])
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	cases := []struct {
+		filter string
+		want   string
+	}{
+		{`metric.label.code = "200`, "unbalanced quote"},
+		{`metric.label.code == "200"`, `invalid operator "=="`},
+		{`(metric.label.code = "200"`, "unbalanced parenthesis"},
+		{`metric.label.code = "200")`, "unbalanced parenthesis"},
+		{`metric.label.code = "200" and resource.type = "x"`, "must be uppercase"},
+		{`metric.label.code "200"`, "expected an operator"},
+		{`metric.label.code = AND`, "expected a value"},
+		{``, "filter is empty"},
+	}
+	for _, tc := range cases {
+		_, err := ParseFilter(tc.filter)
+		if err == nil {
+			t.Fatalf("ParseFilter(%q) expected error", tc.filter)
+		}
+		if !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("ParseFilter(%q) error %q, want %q", tc.filter, err.Error(), tc.want)
+		}
+	}
+}
+
+func TestValidateFilterConflicts(t *testing.T) {
+	template := ServiceTemplate{Name: "cloud-run", ResourceType: "cloud_run_revision"}
+	cases := []struct {
+		name   string
+		filter string
+		code   string
+	}{
+		{"ok", `resource.type = "cloud_run_revision" AND metric.label.response_code_class = "2xx"`, ""},
+		{"missing-resource", `metric.label.response_code_class = "2xx"`, CodeResourceType},
+		{"conflicting-resource", `resource.type = "k8s_container"`, CodeResourceType},
+		{"conflicting-metric", `resource.type = "cloud_run_revision" AND metric.type = "run.googleapis.com/request_latencies"`, CodeFilterConflict},
+		{"syntax", `resource.type = "cloud_run_revision`, CodeInvalidFilter},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validateFilter("good.filter", tc.filter, "run.googleapis.com/request_count", template)
+			if tc.code == "" {
+				if len(errs) != 0 {
+					t.Fatalf("expected ok, got %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Code != tc.code {
+				t.Fatalf("expected %s, got %v", tc.code, errs)
+			}
+		})
+	}
+}
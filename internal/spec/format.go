@@ -0,0 +1,284 @@
+package spec
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format rewrites a spec document into canonical form while keeping
+// comments: keys follow the order of the spec types, SLOs are sorted by name,
+// windows and thresholds are normalized, lists use block style, and fields
+// that hold their default value are dropped. The document must parse with
+// Parse; semantic validation is not required.
+func Format(data []byte) ([]byte, error) {
+	if _, err := Parse(data); err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("spec is empty")
+	}
+	formatNode(doc.Content[0], wireType(apiVersionOf(doc.Content[0])), "")
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return separateSections(buf.Bytes()), nil
+}
+
+// Marshal encodes a spec in the same canonical form as Format, using the wire
+// format of s.APIVersion.
+func Marshal(s Spec) ([]byte, error) {
+	var wire any = s
+	if s.APIVersion == APIVersionV2 {
+		wire = toV2(s)
+	}
+	data, err := yaml.Marshal(wire)
+	if err != nil {
+		return nil, err
+	}
+	return Format(data)
+}
+
+func wireType(version string) reflect.Type {
+	if version == APIVersionV2 {
+		return reflect.TypeOf(specV2{})
+	}
+	return reflect.TypeOf(Spec{})
+}
+
+// FormatWindow renders a duration with the largest window unit that divides
+// it evenly, for example 720h as 30d and 168h as 1w.
+func FormatWindow(d time.Duration) (string, bool) {
+	units := []struct {
+		suffix string
+		size   time.Duration
+	}{
+		{"w", 7 * 24 * time.Hour},
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	}
+	if d <= 0 {
+		return "", false
+	}
+	for _, unit := range units {
+		if d%unit.size == 0 {
+			return fmt.Sprintf("%d%s", d/unit.size, unit.suffix), true
+		}
+	}
+	return "", false
+}
+
+// FormatThreshold renders a latency threshold as milliseconds below one
+// second, whole seconds when exact, and Go duration syntax otherwise.
+func FormatThreshold(d time.Duration) string {
+	switch {
+	case d%time.Millisecond == 0 && d < time.Second:
+		return fmt.Sprintf("%dms", d/time.Millisecond)
+	case d%time.Second == 0:
+		return fmt.Sprintf("%ds", d/time.Second)
+	default:
+		return d.String()
+	}
+}
+
+func formatNode(node *yaml.Node, typ reflect.Type, path string) (drop bool) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if node.Kind == yaml.AliasNode {
+		return false
+	}
+	if node.Kind == yaml.ScalarNode {
+		return formatScalar(node, path)
+	}
+	node.Style &^= yaml.FlowStyle
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return false
+		}
+		fields := yamlFields(typ)
+		type pair struct {
+			key, value *yaml.Node
+			index      []int
+		}
+		var pairs []pair
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				continue
+			}
+			key.Style = 0
+			if formatNode(value, field.Type, joinPath(path, key.Value)) {
+				continue
+			}
+			pairs = append(pairs, pair{key: key, value: value, index: field.Index})
+		}
+		sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].index[0] < pairs[j].index[0] })
+		node.Content = node.Content[:0]
+		for _, p := range pairs {
+			node.Content = append(node.Content, p.key, p.value)
+		}
+		if isDefaultAlert(node, path) {
+			return true
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return false
+		}
+		items := node.Content[:0]
+		for _, item := range node.Content {
+			if !formatNode(item, typ.Elem(), path+"[]") {
+				items = append(items, item)
+			}
+		}
+		node.Content = items
+		switch path {
+		case "slos":
+			sort.SliceStable(node.Content, func(i, j int) bool {
+				return mappingValue(node.Content[i], "name") < mappingValue(node.Content[j], "name")
+			})
+		case "slos[].alerts":
+			sort.SliceStable(node.Content, func(i, j int) bool {
+				return mappingValue(node.Content[i], "tier") < mappingValue(node.Content[j], "tier")
+			})
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return false
+		}
+		type pair struct{ key, value *yaml.Node }
+		var pairs []pair
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			key.Style = 0
+			formatScalar(value, joinPath(path, key.Value))
+			pairs = append(pairs, pair{key, value})
+		}
+		sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].key.Value < pairs[j].key.Value })
+		node.Content = node.Content[:0]
+		for _, p := range pairs {
+			node.Content = append(node.Content, p.key, p.value)
+		}
+	}
+	return len(node.Content) == 0 && node.HeadComment == "" && node.FootComment == ""
+}
+
+func formatScalar(node *yaml.Node, path string) (drop bool) {
+	if node.Tag == "!!null" || (node.Tag == "!!str" && node.Value == "") {
+		return !strings.HasPrefix(path, "metadata.labels.")
+	}
+	if node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) == 0 || !strings.Contains(node.Value, "\n") {
+		node.Style = 0
+	}
+	switch {
+	case path == "slos[].period":
+		return strings.TrimSpace(node.Value) == "rolling"
+	case path == "slos[].window" || strings.HasSuffix(path, ".windows[]"):
+		if d, err := ParseWindow(node.Value); err == nil {
+			if window, ok := FormatWindow(d); ok {
+				node.Value = window
+			}
+		}
+	case path == "slos[].sli.threshold" || path == "slos[].sli.latency.threshold":
+		if d, err := time.ParseDuration(strings.TrimSpace(node.Value)); err == nil && d > 0 {
+			node.Value = FormatThreshold(d)
+		}
+	case node.Tag == "!!float" || node.Tag == "!!int":
+		if value, err := strconv.ParseFloat(node.Value, 64); err == nil {
+			node.Value = strconv.FormatFloat(value, 'f', -1, 64)
+			node.Tag = "!!float"
+			if !strings.ContainsAny(node.Value, ".e") {
+				node.Tag = "!!int"
+			}
+		}
+	}
+	return false
+}
+
+// isDefaultAlert reports whether an SLO alerting override repeats the
+// built-in fast or slow burn alert.
+func isDefaultAlert(node *yaml.Node, path string) bool {
+	tier := ""
+	switch path {
+	case "slos[].alerting.fast":
+		tier = TierFast
+	case "slos[].alerting.slow":
+		tier = TierSlow
+	case "slos[].alerts[]":
+		tier = mappingValue(node, "tier")
+	default:
+		return false
+	}
+	want, ok := map[string]AlertOverride{TierFast: DefaultFastBurn, TierSlow: DefaultSlowBurn}[tier]
+	if !ok {
+		return false
+	}
+	var got AlertOverride
+	if err := node.Decode(&got); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(got, want)
+}
+
+func mappingValue(node *yaml.Node, key string) string {
+	if node.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// separateSections puts a blank line after each top-level block and between
+// SLOs so formatted specs keep the layout people write by hand. Comments
+// directly above a key stay attached to it.
+func separateSections(data []byte) []byte {
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	var out []string
+	for _, line := range lines {
+		if (len(line) > 0 && line[0] != ' ' && line[0] != '#') || strings.HasPrefix(line, "  - ") {
+			insert := len(out)
+			for insert > 0 && isCommentAt(out[insert-1], indentOf(line)) {
+				insert--
+			}
+			if insert > 0 && out[insert-1] != "" && indentOf(out[insert-1]) > indentOf(line) {
+				out = append(out[:insert], append([]string{""}, out[insert:]...)...)
+			}
+		}
+		out = append(out, line)
+	}
+	return []byte(strings.Join(out, "\n") + "\n")
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func isCommentAt(line string, indent int) bool {
+	return indentOf(line) == indent && strings.HasPrefix(strings.TrimSpace(line), "#")
+}
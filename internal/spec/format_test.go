@@ -0,0 +1,147 @@
+package spec
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const unformattedSpecYAML = `# service comment
+apiVersion: "margin/v1"
+kind: ServiceSLO
+metadata:
+  project: demo # the project
+  service: cloud-run
+  name: checkout-api
+  labels: {team: payments, env: prod}
+alerting:
+  burnRateResourceType: ""
+slos:
+  # latency first
+  - name: latency
+    window: 720h
+    objective: 99.00
+    period: rolling
+    sli:
+      threshold: 0.5s
+      type: latency
+      metric: run.googleapis.com/request_latencies
+      filter: 'resource.type="cloud_run_revision"'
+    alerting:
+      fast: {windows: [5m, 1h], burnRate: 14.4}
+      slow: {windows: [60m, 12h], burnRate: 3}
+  - name: availability
+    objective: 99.9
+    window: 168h
+    sli:
+      type: request-based
+      good:
+        metric: run.googleapis.com/request_count
+        filter: resource.type="cloud_run_revision" AND metric.label.response_code_class="2xx"
+      total:
+        metric: run.googleapis.com/request_count
+`
+
+const formattedSpecYAML = `# service comment
+apiVersion: margin/v1
+kind: ServiceSLO
+metadata:
+  name: checkout-api
+  service: cloud-run
+  project: demo # the project
+  labels:
+    env: prod
+    team: payments
+
+slos:
+  - name: availability
+    objective: 99.9
+    window: 1w
+    sli:
+      type: request-based
+      good:
+        metric: run.googleapis.com/request_count
+        filter: resource.type="cloud_run_revision" AND metric.label.response_code_class="2xx"
+      total:
+        metric: run.googleapis.com/request_count
+
+  # latency first
+  - name: latency
+    objective: 99
+    window: 30d
+    sli:
+      type: latency
+      metric: run.googleapis.com/request_latencies
+      filter: resource.type="cloud_run_revision"
+      threshold: 500ms
+    alerting:
+      slow:
+        windows:
+          - 1h
+          - 12h
+        burnRate: 3
+`
+
+func TestFormatCanonicalizes(t *testing.T) {
+	got, err := Format([]byte(unformattedSpecYAML))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != formattedSpecYAML {
+		t.Fatalf("format mismatch\n--- got ---\n%s\n--- want ---\n%s", got, formattedSpecYAML)
+	}
+	again, err := Format(got)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(again) != string(got) {
+		t.Fatalf("format is not idempotent\n%s", again)
+	}
+}
+
+func TestFormatRejectsUnknownFields(t *testing.T) {
+	doc := strings.Replace(validSpecYAML, "objective: 99.9", "objetive: 99.9", 1)
+	if _, err := Format([]byte(doc)); err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+}
+
+func TestMarshalDropsDefaults(t *testing.T) {
+	s, err := Parse([]byte(validSpecYAML))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	data, err := Marshal(s)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	for _, unwanted := range []string{"period", "alerting", "lint", "labels", "runbook", `""`} {
+		if strings.Contains(string(data), unwanted) {
+			t.Fatalf("expected %q to be dropped:\n%s", unwanted, data)
+		}
+	}
+}
+
+func TestFormatWindowAndThreshold(t *testing.T) {
+	windows := map[time.Duration]string{
+		720 * time.Hour:  "30d",
+		168 * time.Hour:  "1w",
+		90 * time.Minute: "90m",
+		36 * time.Hour:   "36h",
+	}
+	for d, want := range windows {
+		if got, ok := FormatWindow(d); !ok || got != want {
+			t.Fatalf("FormatWindow(%s) = %q, want %q", d, got, want)
+		}
+	}
+	thresholds := map[time.Duration]string{
+		500 * time.Millisecond:  "500ms",
+		2 * time.Second:         "2s",
+		1500 * time.Millisecond: "1.5s",
+	}
+	for d, want := range thresholds {
+		if got := FormatThreshold(d); got != want {
+			t.Fatalf("FormatThreshold(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
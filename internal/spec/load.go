@@ -1,20 +1,235 @@
 package spec
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
+var yamlLineRe = regexp.MustCompile(`line (\d+): `)
+
 func Load(path string) (Spec, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return Spec{}, fmt.Errorf("read spec: %w", err)
 	}
+	return Parse(data)
+}
+
+// Parse strictly decodes a spec document. Structural problems such as YAML
+// syntax errors, unknown fields, and type mismatches are returned as
+// ValidationErrors so callers can report them with line numbers.
+func Parse(data []byte) (Spec, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return Spec{}, ValidationErrors{yamlError("", CodeSyntax, err.Error())}
+	}
+	var doc *yaml.Node
+	if len(root.Content) > 0 {
+		doc = root.Content[0]
+	}
+
+	if apiVersionOf(doc) == APIVersionV2 {
+		var wire specV2
+		positions, err := decodeStrict(data, doc, &wire)
+		if err != nil {
+			return Spec{}, err
+		}
+		s, paths, errs := fromV2(wire)
+		s.positions = translatePositions(positions, paths)
+		if len(errs) > 0 {
+			return Spec{}, s.locate(errs)
+		}
+		return s, nil
+	}
+
 	var s Spec
-	if err := yaml.Unmarshal(data, &s); err != nil {
-		return Spec{}, fmt.Errorf("parse spec: %w", err)
+	positions, err := decodeStrict(data, doc, &s)
+	if err != nil {
+		return Spec{}, err
 	}
+	s.positions = positions
 	return s, nil
 }
+
+// decodeStrict records the position of every key in doc and decodes data
+// into out, rejecting unknown fields and type mismatches.
+func decodeStrict(data []byte, doc *yaml.Node, out any) (map[string]Position, error) {
+	positions := map[string]Position{}
+	var errs ValidationErrors
+	if doc != nil {
+		walkNode(doc, reflect.TypeOf(out).Elem(), "", positions, &errs)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, ValidationErrors{yamlError("", CodeSyntax, err.Error())}
+		}
+		for _, msg := range typeErr.Errors {
+			item := yamlError("", CodeInvalidType, msg)
+			item.Path = pathAtLine(positions, item.Line)
+			errs = append(errs, item)
+		}
+		return nil, errs
+	}
+	return positions, nil
+}
+
+func yamlError(path, code, message string) ValidationError {
+	item := newError(path, code, strings.TrimPrefix(message, "yaml: "))
+	if match := yamlLineRe.FindStringSubmatch(message); match != nil {
+		item.Line, _ = strconv.Atoi(match[1])
+	}
+	return item
+}
+
+func pathAtLine(positions map[string]Position, line int) string {
+	best := ""
+	for path, pos := range positions {
+		if pos.Line != line {
+			continue
+		}
+		if best == "" || len(path) > len(best) || (len(path) == len(best) && path < best) {
+			best = path
+		}
+	}
+	return best
+}
+
+func walkNode(node *yaml.Node, typ reflect.Type, path string, positions map[string]Position, errs *ValidationErrors) {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		fields := yamlFields(typ)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			childPath := joinPath(path, key.Value)
+			field, ok := fields[key.Value]
+			if !ok {
+				item := newError(childPath, CodeUnknownField, unknownFieldMessage(path, key.Value, fields))
+				item.Line, item.Column = key.Line, key.Column
+				*errs = append(*errs, item)
+				continue
+			}
+			positions[childPath] = Position{Line: key.Line, Column: key.Column}
+			walkNode(value, field.Type, childPath, positions, errs)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range node.Content {
+			itemPath := indexPath(path, i)
+			positions[itemPath] = Position{Line: item.Line, Column: item.Column}
+			walkNode(item, typ.Elem(), itemPath, positions, errs)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			positions[joinPath(path, key.Value)] = Position{Line: key.Line, Column: key.Column}
+		}
+	}
+}
+
+func yamlFields(typ reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := yamlFieldName(field)
+		if name == "" {
+			continue
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+func yamlFieldName(field reflect.StructField) string {
+	tag := field.Tag.Get("yaml")
+	if tag == "-" {
+		return ""
+	}
+	name := strings.Split(tag, ",")[0]
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name
+}
+
+func unknownFieldMessage(path, name string, fields map[string]reflect.StructField) string {
+	location := path
+	if location == "" {
+		location = "spec"
+	}
+	msg := fmt.Sprintf("%s: unknown field %q", location, name)
+	if suggestion := closestField(name, fields); suggestion != "" {
+		msg = fmt.Sprintf("%s (did you mean %q?)", msg, suggestion)
+	}
+	return msg
+}
+
+func closestField(name string, fields map[string]reflect.StructField) string {
+	var candidates []string
+	for candidate := range fields {
+		candidates = append(candidates, candidate)
+	}
+	sort.Strings(candidates)
+	best := ""
+	bestDistance := 3
+	for _, candidate := range candidates {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(candidate)); d < bestDistance {
+			best = candidate
+			bestDistance = d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,89 @@
+package spec
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const validSpecYAML = `apiVersion: margin/v1
+kind: ServiceSLO
+metadata:
+  name: checkout-api
+  service: cloud-run
+  project: demo
+slos:
+  - name: availability
+    objective: 99.9
+    window: 30d
+    sli:
+      type: request-based
+      good:
+        metric: run.googleapis.com/request_count
+        filter: resource.type="cloud_run_revision" AND metric.label.response_code_class="2xx"
+      total:
+        metric: run.googleapis.com/request_count
+`
+
+func TestParseValidSpec(t *testing.T) {
+	s, err := Parse([]byte(validSpecYAML))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("expected valid spec, got %v", err)
+	}
+	pos, ok := s.Position("slos[0].sli.good.filter")
+	if !ok || pos.Line != 15 || pos.Column != 9 {
+		t.Fatalf("unexpected position %+v (ok=%v)", pos, ok)
+	}
+}
+
+func TestParseRejectsUnknownFields(t *testing.T) {
+	doc := strings.Replace(validSpecYAML, "objective: 99.9", "objetive: 99.9", 1)
+	_, err := Parse([]byte(doc))
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", errs)
+	}
+	got := errs[0]
+	if got.Code != CodeUnknownField || got.Path != "slos[0].objetive" || got.Line != 9 || got.Column != 5 {
+		t.Fatalf("unexpected error %+v", got)
+	}
+	if !strings.Contains(got.Message, `did you mean "objective"`) {
+		t.Fatalf("expected suggestion, got %q", got.Message)
+	}
+}
+
+func TestParseReportsTypeErrors(t *testing.T) {
+	doc := strings.Replace(validSpecYAML, "objective: 99.9", "objective: high", 1)
+	_, err := Parse([]byte(doc))
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	if errs[0].Code != CodeInvalidType || errs[0].Line != 9 || errs[0].Path != "slos[0].objective" {
+		t.Fatalf("unexpected error %+v", errs[0])
+	}
+}
+
+func TestCheckAnnotatesPositions(t *testing.T) {
+	doc := strings.Replace(validSpecYAML, "window: 30d", "window: 30x", 1)
+	s, err := Parse([]byte(doc))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	errs := s.Check()
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", errs)
+	}
+	if errs[0].Path != "slos[0].window" || errs[0].Line != 10 || errs[0].Code != CodeInvalidWindow {
+		t.Fatalf("unexpected error %+v", errs[0])
+	}
+	if errs[0].Severity != SeverityError {
+		t.Fatalf("expected error severity, got %q", errs[0].Severity)
+	}
+}
@@ -0,0 +1,138 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Migrate rewrites a spec document to the target apiVersion and returns it in
+// margin fmt form. It edits the YAML nodes rather than re-encoding the hub
+// type, so comments move along with the fields they describe.
+func Migrate(data []byte, target string) ([]byte, error) {
+	if _, err := Parse(data); err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("spec is empty")
+	}
+	root := doc.Content[0]
+	from := apiVersionOf(root)
+	switch {
+	case from == target:
+	case from == APIVersionV1 && target == APIVersionV2:
+		migrateV1ToV2(root)
+	default:
+		return nil, fmt.Errorf("cannot migrate from %q to %q", from, target)
+	}
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, err
+	}
+	return Format(retargetModeline(out, from, target))
+}
+
+// retargetModeline points a yaml-language-server modeline that references
+// the schema of the old version at the schema of the new one.
+func retargetModeline(data []byte, from, target string) []byte {
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "# yaml-language-server:") {
+			lines[i] = strings.ReplaceAll(line, SchemaFile(from), SchemaFile(target))
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+func migrateV1ToV2(root *yaml.Node) {
+	if value := mappingEntry(root, "apiVersion"); value != nil {
+		value.Value = APIVersionV2
+		value.Style = 0
+	}
+	slos := mappingEntry(root, "slos")
+	if slos == nil || slos.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, slo := range slos.Content {
+		if slo.Kind != yaml.MappingNode {
+			continue
+		}
+		if sli := mappingEntry(slo, "sli"); sli != nil && sli.Kind == yaml.MappingNode {
+			migrateSLIToV2(sli)
+		}
+		migrateAlertingToV2(slo)
+	}
+}
+
+// migrateSLIToV2 moves the flat v1 SLI fields under requestBased or latency
+// according to the removed type field.
+func migrateSLIToV2(sli *yaml.Node) {
+	typeKey, typeValue := removeMappingEntry(sli, "type")
+	wrapper := "requestBased"
+	if typeValue != nil && typeValue.Value == "latency" {
+		wrapper = "latency"
+	}
+	body := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: sli.Content}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: wrapper}
+	if typeKey != nil {
+		key.HeadComment = typeKey.HeadComment
+		key.LineComment = typeValue.LineComment
+	}
+	sli.Content = []*yaml.Node{key, body}
+}
+
+// migrateAlertingToV2 turns alerting.fast and alerting.slow into an alerts
+// list with one entry per tier.
+func migrateAlertingToV2(slo *yaml.Node) {
+	alertingKey, alerting := removeMappingEntry(slo, "alerting")
+	if alerting == nil || alerting.Kind != yaml.MappingNode {
+		return
+	}
+	alerts := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for i := 0; i+1 < len(alerting.Content); i += 2 {
+		tierKey, tier := alerting.Content[i], alerting.Content[i+1]
+		if tier.Kind != yaml.MappingNode {
+			continue
+		}
+		item := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", HeadComment: tierKey.HeadComment}
+		item.Content = append(item.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "tier"},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: tierKey.Value, LineComment: tierKey.LineComment},
+		)
+		item.Content = append(item.Content, tier.Content...)
+		alerts.Content = append(alerts.Content, item)
+	}
+	if len(alerts.Content) == 0 {
+		return
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "alerts", HeadComment: alertingKey.HeadComment}
+	slo.Content = append(slo.Content, key, alerts)
+}
+
+func mappingEntry(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func removeMappingEntry(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			k, v := node.Content[i], node.Content[i+1]
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return k, v
+		}
+	}
+	return nil, nil
+}
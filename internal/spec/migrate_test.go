@@ -0,0 +1,119 @@
+package spec
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const migrateSpecYAML = `# yaml-language-server: $schema=../schema/margin-v1.schema.json
+apiVersion: margin/v1
+kind: ServiceSLO
+metadata:
+  name: checkout-api
+  service: cloud-run
+  project: demo
+slos:
+  - name: availability
+    objective: 99.9
+    window: 30d
+    sli:
+      # counts 2xx as good
+      type: request-based
+      good:
+        metric: run.googleapis.com/request_count
+        filter: resource.type="cloud_run_revision" AND metric.label.response_code_class="2xx"
+      total:
+        metric: run.googleapis.com/request_count # all requests
+    # page sooner for checkout
+    alerting:
+      fast:
+        windows: [2m, 30m]
+        burnRate: 20
+  - name: latency
+    objective: 99
+    window: 30d
+    sli:
+      type: latency
+      metric: run.googleapis.com/request_latencies
+      filter: resource.type="cloud_run_revision"
+      threshold: 500ms
+`
+
+const migratedSpecYAML = `# yaml-language-server: $schema=../schema/margin-v2.schema.json
+apiVersion: margin/v2
+kind: ServiceSLO
+metadata:
+  name: checkout-api
+  service: cloud-run
+  project: demo
+
+slos:
+  - name: availability
+    objective: 99.9
+    window: 30d
+    sli:
+      # counts 2xx as good
+      requestBased:
+        good:
+          metric: run.googleapis.com/request_count
+          filter: resource.type="cloud_run_revision" AND metric.label.response_code_class="2xx"
+        total:
+          metric: run.googleapis.com/request_count # all requests
+    # page sooner for checkout
+    alerts:
+      - tier: fast
+        windows:
+          - 2m
+          - 30m
+        burnRate: 20
+
+  - name: latency
+    objective: 99
+    window: 30d
+    sli:
+      latency:
+        metric: run.googleapis.com/request_latencies
+        filter: resource.type="cloud_run_revision"
+        threshold: 500ms
+`
+
+func TestMigrateV1ToV2(t *testing.T) {
+	got, err := Migrate([]byte(migrateSpecYAML), APIVersionV2)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if string(got) != migratedSpecYAML {
+		t.Fatalf("unexpected migration:\n%s", got)
+	}
+	before, err := Parse([]byte(migrateSpecYAML))
+	if err != nil {
+		t.Fatalf("parse v1: %v", err)
+	}
+	after, err := Parse(got)
+	if err != nil {
+		t.Fatalf("parse v2: %v", err)
+	}
+	before.APIVersion = APIVersionV2
+	before.positions, after.positions = nil, nil
+	if !reflect.DeepEqual(before, after) {
+		t.Fatalf("migration changed the spec:\nbefore %+v\nafter  %+v", before, after)
+	}
+}
+
+func TestMigrateLatestIsFormatOnly(t *testing.T) {
+	got, err := Migrate([]byte(migratedSpecYAML), APIVersionV2)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if string(got) != migratedSpecYAML {
+		t.Fatalf("expected no changes, got:\n%s", got)
+	}
+}
+
+func TestMigrateRejectsDowngrade(t *testing.T) {
+	_, err := Migrate([]byte(migratedSpecYAML), APIVersionV1)
+	if err == nil || !strings.Contains(err.Error(), "cannot migrate") {
+		t.Fatalf("expected downgrade error, got %v", err)
+	}
+}
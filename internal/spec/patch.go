@@ -0,0 +1,76 @@
+package spec
+
+import (
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SLOPatch changes one SLO's objective and, for latency SLIs, its
+// threshold. Zero values leave a field alone.
+type SLOPatch struct {
+	Objective float64
+	Threshold string
+}
+
+// PatchSLOs applies patches, keyed by SLO name, to a spec document and
+// returns it in margin fmt form. Like Migrate it edits the YAML nodes, so
+// comments are kept.
+func PatchSLOs(data []byte, patches map[string]SLOPatch) ([]byte, error) {
+	if _, err := Parse(data); err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("spec is empty")
+	}
+	slos := mappingEntry(doc.Content[0], "slos")
+	if slos == nil || slos.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("spec has no slos")
+	}
+	for _, slo := range slos.Content {
+		name := mappingEntry(slo, "name")
+		if name == nil {
+			continue
+		}
+		patch, ok := patches[name.Value]
+		if !ok {
+			continue
+		}
+		if patch.Objective > 0 {
+			setScalar(slo, "objective", strconv.FormatFloat(patch.Objective, 'f', -1, 64), "!!float")
+		}
+		if patch.Threshold != "" {
+			sli := mappingEntry(slo, "sli")
+			if sli == nil {
+				return nil, fmt.Errorf("SLO %s has no sli", name.Value)
+			}
+			if latency := mappingEntry(sli, "latency"); latency != nil {
+				sli = latency
+			}
+			setScalar(sli, "threshold", patch.Threshold, "!!str")
+		}
+	}
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, err
+	}
+	return Format(out)
+}
+
+func setScalar(node *yaml.Node, key, value, tag string) {
+	if existing := mappingEntry(node, key); existing != nil {
+		existing.Value = value
+		existing.Tag = tag
+		existing.Style = 0
+		return
+	}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value},
+	)
+}
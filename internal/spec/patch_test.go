@@ -0,0 +1,36 @@
+package spec
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPatchSLOs(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   string
+	}{
+		{"v1", migrateSpecYAML},
+		{"v2", migratedSpecYAML},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := PatchSLOs([]byte(tc.in), map[string]SLOPatch{
+				"availability": {Objective: 99.5},
+				"latency":      {Objective: 99.9, Threshold: "300ms"},
+			})
+			if err != nil {
+				t.Fatalf("patch: %v", err)
+			}
+			patched, err := Parse(out)
+			if err != nil {
+				t.Fatalf("parse patched spec: %v\n%s", err, out)
+			}
+			if patched.SLOs[0].Objective != 99.5 || patched.SLOs[1].Objective != 99.9 || patched.SLOs[1].SLI.Threshold != "300ms" {
+				t.Fatalf("patches not applied:\n%s", out)
+			}
+			if !strings.Contains(string(out), "# counts 2xx as good") {
+				t.Fatalf("expected comments to be kept:\n%s", out)
+			}
+		})
+	}
+}
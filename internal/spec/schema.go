@@ -0,0 +1,251 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	SchemaDraft   = "http://json-schema.org/draft-07/schema#"
+	schemaBaseURL = "https://raw.githubusercontent.com/bayneri/margin/main/schema/"
+)
+
+// SchemaFile returns the file name of the shipped schema for an apiVersion,
+// for example margin-v2.schema.json.
+func SchemaFile(version string) string {
+	return strings.ReplaceAll(version, "/", "-") + ".schema.json"
+}
+
+// SchemaURL is the $id of the schema for an apiVersion.
+func SchemaURL(version string) string {
+	return schemaBaseURL + SchemaFile(version)
+}
+
+var durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+// schemaFields adds constraints and descriptions that the Go types cannot
+// express. Keys are spec paths with "[]" standing for any list index; the
+// version-specific maps below are layered on top.
+var schemaFields = map[string]map[string]any{
+	"kind": {"const": KindServiceSLO},
+	"metadata": {
+		"required": []string{"name", "service", "project"},
+	},
+	"metadata.name":    {"description": "Service name used in resource IDs and display names.", "minLength": 1},
+	"metadata.service": {"description": "Service template that decides metrics and resource.type."},
+	"metadata.project": {"description": "GCP project ID; can be overridden with --project.", "minLength": 1},
+	"metadata.labels":  {"description": "User labels applied to SLOs, alerts, and the dashboard."},
+	"metadata.runbook": {"description": "Runbook URL linked from alerts and the dashboard.", "pattern": `^https?://`},
+	"alerting.burnRateResourceType": {
+		"description": "resource.type used in burn-rate alert filters; must match the template when set.",
+		"pattern":     `^[a-z0-9_]+$`,
+	},
+	"slos": {"minItems": 1},
+	"slos[]": {
+		"required": []string{"name", "objective", "window", "sli"},
+	},
+	"slos[].name":          {"minLength": 1},
+	"slos[].objective":     {"description": "Target percentage, for example 99.9.", "exclusiveMinimum": 0, "exclusiveMaximum": 100},
+	"slos[].window":        {"description": "Compliance window such as 30d, 1w, or 6h (1m to 90d).", "pattern": windowRe.String()},
+	"slos[].period":        {"description": "rolling (default) or calendar.", "enum": []string{"rolling", "calendar"}},
+	"maintenance":          {"description": "Planned maintenance that margin analyze leaves out of the adjusted error budget."},
+	"maintenance[]":        {"required": []string{"start", "end"}},
+	"maintenance[].start":  {"description": "RFC3339 start time.", "format": "date-time"},
+	"maintenance[].end":    {"description": "RFC3339 end time, after start.", "format": "date-time"},
+	"maintenance[].reason": {"description": "Why the interval is excluded; shown in analyze reports."},
+	"lint":                 {"description": "Lint settings for margin lint."},
+	"lint.disable":         {"description": "Lint rule IDs to skip for this spec."},
+}
+
+var schemaFieldsV1 = map[string]map[string]any{
+	"apiVersion": {"const": APIVersionV1},
+	"slos[].sli": {
+		"required": []string{"type"},
+		"allOf": []any{
+			map[string]any{
+				"if":   map[string]any{"properties": map[string]any{"type": map[string]any{"const": "request-based"}}},
+				"then": map[string]any{"required": []string{"good", "total"}, "properties": map[string]any{"good": map[string]any{"required": []string{"filter"}}}},
+			},
+			map[string]any{
+				"if":   map[string]any{"properties": map[string]any{"type": map[string]any{"const": "latency"}}},
+				"then": map[string]any{"required": []string{"metric", "threshold"}},
+			},
+		},
+	},
+	"slos[].sli.type":      {"enum": []string{"request-based", "latency"}},
+	"slos[].sli.filter":    {"description": "Monitoring filter; must include the template resource.type."},
+	"slos[].sli.threshold": {"description": "Latency threshold such as 500ms or 1s.", "pattern": durationPattern},
+	"slos[].sli.good":      {"required": []string{"metric"}},
+	"slos[].sli.total":     {"required": []string{"metric"}},
+	"slos[].sli.good.filter": {
+		"description": "Monitoring filter selecting good events; must include the template resource.type.",
+	},
+	"slos[].alerting.fast.windows":  {"items": map[string]any{"pattern": windowRe.String()}, "minItems": 2, "maxItems": 2},
+	"slos[].alerting.slow.windows":  {"items": map[string]any{"pattern": windowRe.String()}, "minItems": 2, "maxItems": 2},
+	"slos[].alerting.fast":          {"required": []string{"burnRate"}},
+	"slos[].alerting.slow":          {"required": []string{"burnRate"}},
+	"slos[].alerting.fast.burnRate": {"minimum": 1},
+	"slos[].alerting.slow.burnRate": {"minimum": 1},
+}
+
+var schemaFieldsV2 = map[string]map[string]any{
+	"apiVersion": {"const": APIVersionV2},
+	"slos[].sli": {
+		"description":   "Exactly one SLI kind.",
+		"minProperties": 1,
+		"maxProperties": 1,
+	},
+	"slos[].sli.requestBased":       {"description": "Ratio of good to total events.", "required": []string{"good", "total"}},
+	"slos[].sli.requestBased.good":  {"required": []string{"metric", "filter"}},
+	"slos[].sli.requestBased.total": {"required": []string{"metric"}},
+	"slos[].sli.latency":            {"description": "Share of requests faster than threshold.", "required": []string{"metric", "threshold"}},
+	"slos[].sli.latency.filter":     {"description": "Monitoring filter; must include the template resource.type."},
+	"slos[].sli.latency.threshold":  {"description": "Latency threshold such as 500ms or 1s.", "pattern": durationPattern},
+	"slos[].sli.requestBased.good.filter": {
+		"description": "Monitoring filter selecting good events; must include the template resource.type.",
+	},
+	"slos[].alerts":            {"description": "Burn-rate alert overrides; tiers that are not listed use the defaults."},
+	"slos[].alerts[]":          {"required": []string{"tier", "burnRate"}},
+	"slos[].alerts[].tier":     {"enum": []string{TierFast, TierSlow}},
+	"slos[].alerts[].windows":  {"items": map[string]any{"pattern": windowRe.String()}, "minItems": 2, "maxItems": 2},
+	"slos[].alerts[].burnRate": {"minimum": 1},
+}
+
+// JSONSchema returns a JSON Schema (draft-07) for ServiceSLO specs of the
+// given apiVersion. The shape is derived from the wire types; service names
+// and metric types come from the template registry.
+func JSONSchema(version string) (map[string]any, error) {
+	overlay := map[string]map[string]any{}
+	for path, fields := range schemaFields {
+		overlay[path] = fields
+	}
+	var metricFields []string
+	switch version {
+	case APIVersionV1:
+		for path, fields := range schemaFieldsV1 {
+			overlay[path] = fields
+		}
+		metricFields = []string{"metric", "good", "total"}
+	case APIVersionV2:
+		for path, fields := range schemaFieldsV2 {
+			overlay[path] = fields
+		}
+		metricFields = []string{"latency", "requestBased"}
+	default:
+		return nil, fmt.Errorf("unsupported apiVersion %q (want one of %s)", version, strings.Join(SupportedAPIVersions(), ", "))
+	}
+
+	schema := typeSchema(wireType(version), "", overlay)
+	schema["$schema"] = SchemaDraft
+	schema["$id"] = SchemaURL(version)
+	schema["title"] = "margin ServiceSLO"
+	schema["required"] = []string{"apiVersion", "kind", "metadata", "slos"}
+
+	services := TemplateNames()
+	props := schema["properties"].(map[string]any)
+	props["metadata"].(map[string]any)["properties"].(map[string]any)["service"].(map[string]any)["enum"] = services
+
+	var conditions []any
+	for _, name := range services {
+		metrics := templateMetrics(serviceTemplates[name])
+		sli := map[string]any{}
+		for _, field := range metricFields {
+			sli[field] = metricSchema(version, field, metrics)
+		}
+		conditions = append(conditions, map[string]any{
+			"if": map[string]any{
+				"required":   []string{"metadata"},
+				"properties": map[string]any{"metadata": map[string]any{"properties": map[string]any{"service": map[string]any{"const": name}}}},
+			},
+			"then": map[string]any{
+				"properties": map[string]any{"slos": map[string]any{"items": map[string]any{"properties": map[string]any{
+					"sli": map[string]any{"properties": sli},
+				}}}},
+			},
+		})
+	}
+	schema["allOf"] = conditions
+	return schema, nil
+}
+
+// metricSchema limits the metrics an SLI field may reference to the ones the
+// service template defines.
+func metricSchema(version, field string, metrics []string) map[string]any {
+	enum := map[string]any{"enum": metrics}
+	if version == APIVersionV1 && field == "metric" {
+		return enum
+	}
+	metric := map[string]any{"properties": map[string]any{"metric": enum}}
+	if version == APIVersionV2 && field == "requestBased" {
+		return map[string]any{"properties": map[string]any{"good": metric, "total": metric}}
+	}
+	return metric
+}
+
+// MarshalJSONSchema renders JSONSchema as indented JSON with a trailing
+// newline.
+func MarshalJSONSchema(version string) ([]byte, error) {
+	schema, err := JSONSchema(version)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func typeSchema(typ reflect.Type, path string, overlay map[string]map[string]any) map[string]any {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	out := map[string]any{}
+	switch typ.Kind() {
+	case reflect.Struct:
+		props := map[string]any{}
+		for name, field := range yamlFields(typ) {
+			props[name] = typeSchema(field.Type, joinPath(path, name), overlay)
+		}
+		out["type"] = "object"
+		out["properties"] = props
+		out["additionalProperties"] = false
+	case reflect.Slice:
+		out["type"] = "array"
+		out["items"] = typeSchema(typ.Elem(), path+"[]", overlay)
+	case reflect.Map:
+		out["type"] = "object"
+		out["additionalProperties"] = typeSchema(typ.Elem(), path+".*", overlay)
+	case reflect.String:
+		out["type"] = "string"
+	case reflect.Float32, reflect.Float64:
+		out["type"] = "number"
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		out["type"] = "integer"
+	case reflect.Bool:
+		out["type"] = "boolean"
+	}
+	for key, value := range overlay[path] {
+		if key == "items" {
+			items := out["items"].(map[string]any)
+			for k, v := range value.(map[string]any) {
+				items[k] = v
+			}
+			continue
+		}
+		out[key] = value
+	}
+	return out
+}
+
+func templateMetrics(tpl ServiceTemplate) []string {
+	metrics := make([]string, 0, len(tpl.Metrics))
+	for name := range tpl.Metrics {
+		metrics = append(metrics, name)
+	}
+	sort.Strings(metrics)
+	return metrics
+}
@@ -0,0 +1,111 @@
+package spec
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestSchemaFileUpToDate(t *testing.T) {
+	for _, version := range SupportedAPIVersions() {
+		want, err := MarshalJSONSchema(version)
+		if err != nil {
+			t.Fatalf("marshal %s schema: %v", version, err)
+		}
+		file := "schema/" + SchemaFile(version)
+		got, err := os.ReadFile("../../" + file)
+		if err != nil {
+			t.Fatalf("read schema: %v", err)
+		}
+		if string(got) != string(want) {
+			t.Fatalf("%s is stale; run `go run ./cmd/margin schema --api-version %s --out %s`", file, version, file)
+		}
+	}
+}
+
+func TestSchemaRejectsUnknownVersion(t *testing.T) {
+	if _, err := JSONSchema("margin/v0"); err == nil {
+		t.Fatal("expected error for unknown apiVersion")
+	}
+}
+
+func TestSchemaFollowsSpecTypes(t *testing.T) {
+	data, err := MarshalJSONSchema(APIVersionV1)
+	if err != nil {
+		t.Fatalf("marshal schema: %v", err)
+	}
+	var schema struct {
+		Properties map[string]struct {
+			Properties map[string]struct {
+				Enum []string `json:"enum"`
+			} `json:"properties"`
+			Items struct {
+				Required []string `json:"required"`
+			} `json:"items"`
+		} `json:"properties"`
+		AllOf []json.RawMessage `json:"allOf"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("unmarshal schema: %v", err)
+	}
+	for _, field := range []string{"apiVersion", "kind", "metadata", "alerting", "slos", "lint"} {
+		if _, ok := schema.Properties[field]; !ok {
+			t.Fatalf("schema missing property %q", field)
+		}
+	}
+	services := schema.Properties["metadata"].Properties["service"].Enum
+	if len(services) != len(serviceTemplates) {
+		t.Fatalf("expected %d services, got %v", len(serviceTemplates), services)
+	}
+	if len(schema.AllOf) != len(serviceTemplates) {
+		t.Fatalf("expected one metric condition per service, got %d", len(schema.AllOf))
+	}
+	if got := schema.Properties["slos"].Items.Required; len(got) != 4 {
+		t.Fatalf("unexpected slo required fields %v", got)
+	}
+}
+
+func TestSchemaV2SLIShape(t *testing.T) {
+	data, err := MarshalJSONSchema(APIVersionV2)
+	if err != nil {
+		t.Fatalf("marshal schema: %v", err)
+	}
+	var schema struct {
+		ID         string `json:"$id"`
+		Properties struct {
+			SLOs struct {
+				Items struct {
+					Properties map[string]struct {
+						Properties    map[string]json.RawMessage `json:"properties"`
+						MaxProperties int                        `json:"maxProperties"`
+					} `json:"properties"`
+				} `json:"items"`
+			} `json:"slos"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("unmarshal schema: %v", err)
+	}
+	if schema.ID != SchemaURL(APIVersionV2) {
+		t.Fatalf("unexpected $id %q", schema.ID)
+	}
+	slo := schema.Properties.SLOs.Items.Properties
+	if _, ok := slo["alerting"]; ok {
+		t.Fatal("v2 schema should not accept per-SLO alerting")
+	}
+	if _, ok := slo["alerts"]; !ok {
+		t.Fatal("v2 schema missing alerts")
+	}
+	sli := slo["sli"]
+	if sli.MaxProperties != 1 {
+		t.Fatalf("expected sli to allow one kind, got maxProperties %d", sli.MaxProperties)
+	}
+	for _, field := range []string{"requestBased", "latency"} {
+		if _, ok := sli.Properties[field]; !ok {
+			t.Fatalf("v2 sli missing %q", field)
+		}
+	}
+	if _, ok := sli.Properties["type"]; ok {
+		t.Fatal("v2 sli should not accept type")
+	}
+}
@@ -1,7 +1,6 @@
 package spec
 
 import (
-	"errors"
 	"fmt"
 	"regexp"
 	"strings"
@@ -13,12 +12,21 @@ const (
 	KindServiceSLO = "ServiceSLO"
 )
 
+// Spec is the internal hub type every supported apiVersion decodes into. Its
+// yaml tags describe margin/v1; newer versions have their own wire types and
+// converters in version.go, so the rest of margin only ever sees Spec.
 type Spec struct {
 	APIVersion string   `yaml:"apiVersion"`
 	Kind       string   `yaml:"kind"`
 	Metadata   Metadata `yaml:"metadata"`
 	Alerting   Alerting `yaml:"alerting"`
 	SLOs       []SLO    `yaml:"slos"`
+	// Maintenance lists planned maintenance that margin analyze leaves out
+	// of the adjusted error budget.
+	Maintenance []MaintenanceWindow `yaml:"maintenance,omitempty"`
+	Lint        Lint                `yaml:"lint,omitempty"`
+
+	positions map[string]Position
 }
 
 type Metadata struct {
@@ -66,69 +74,92 @@ type AlertOverride struct {
 	BurnRate float64  `yaml:"burnRate"`
 }
 
+// DefaultFastBurn and DefaultSlowBurn are the burn-rate alerts used when an
+// SLO does not override them.
+var (
+	DefaultFastBurn = AlertOverride{Windows: []string{"5m", "1h"}, BurnRate: 14.4}
+	DefaultSlowBurn = AlertOverride{Windows: []string{"30m", "6h"}, BurnRate: 6}
+)
+
+// MaintenanceWindow is a planned interval, with RFC3339 start and end
+// times, that should not count against the error budget.
+type MaintenanceWindow struct {
+	Start  string `yaml:"start"`
+	End    string `yaml:"end"`
+	Reason string `yaml:"reason,omitempty"`
+}
+
+type Lint struct {
+	Disable []string `yaml:"disable"`
+}
+
 var windowRe = regexp.MustCompile(`^(\d+)([smhdw])$`)
 
 func (s Spec) Validate() error {
-	var errs []string
-	if s.APIVersion != APIVersionV1 {
-		errs = append(errs, fmt.Sprintf("apiVersion must be %q", APIVersionV1))
+	if errs := s.Check(); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Check returns every validation problem in the spec, annotated with the
+// source position recorded by Load when available.
+func (s Spec) Check() ValidationErrors {
+	var errs ValidationErrors
+	if !supportedAPIVersion(s.APIVersion) {
+		errs = append(errs, newError("apiVersion", CodeUnsupportedVersion, fmt.Sprintf("apiVersion must be one of %s", strings.Join(SupportedAPIVersions(), ", "))))
 	}
 	if s.Kind != KindServiceSLO {
-		errs = append(errs, fmt.Sprintf("kind must be %q", KindServiceSLO))
+		errs = append(errs, newError("kind", CodeUnsupportedKind, fmt.Sprintf("kind must be %q", KindServiceSLO)))
 	}
 	if strings.TrimSpace(s.Metadata.Name) == "" {
-		errs = append(errs, "metadata.name is required")
+		errs = append(errs, newError("metadata.name", CodeRequired, "metadata.name is required"))
 	}
 	if strings.TrimSpace(s.Metadata.Service) == "" {
-		errs = append(errs, "metadata.service is required")
+		errs = append(errs, newError("metadata.service", CodeRequired, "metadata.service is required"))
 	}
 	if strings.TrimSpace(s.Metadata.Project) == "" {
-		errs = append(errs, "metadata.project is required")
+		errs = append(errs, newError("metadata.project", CodeRequired, "metadata.project is required"))
 	}
 	if strings.TrimSpace(s.Metadata.Runbook) != "" && !validURL(s.Metadata.Runbook) {
-		errs = append(errs, "metadata.runbook must start with http:// or https://")
+		errs = append(errs, newError("metadata.runbook", CodeInvalidURL, "metadata.runbook must start with http:// or https://"))
 	}
 	if len(s.SLOs) == 0 {
-		errs = append(errs, "at least one SLO is required")
+		errs = append(errs, newError("slos", CodeRequired, "at least one SLO is required"))
 	}
 
 	template, templateErr := TemplateForService(s.Metadata.Service)
 	if templateErr != nil {
-		errs = append(errs, templateErr.Error())
+		errs = append(errs, newError("metadata.service", CodeUnknownService, templateErr.Error()))
 	}
 	if alertErr := validateAlerting(s.Alerting, template); alertErr != "" {
-		errs = append(errs, alertErr)
+		errs = append(errs, newError("alerting.burnRateResourceType", CodeInvalidAlerting, alertErr))
 	}
 
 	for i, slo := range s.SLOs {
-		prefix := fmt.Sprintf("slos[%d]", i)
+		prefix := indexPath("slos", i)
 		if strings.TrimSpace(slo.Name) == "" {
-			errs = append(errs, fmt.Sprintf("%s.name is required", prefix))
+			errs = append(errs, newError(prefix+".name", CodeRequired, fmt.Sprintf("%s.name is required", prefix)))
 		}
 		if slo.Objective <= 0 || slo.Objective >= 100 {
-			errs = append(errs, fmt.Sprintf("%s.objective must be between 0 and 100", prefix))
+			errs = append(errs, newError(prefix+".objective", CodeInvalidObjective, fmt.Sprintf("%s.objective must be between 0 and 100", prefix)))
 		}
 		if periodErr := validatePeriod(slo.Period, slo.Window); periodErr != "" {
-			errs = append(errs, fmt.Sprintf("%s.period: %s", prefix, periodErr))
+			errs = append(errs, newError(prefix+".period", CodeInvalidPeriod, fmt.Sprintf("%s.period: %s", prefix, periodErr)))
 		}
 		if !validWindow(slo.Window) {
-			errs = append(errs, fmt.Sprintf("%s.window must look like 30d, 1h, or 15m", prefix))
+			errs = append(errs, newError(prefix+".window", CodeInvalidWindow, fmt.Sprintf("%s.window must look like 30d, 1h, or 15m", prefix)))
 		} else if windowErr := validateWindowBounds(slo.Window); windowErr != "" {
-			errs = append(errs, fmt.Sprintf("%s.window: %s", prefix, windowErr))
-		}
-		if overrideErr := validateSLOAlerting(slo.Alerting); overrideErr != "" {
-			errs = append(errs, fmt.Sprintf("%s.alerting: %s", prefix, overrideErr))
-		}
-		sliErrs := validateSLI(slo.SLI, template)
-		for _, err := range sliErrs {
-			errs = append(errs, fmt.Sprintf("%s.sli: %s", prefix, err))
+			errs = append(errs, newError(prefix+".window", CodeInvalidWindow, fmt.Sprintf("%s.window: %s", prefix, windowErr)))
 		}
+		errs = append(errs, validateSLOAlerting(slo.Alerting).prefixed(prefix+".alerting")...)
+		errs = append(errs, validateSLI(slo.SLI, template).prefixed(prefix+".sli")...)
 	}
-
-	if len(errs) > 0 {
-		return errors.New(strings.Join(errs, "; "))
+	for i, window := range s.Maintenance {
+		errs = append(errs, validateMaintenance(window).prefixed(indexPath("maintenance", i))...)
 	}
-	return nil
+
+	return s.locate(errs)
 }
 
 func validWindow(window string) bool {
@@ -183,132 +214,151 @@ func validCalendarWindow(window string) bool {
 	}
 }
 
-func validateSLI(sli SLI, template ServiceTemplate) []string {
-	var errs []string
+func validateSLI(sli SLI, template ServiceTemplate) ValidationErrors {
+	var errs ValidationErrors
 	switch sli.Type {
 	case "request-based":
 		if sli.Good == nil || sli.Total == nil {
-			errs = append(errs, "request-based SLI requires good and total metrics")
+			errs = append(errs, newError("", CodeRequired, "request-based SLI requires good and total metrics"))
 			return errs
 		}
 		if strings.TrimSpace(sli.Good.Metric) == "" {
-			errs = append(errs, "good.metric is required")
+			errs = append(errs, newError("good.metric", CodeRequired, "good.metric is required"))
 		}
 		if strings.TrimSpace(sli.Total.Metric) == "" {
-			errs = append(errs, "total.metric is required")
-		}
-		if strings.TrimSpace(sli.Good.Filter) == "" {
-			errs = append(errs, "good.filter is required")
-		} else if !qualifiedFilter(sli.Good.Filter) {
-			errs = append(errs, "good.filter must reference metric., resource., project., metadata., or group.")
-		}
-		if strings.TrimSpace(sli.Total.Filter) != "" && !qualifiedFilter(sli.Total.Filter) {
-			errs = append(errs, "total.filter must reference metric., resource., project., metadata., or group.")
+			errs = append(errs, newError("total.metric", CodeRequired, "total.metric is required"))
 		}
 		if template.Name != "" {
 			if err := template.ValidateMetric(sli.Good.Metric); err != nil {
-				errs = append(errs, err.Error())
+				errs = append(errs, newError("good.metric", CodeUnsupportedMetric, err.Error()))
 			}
 			if err := template.ValidateMetric(sli.Total.Metric); err != nil {
-				errs = append(errs, err.Error())
-			}
-			if !filterHasResource(sli.Good.Filter, template.ResourceType) {
-				errs = append(errs, fmt.Sprintf("good.filter must include resource.type=%q", template.ResourceType))
-			}
-			if strings.TrimSpace(sli.Total.Filter) != "" && !filterHasResource(sli.Total.Filter, template.ResourceType) {
-				errs = append(errs, fmt.Sprintf("total.filter must include resource.type=%q", template.ResourceType))
+				errs = append(errs, newError("total.metric", CodeUnsupportedMetric, err.Error()))
 			}
 		}
+		if strings.TrimSpace(sli.Good.Filter) == "" {
+			errs = append(errs, newError("good.filter", CodeRequired, "good.filter is required"))
+		} else {
+			errs = append(errs, validateFilter("good.filter", sli.Good.Filter, sli.Good.Metric, template)...)
+		}
+		if strings.TrimSpace(sli.Total.Filter) != "" {
+			errs = append(errs, validateFilter("total.filter", sli.Total.Filter, sli.Total.Metric, template)...)
+		}
 	case "latency":
 		if strings.TrimSpace(sli.Metric) == "" {
-			errs = append(errs, "metric is required")
+			errs = append(errs, newError("metric", CodeRequired, "metric is required"))
 		}
 		if strings.TrimSpace(sli.Threshold) == "" {
-			errs = append(errs, "threshold is required")
+			errs = append(errs, newError("threshold", CodeRequired, "threshold is required"))
 		} else {
 			if _, err := time.ParseDuration(strings.TrimSpace(sli.Threshold)); err != nil {
-				errs = append(errs, "threshold must be a valid duration like 500ms or 1s")
+				errs = append(errs, newError("threshold", CodeInvalidThreshold, "threshold must be a valid duration like 500ms or 1s"))
 			}
 		}
-		if strings.TrimSpace(sli.Filter) != "" && !qualifiedFilter(sli.Filter) {
-			errs = append(errs, "filter must reference metric., resource., project., metadata., or group.")
-		}
 		if template.Name != "" {
 			if err := template.ValidateMetric(sli.Metric); err != nil {
-				errs = append(errs, err.Error())
-			}
-			if !filterHasResource(sli.Filter, template.ResourceType) {
-				errs = append(errs, fmt.Sprintf("filter must include resource.type=%q", template.ResourceType))
+				errs = append(errs, newError("metric", CodeUnsupportedMetric, err.Error()))
 			}
 		}
+		if strings.TrimSpace(sli.Filter) != "" {
+			errs = append(errs, validateFilter("filter", sli.Filter, sli.Metric, template)...)
+		} else if template.ResourceType != "" {
+			errs = append(errs, newError("filter", CodeResourceType, fmt.Sprintf("filter must include resource.type=%q", template.ResourceType)))
+		}
 	default:
-		errs = append(errs, "type must be request-based or latency")
+		errs = append(errs, newError("type", CodeInvalidSLIType, "type must be request-based or latency"))
 	}
 	return errs
 }
 
-func validateSLOAlerting(alerting SLOAlerting) string {
-	var errs []string
-	if err := validateAlertOverride("fast", alerting.Fast); err != "" {
-		errs = append(errs, err)
+// validateFilter parses a user filter and checks it against the
+// metric.type/resource.type clauses that apply prepends to it.
+func validateFilter(field, filter, metric string, template ServiceTemplate) ValidationErrors {
+	parsed, err := ParseFilter(filter)
+	if err != nil {
+		return ValidationErrors{newError(field, CodeInvalidFilter, fmt.Sprintf("%s is not a valid Monitoring filter: %v", field, err))}
 	}
-	if err := validateAlertOverride("slow", alerting.Slow); err != "" {
-		errs = append(errs, err)
+	var errs ValidationErrors
+	if !selectorsQualified(parsed) {
+		errs = append(errs, newError(field, CodeInvalidFilter, fmt.Sprintf("%s must reference metric., resource., project., metadata., or group.", field)))
 	}
-	if len(errs) > 0 {
-		return strings.Join(errs, "; ")
+	if value, ok := parsed.Equality("metric.type"); ok && strings.TrimSpace(metric) != "" && value != metric {
+		errs = append(errs, newError(field, CodeFilterConflict, fmt.Sprintf("%s metric.type %q conflicts with metric %q", field, value, metric)))
 	}
-	return ""
+	if template.ResourceType == "" {
+		return errs
+	}
+	value, ok := parsed.Equality("resource.type")
+	switch {
+	case !ok:
+		errs = append(errs, newError(field, CodeResourceType, fmt.Sprintf("%s must include resource.type=%q", field, template.ResourceType)))
+	case value != template.ResourceType:
+		errs = append(errs, newError(field, CodeResourceType, fmt.Sprintf("%s resource.type %q conflicts with template resource.type %q", field, value, template.ResourceType)))
+	}
+	return errs
+}
+
+func validateSLOAlerting(alerting SLOAlerting) ValidationErrors {
+	var errs ValidationErrors
+	errs = append(errs, validateAlertOverride("fast", alerting.Fast)...)
+	errs = append(errs, validateAlertOverride("slow", alerting.Slow)...)
+	return errs
 }
 
-func validateAlertOverride(name string, override *AlertOverride) string {
+func validateAlertOverride(name string, override *AlertOverride) ValidationErrors {
 	if override == nil {
-		return ""
+		return nil
 	}
-	var errs []string
+	var errs ValidationErrors
+	windowsPath := name + ".windows"
 	if len(override.Windows) > 0 {
 		if len(override.Windows) != 2 {
-			errs = append(errs, fmt.Sprintf("%s.windows must have exactly 2 entries", name))
+			errs = append(errs, newError(windowsPath, CodeInvalidAlerting, fmt.Sprintf("%s.windows must have exactly 2 entries", name)))
 		}
 		if len(override.Windows) == 2 {
-			d0, err0 := parseWindowDuration(override.Windows[0])
-			d1, err1 := parseWindowDuration(override.Windows[1])
+			d0, err0 := ParseWindow(override.Windows[0])
+			d1, err1 := ParseWindow(override.Windows[1])
 			if err0 != nil || err1 != nil {
-				errs = append(errs, fmt.Sprintf("%s.windows must look like 30d, 1h, or 15m", name))
+				errs = append(errs, newError(windowsPath, CodeInvalidAlerting, fmt.Sprintf("%s.windows must look like 30d, 1h, or 15m", name)))
 			} else {
 				if d0 == d1 {
-					errs = append(errs, fmt.Sprintf("%s.windows must have distinct short/long windows", name))
+					errs = append(errs, newError(windowsPath, CodeInvalidAlerting, fmt.Sprintf("%s.windows must have distinct short/long windows", name)))
 				}
 				if d0 >= d1 {
-					errs = append(errs, fmt.Sprintf("%s.windows must be ordered short, long", name))
+					errs = append(errs, newError(windowsPath, CodeInvalidAlerting, fmt.Sprintf("%s.windows must be ordered short, long", name)))
 				}
 			}
 		}
-		for _, window := range override.Windows {
+		for i, window := range override.Windows {
 			if !validWindow(window) {
-				errs = append(errs, fmt.Sprintf("%s.windows value %q must look like 30d, 1h, or 15m", name, window))
+				errs = append(errs, newError(indexPath(windowsPath, i), CodeInvalidAlerting, fmt.Sprintf("%s.windows value %q must look like 30d, 1h, or 15m", name, window)))
 			}
 		}
 	}
 	if override.BurnRate < 1 {
-		errs = append(errs, fmt.Sprintf("%s.burnRate must be >= 1", name))
+		errs = append(errs, newError(name+".burnRate", CodeInvalidAlerting, fmt.Sprintf("%s.burnRate must be >= 1", name)))
 	}
-	if len(errs) > 0 {
-		return strings.Join(errs, "; ")
-	}
-	return ""
+	return errs
 }
 
-func filterHasResource(filter, resourceType string) bool {
-	if strings.TrimSpace(resourceType) == "" {
-		return true
+func validateMaintenance(window MaintenanceWindow) ValidationErrors {
+	var errs ValidationErrors
+	start, startErr := time.Parse(time.RFC3339, strings.TrimSpace(window.Start))
+	if startErr != nil {
+		errs = append(errs, newError("start", CodeInvalidMaintenance, "start must be an RFC3339 time such as 2025-01-01T02:00:00Z"))
+	}
+	end, endErr := time.Parse(time.RFC3339, strings.TrimSpace(window.End))
+	if endErr != nil {
+		errs = append(errs, newError("end", CodeInvalidMaintenance, "end must be an RFC3339 time such as 2025-01-01T04:00:00Z"))
+	}
+	if startErr == nil && endErr == nil && !end.After(start) {
+		errs = append(errs, newError("end", CodeInvalidMaintenance, "end must be after start"))
 	}
-	return strings.Contains(filter, fmt.Sprintf("resource.type=\"%s\"", resourceType)) ||
-		strings.Contains(filter, fmt.Sprintf("resource.type=%q", resourceType))
+	return errs
 }
 
 func validateWindowBounds(window string) string {
-	d, err := parseWindowDuration(window)
+	d, err := ParseWindow(window)
 	if err != nil {
 		return err.Error()
 	}
@@ -321,7 +371,7 @@ func validateWindowBounds(window string) string {
 	return ""
 }
 
-func parseWindowDuration(window string) (time.Duration, error) {
+func ParseWindow(window string) (time.Duration, error) {
 	if window == "" {
 		return 0, fmt.Errorf("window is empty")
 	}
@@ -353,13 +403,18 @@ func validURL(value string) bool {
 }
 
 func qualifiedFilter(filter string) bool {
-	trimmed := strings.TrimSpace(filter)
-	if trimmed == "" {
+	parsed, err := ParseFilter(filter)
+	if err != nil {
 		return false
 	}
-	return strings.Contains(trimmed, "metric.") ||
-		strings.Contains(trimmed, "resource.") ||
-		strings.Contains(trimmed, "project.") ||
-		strings.Contains(trimmed, "metadata.") ||
-		strings.Contains(trimmed, "group.")
+	return selectorsQualified(parsed)
+}
+
+func selectorsQualified(filter *Filter) bool {
+	for _, cmp := range filter.Comparisons() {
+		if !filterRoots[selectorRoot(cmp.Selector)] {
+			return false
+		}
+	}
+	return true
 }
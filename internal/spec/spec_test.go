@@ -95,10 +95,10 @@ func TestValidateSLOAlerting(t *testing.T) {
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			got := validateSLOAlerting(tc.alerting)
-			if tc.wantOK && got != "" {
-				t.Fatalf("expected ok, got %q", got)
+			if tc.wantOK && len(got) != 0 {
+				t.Fatalf("expected ok, got %v", got)
 			}
-			if !tc.wantOK && got == "" {
+			if !tc.wantOK && len(got) == 0 {
 				t.Fatalf("expected error, got ok")
 			}
 		})
@@ -3,15 +3,37 @@ package spec
 import (
 	"fmt"
 	"sort"
+	"time"
 )
 
 type ServiceTemplate struct {
 	Name         string
 	ResourceType string
 	Metrics      map[string]MetricTemplate
-	Pitfalls     []string
+	Pitfalls     []Pitfall
+	LatencyFloor time.Duration
+	// Starter SLO defaults used by margin init. GoodFilter selects successful
+	// events of RequestMetric and is empty when the metric has no such label.
+	RequestMetric    string
+	LatencyMetric    string
+	GoodFilter       string
+	LatencyThreshold time.Duration
 }
 
+// Pitfall is a known trap for a template. Check names the lint rule that
+// detects it, or is empty when the pitfall is advisory only.
+type Pitfall struct {
+	Text  string
+	Check string
+}
+
+const (
+	CheckRetries      = "good-filter-counts-retries"
+	CheckNotFound     = "good-filter-counts-404s"
+	CheckHealthChecks = "health-checks-not-excluded"
+	CheckCacheHits    = "cache-hits-not-filtered"
+)
+
 type MetricTemplate struct {
 	Name        string
 	Description string
@@ -31,9 +53,14 @@ var serviceTemplates = map[string]ServiceTemplate{
 				Description: "Request latency distribution for Cloud Run",
 			},
 		},
-		Pitfalls: []string{
-			"Cold starts can skew latency SLOs for low-traffic services.",
-			"Retries can double-count failed requests unless filters exclude them.",
+		LatencyFloor:     10 * time.Millisecond,
+		RequestMetric:    "run.googleapis.com/request_count",
+		LatencyMetric:    "run.googleapis.com/request_latencies",
+		GoodFilter:       `metric.label.response_code_class="2xx"`,
+		LatencyThreshold: 500 * time.Millisecond,
+		Pitfalls: []Pitfall{
+			{Text: "Cold starts can skew latency SLOs for low-traffic services."},
+			{Text: "Retries can double-count failed requests unless filters exclude them.", Check: CheckRetries},
 		},
 	},
 	"https-load-balancer": {
@@ -49,9 +76,14 @@ var serviceTemplates = map[string]ServiceTemplate{
 				Description: "HTTPS load balancer total latency distribution",
 			},
 		},
-		Pitfalls: []string{
-			"Backends returning 404s can hide real availability issues.",
-			"Retry policies may inflate request counts.",
+		LatencyFloor:     5 * time.Millisecond,
+		RequestMetric:    "loadbalancing.googleapis.com/https/request_count",
+		LatencyMetric:    "loadbalancing.googleapis.com/https/total_latencies",
+		GoodFilter:       `metric.label.response_code_class=200`,
+		LatencyThreshold: 500 * time.Millisecond,
+		Pitfalls: []Pitfall{
+			{Text: "Backends returning 404s can hide real availability issues.", Check: CheckNotFound},
+			{Text: "Retry policies may inflate request counts.", Check: CheckRetries},
 		},
 	},
 	"gke-ingress": {
@@ -67,9 +99,14 @@ var serviceTemplates = map[string]ServiceTemplate{
 				Description: "GKE ingress request latency distribution",
 			},
 		},
-		Pitfalls: []string{
-			"Default backend 404s can mask real availability issues.",
-			"Ingress metrics are per-cluster; multi-cluster routing may need multiple SLOs.",
+		LatencyFloor:     5 * time.Millisecond,
+		RequestMetric:    "kubernetes.io/ingress/request_count",
+		LatencyMetric:    "kubernetes.io/ingress/latency",
+		GoodFilter:       `metric.label.response_code_class="2xx"`,
+		LatencyThreshold: 500 * time.Millisecond,
+		Pitfalls: []Pitfall{
+			{Text: "Default backend 404s can mask real availability issues.", Check: CheckNotFound},
+			{Text: "Ingress metrics are per-cluster; multi-cluster routing may need multiple SLOs."},
 		},
 	},
 	"cloud-sql": {
@@ -85,9 +122,13 @@ var serviceTemplates = map[string]ServiceTemplate{
 				Description: "Cloud SQL query latency distribution",
 			},
 		},
-		Pitfalls: []string{
-			"Long-running queries can skew latency SLOs without proper filters.",
-			"Replica failover can create transient errors that impact availability.",
+		LatencyFloor:     time.Millisecond,
+		RequestMetric:    "cloudsql.googleapis.com/database/queries",
+		LatencyMetric:    "cloudsql.googleapis.com/database/query_latency",
+		LatencyThreshold: 100 * time.Millisecond,
+		Pitfalls: []Pitfall{
+			{Text: "Long-running queries can skew latency SLOs without proper filters."},
+			{Text: "Replica failover can create transient errors that impact availability."},
 		},
 	},
 	"gke-service": {
@@ -103,9 +144,14 @@ var serviceTemplates = map[string]ServiceTemplate{
 				Description: "GKE service request latency distribution",
 			},
 		},
-		Pitfalls: []string{
-			"Service metrics are per-cluster; multi-cluster services need multiple SLOs.",
-			"Mixing readiness probe failures with user traffic can skew availability.",
+		LatencyFloor:     5 * time.Millisecond,
+		RequestMetric:    "kubernetes.io/service/request_count",
+		LatencyMetric:    "kubernetes.io/service/latency",
+		GoodFilter:       `metric.label.response_code_class="2xx"`,
+		LatencyThreshold: 500 * time.Millisecond,
+		Pitfalls: []Pitfall{
+			{Text: "Service metrics are per-cluster; multi-cluster services need multiple SLOs."},
+			{Text: "Mixing readiness probe failures with user traffic can skew availability."},
 		},
 	},
 	"gke-gateway": {
@@ -121,9 +167,14 @@ var serviceTemplates = map[string]ServiceTemplate{
 				Description: "GKE Gateway request latency distribution",
 			},
 		},
-		Pitfalls: []string{
-			"Gateway metrics can include internal health checks unless filtered.",
-			"Gateway routing rules may mask backend-specific latency issues.",
+		LatencyFloor:     5 * time.Millisecond,
+		RequestMetric:    "kubernetes.io/gateway/request_count",
+		LatencyMetric:    "kubernetes.io/gateway/latency",
+		GoodFilter:       `metric.label.response_code_class="2xx"`,
+		LatencyThreshold: 500 * time.Millisecond,
+		Pitfalls: []Pitfall{
+			{Text: "Gateway metrics can include internal health checks unless filtered.", Check: CheckHealthChecks},
+			{Text: "Gateway routing rules may mask backend-specific latency issues."},
 		},
 	},
 	"gce-lb": {
@@ -139,9 +190,14 @@ var serviceTemplates = map[string]ServiceTemplate{
 				Description: "HTTPS load balancer latency distribution (GCE)",
 			},
 		},
-		Pitfalls: []string{
-			"Backend errors can be masked by cache hits without proper filters.",
-			"Global vs regional load balancers may use different resource labels.",
+		LatencyFloor:     5 * time.Millisecond,
+		RequestMetric:    "loadbalancing.googleapis.com/https/request_count",
+		LatencyMetric:    "loadbalancing.googleapis.com/https/total_latencies",
+		GoodFilter:       `metric.label.response_code_class=200`,
+		LatencyThreshold: 500 * time.Millisecond,
+		Pitfalls: []Pitfall{
+			{Text: "Backend errors can be masked by cache hits without proper filters.", Check: CheckCacheHits},
+			{Text: "Global vs regional load balancers may use different resource labels."},
 		},
 	},
 	"cloud-functions": {
@@ -157,9 +213,14 @@ var serviceTemplates = map[string]ServiceTemplate{
 				Description: "Cloud Functions execution time distribution",
 			},
 		},
-		Pitfalls: []string{
-			"Cold starts can inflate latency for low-traffic functions.",
-			"Retries can double-count failures unless filtered.",
+		LatencyFloor:     20 * time.Millisecond,
+		RequestMetric:    "cloudfunctions.googleapis.com/function/execution_count",
+		LatencyMetric:    "cloudfunctions.googleapis.com/function/execution_times",
+		GoodFilter:       `metric.label.status="ok"`,
+		LatencyThreshold: time.Second,
+		Pitfalls: []Pitfall{
+			{Text: "Cold starts can inflate latency for low-traffic functions."},
+			{Text: "Retries can double-count failures unless filtered.", Check: CheckRetries},
 		},
 	},
 	"pubsub-subscription": {
@@ -175,9 +236,13 @@ var serviceTemplates = map[string]ServiceTemplate{
 				Description: "Pub/Sub ack delay distribution",
 			},
 		},
-		Pitfalls: []string{
-			"Backlog spikes can be caused by subscriber scaling, not publisher errors.",
-			"Dead-letter policies can hide underlying delivery failures.",
+		LatencyFloor:     100 * time.Millisecond,
+		RequestMetric:    "pubsub.googleapis.com/subscription/ack_message_count",
+		LatencyMetric:    "pubsub.googleapis.com/subscription/ack_message_delay",
+		LatencyThreshold: 10 * time.Second,
+		Pitfalls: []Pitfall{
+			{Text: "Backlog spikes can be caused by subscriber scaling, not publisher errors."},
+			{Text: "Dead-letter policies can hide underlying delivery failures."},
 		},
 	},
 	"cloud-storage": {
@@ -193,9 +258,14 @@ var serviceTemplates = map[string]ServiceTemplate{
 				Description: "Cloud Storage API request latency distribution",
 			},
 		},
-		Pitfalls: []string{
-			"Multi-region buckets can have higher tail latency without an incident.",
-			"Requester-pays or IAM errors can look like availability issues.",
+		LatencyFloor:     10 * time.Millisecond,
+		RequestMetric:    "storage.googleapis.com/api/request_count",
+		LatencyMetric:    "storage.googleapis.com/api/request_latencies",
+		GoodFilter:       `metric.label.response_code="OK"`,
+		LatencyThreshold: 500 * time.Millisecond,
+		Pitfalls: []Pitfall{
+			{Text: "Multi-region buckets can have higher tail latency without an incident."},
+			{Text: "Requester-pays or IAM errors can look like availability issues."},
 		},
 	},
 	"cloud-tasks": {
@@ -211,9 +281,13 @@ var serviceTemplates = map[string]ServiceTemplate{
 				Description: "Cloud Tasks task attempt latency distribution",
 			},
 		},
-		Pitfalls: []string{
-			"High retry rates can inflate attempts without real user impact.",
-			"Queue throttling may increase latency during bursts.",
+		LatencyFloor:     10 * time.Millisecond,
+		RequestMetric:    "cloudtasks.googleapis.com/queue/task_attempt_count",
+		LatencyMetric:    "cloudtasks.googleapis.com/queue/task_attempt_latencies",
+		LatencyThreshold: time.Second,
+		Pitfalls: []Pitfall{
+			{Text: "High retry rates can inflate attempts without real user impact.", Check: CheckRetries},
+			{Text: "Queue throttling may increase latency during bursts."},
 		},
 	},
 	"bigquery": {
@@ -229,9 +303,13 @@ var serviceTemplates = map[string]ServiceTemplate{
 				Description: "BigQuery query latency distribution",
 			},
 		},
-		Pitfalls: []string{
-			"Batch queries have higher latency and should be filtered separately.",
-			"Resource-heavy queries can dominate latency even when the service is healthy.",
+		LatencyFloor:     500 * time.Millisecond,
+		RequestMetric:    "bigquery.googleapis.com/query/count",
+		LatencyMetric:    "bigquery.googleapis.com/query/latency",
+		LatencyThreshold: 10 * time.Second,
+		Pitfalls: []Pitfall{
+			{Text: "Batch queries have higher latency and should be filtered separately."},
+			{Text: "Resource-heavy queries can dominate latency even when the service is healthy."},
 		},
 	},
 	"spanner": {
@@ -247,9 +325,14 @@ var serviceTemplates = map[string]ServiceTemplate{
 				Description: "Spanner API latency distribution",
 			},
 		},
-		Pitfalls: []string{
-			"Hot partitions can cause latency spikes without full outage.",
-			"Client-side timeouts can appear as service errors unless filtered.",
+		LatencyFloor:     2 * time.Millisecond,
+		RequestMetric:    "spanner.googleapis.com/api/request_count",
+		LatencyMetric:    "spanner.googleapis.com/api/latency",
+		GoodFilter:       `metric.label.status="OK"`,
+		LatencyThreshold: 100 * time.Millisecond,
+		Pitfalls: []Pitfall{
+			{Text: "Hot partitions can cause latency spikes without full outage."},
+			{Text: "Client-side timeouts can appear as service errors unless filtered."},
 		},
 	},
 	"cloud-cdn": {
@@ -265,9 +348,14 @@ var serviceTemplates = map[string]ServiceTemplate{
 				Description: "HTTP(S) latency distribution for CDN-enabled load balancer",
 			},
 		},
-		Pitfalls: []string{
-			"Cache hits can mask backend errors; consider filtering on cache_result if needed.",
-			"Regional vs global LB settings may change resource.label values.",
+		LatencyFloor:     time.Millisecond,
+		RequestMetric:    "loadbalancing.googleapis.com/https/request_count",
+		LatencyMetric:    "loadbalancing.googleapis.com/https/total_latencies",
+		GoodFilter:       `metric.label.response_code_class=200`,
+		LatencyThreshold: 200 * time.Millisecond,
+		Pitfalls: []Pitfall{
+			{Text: "Cache hits can mask backend errors; consider filtering on cache_result if needed.", Check: CheckCacheHits},
+			{Text: "Regional vs global LB settings may change resource.label values."},
 		},
 	},
 	"gce-uptime": {
@@ -279,9 +367,10 @@ var serviceTemplates = map[string]ServiceTemplate{
 				Description: "Uptime check pass/fail for HTTP(S) endpoints",
 			},
 		},
-		Pitfalls: []string{
-			"Uptime checks are synthetic; ensure they match user paths and auth.",
-			"Probe location failures can be localized; consider multi-location checks.",
+		RequestMetric: "monitoring.googleapis.com/uptime_check/check_passed",
+		Pitfalls: []Pitfall{
+			{Text: "Uptime checks are synthetic; ensure they match user paths and auth."},
+			{Text: "Probe location failures can be localized; consider multi-location checks."},
 		},
 	},
 }
@@ -290,12 +379,17 @@ func TemplateForService(service string) (ServiceTemplate, error) {
 	if tpl, ok := serviceTemplates[service]; ok {
 		return tpl, nil
 	}
-	var keys []string
-	for k := range serviceTemplates {
-		keys = append(keys, k)
+	return ServiceTemplate{}, fmt.Errorf("metadata.service must be one of %v", TemplateNames())
+}
+
+// TemplateNames returns the supported metadata.service values in sorted order.
+func TemplateNames() []string {
+	names := make([]string, 0, len(serviceTemplates))
+	for name := range serviceTemplates {
+		names = append(names, name)
 	}
-	sort.Strings(keys)
-	return ServiceTemplate{}, fmt.Errorf("metadata.service must be one of %v", keys)
+	sort.Strings(names)
+	return names
 }
 
 func (t ServiceTemplate) ValidateMetric(metric string) error {
@@ -0,0 +1,265 @@
+package spec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	APIVersionV2     = "margin/v2"
+	APIVersionLatest = APIVersionV2
+
+	CodeDeprecated = "deprecated"
+)
+
+// Alert tiers supported by margin/v2. They map onto the fast and slow burn
+// alerts that the planner builds.
+const (
+	TierFast = "fast"
+	TierSlow = "slow"
+)
+
+func SupportedAPIVersions() []string {
+	return []string{APIVersionV1, APIVersionV2}
+}
+
+func supportedAPIVersion(version string) bool {
+	for _, supported := range SupportedAPIVersions() {
+		if version == supported {
+			return true
+		}
+	}
+	return false
+}
+
+type specV2 struct {
+	APIVersion  string              `yaml:"apiVersion"`
+	Kind        string              `yaml:"kind"`
+	Metadata    Metadata            `yaml:"metadata"`
+	Alerting    Alerting            `yaml:"alerting,omitempty"`
+	SLOs        []sloV2             `yaml:"slos"`
+	Maintenance []MaintenanceWindow `yaml:"maintenance,omitempty"`
+	Lint        Lint                `yaml:"lint,omitempty"`
+}
+
+type sloV2 struct {
+	Name      string    `yaml:"name"`
+	Objective float64   `yaml:"objective"`
+	Window    string    `yaml:"window"`
+	Period    string    `yaml:"period,omitempty"`
+	SLI       sliV2     `yaml:"sli"`
+	Alerts    []alertV2 `yaml:"alerts,omitempty"`
+}
+
+type sliV2 struct {
+	RequestBased *requestBasedV2 `yaml:"requestBased,omitempty"`
+	Latency      *latencyV2      `yaml:"latency,omitempty"`
+}
+
+type requestBasedV2 struct {
+	Good  *MetricDef `yaml:"good"`
+	Total *MetricDef `yaml:"total"`
+}
+
+type latencyV2 struct {
+	Metric    string `yaml:"metric"`
+	Filter    string `yaml:"filter,omitempty"`
+	Threshold string `yaml:"threshold"`
+}
+
+type alertV2 struct {
+	Tier     string   `yaml:"tier"`
+	Windows  []string `yaml:"windows,omitempty"`
+	BurnRate float64  `yaml:"burnRate"`
+}
+
+// fromV2 converts a margin/v2 document into the hub type. The returned map
+// translates hub paths to the v2 paths they were read from so positions can
+// be carried over.
+func fromV2(in specV2) (Spec, map[string]string, ValidationErrors) {
+	out := Spec{
+		APIVersion:  in.APIVersion,
+		Kind:        in.Kind,
+		Metadata:    in.Metadata,
+		Alerting:    in.Alerting,
+		Maintenance: in.Maintenance,
+		Lint:        in.Lint,
+	}
+	paths := map[string]string{}
+	var errs ValidationErrors
+	for i, slo := range in.SLOs {
+		prefix := indexPath("slos", i)
+		hub := SLO{
+			Name:      slo.Name,
+			Objective: slo.Objective,
+			Window:    slo.Window,
+			Period:    slo.Period,
+		}
+		switch {
+		case slo.SLI.RequestBased != nil && slo.SLI.Latency != nil:
+			errs = append(errs, newError(prefix+".sli", CodeInvalidSLIType, fmt.Sprintf("%s.sli must set only one of requestBased or latency", prefix)))
+		case slo.SLI.RequestBased != nil:
+			hub.SLI = SLI{Type: "request-based", Good: slo.SLI.RequestBased.Good, Total: slo.SLI.RequestBased.Total}
+			paths[prefix+".sli.type"] = prefix + ".sli.requestBased"
+			paths[prefix+".sli.good"] = prefix + ".sli.requestBased.good"
+			paths[prefix+".sli.total"] = prefix + ".sli.requestBased.total"
+		case slo.SLI.Latency != nil:
+			hub.SLI = SLI{Type: "latency", Metric: slo.SLI.Latency.Metric, Filter: slo.SLI.Latency.Filter, Threshold: slo.SLI.Latency.Threshold}
+			paths[prefix+".sli.type"] = prefix + ".sli.latency"
+			for _, field := range []string{"metric", "filter", "threshold"} {
+				paths[prefix+".sli."+field] = prefix + ".sli.latency." + field
+			}
+		default:
+			errs = append(errs, newError(prefix+".sli", CodeInvalidSLIType, fmt.Sprintf("%s.sli must set requestBased or latency", prefix)))
+		}
+
+		if len(slo.Alerts) > 0 {
+			paths[prefix+".alerting"] = prefix + ".alerts"
+		}
+		seen := map[string]bool{}
+		for j, alert := range slo.Alerts {
+			alertPath := indexPath(prefix+".alerts", j)
+			override := &AlertOverride{Windows: alert.Windows, BurnRate: alert.BurnRate}
+			switch alert.Tier {
+			case TierFast:
+				hub.Alerting.Fast = override
+			case TierSlow:
+				hub.Alerting.Slow = override
+			default:
+				errs = append(errs, newError(alertPath+".tier", CodeInvalidAlerting, fmt.Sprintf("%s.tier must be %s or %s", alertPath, TierFast, TierSlow)))
+				continue
+			}
+			if seen[alert.Tier] {
+				errs = append(errs, newError(alertPath+".tier", CodeInvalidAlerting, fmt.Sprintf("%s.tier %q is defined more than once", alertPath, alert.Tier)))
+			}
+			seen[alert.Tier] = true
+			paths[prefix+".alerting."+alert.Tier] = alertPath
+		}
+		out.SLOs = append(out.SLOs, hub)
+	}
+	return out, paths, errs
+}
+
+// toV2 converts the hub type into its margin/v2 wire form.
+func toV2(in Spec) specV2 {
+	out := specV2{
+		APIVersion:  APIVersionV2,
+		Kind:        in.Kind,
+		Metadata:    in.Metadata,
+		Alerting:    in.Alerting,
+		Maintenance: in.Maintenance,
+		Lint:        in.Lint,
+	}
+	for _, slo := range in.SLOs {
+		v2 := sloV2{
+			Name:      slo.Name,
+			Objective: slo.Objective,
+			Window:    slo.Window,
+			Period:    slo.Period,
+		}
+		switch slo.SLI.Type {
+		case "latency":
+			v2.SLI.Latency = &latencyV2{Metric: slo.SLI.Metric, Filter: slo.SLI.Filter, Threshold: slo.SLI.Threshold}
+		default:
+			v2.SLI.RequestBased = &requestBasedV2{Good: slo.SLI.Good, Total: slo.SLI.Total}
+		}
+		if slo.Alerting.Fast != nil {
+			v2.Alerts = append(v2.Alerts, alertV2{Tier: TierFast, Windows: slo.Alerting.Fast.Windows, BurnRate: slo.Alerting.Fast.BurnRate})
+		}
+		if slo.Alerting.Slow != nil {
+			v2.Alerts = append(v2.Alerts, alertV2{Tier: TierSlow, Windows: slo.Alerting.Slow.Windows, BurnRate: slo.Alerting.Slow.BurnRate})
+		}
+		out.SLOs = append(out.SLOs, v2)
+	}
+	return out
+}
+
+// translatePositions maps positions recorded against v2 paths onto the hub
+// paths that validation and lint report.
+func translatePositions(v2 map[string]Position, paths map[string]string) map[string]Position {
+	out := make(map[string]Position, len(v2))
+	for path, pos := range v2 {
+		out[path] = pos
+	}
+	// Longest hub prefix first so slos[0].sli.good wins over slos[0].sli.
+	hubPaths := make([]string, 0, len(paths))
+	for hub := range paths {
+		hubPaths = append(hubPaths, hub)
+	}
+	sort.Slice(hubPaths, func(i, j int) bool { return len(hubPaths[i]) > len(hubPaths[j]) })
+	for _, hub := range hubPaths {
+		from := paths[hub]
+		for path, pos := range v2 {
+			if path == from {
+				out[hub] = pos
+			} else if strings.HasPrefix(path, from+".") || strings.HasPrefix(path, from+"[") {
+				rest := path[len(from):]
+				if _, ok := out[hub+rest]; !ok {
+					out[hub+rest] = pos
+				}
+			}
+		}
+	}
+	return out
+}
+
+// Deprecations lists fields the spec uses that are removed in the latest
+// apiVersion. They are warnings: deprecated specs still load and apply.
+func (s Spec) Deprecations() ValidationErrors {
+	if s.APIVersion != APIVersionV1 {
+		return nil
+	}
+	errs := ValidationErrors{{
+		Path:     "apiVersion",
+		Code:     CodeDeprecated,
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("apiVersion %s is deprecated; run `margin migrate` to rewrite the spec as %s", APIVersionV1, APIVersionLatest),
+	}}
+	for i, slo := range s.SLOs {
+		prefix := indexPath("slos", i)
+		var fields []string
+		for _, field := range []struct {
+			name string
+			set  bool
+		}{
+			{"type", slo.SLI.Type != ""},
+			{"good", slo.SLI.Good != nil},
+			{"total", slo.SLI.Total != nil},
+			{"metric", slo.SLI.Metric != ""},
+			{"filter", slo.SLI.Filter != ""},
+			{"threshold", slo.SLI.Threshold != ""},
+		} {
+			if field.set {
+				fields = append(fields, "sli."+field.name)
+			}
+		}
+		if len(fields) > 0 {
+			errs = append(errs, ValidationError{
+				Path:     prefix + ".sli.type",
+				Code:     CodeDeprecated,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s: %s are removed in %s; use sli.requestBased or sli.latency", prefix, strings.Join(fields, ", "), APIVersionV2),
+			})
+		}
+		if slo.Alerting.Fast != nil || slo.Alerting.Slow != nil {
+			errs = append(errs, ValidationError{
+				Path:     prefix + ".alerting",
+				Code:     CodeDeprecated,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s: alerting.fast and alerting.slow are removed in %s; use alerts with tier: fast or tier: slow", prefix, APIVersionV2),
+			})
+		}
+	}
+	return s.locate(errs)
+}
+
+// apiVersionOf returns the apiVersion of a document without decoding it.
+func apiVersionOf(root *yaml.Node) string {
+	if root == nil {
+		return ""
+	}
+	return mappingValue(root, "apiVersion")
+}
@@ -0,0 +1,160 @@
+package spec
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const validSpecV2YAML = `apiVersion: margin/v2
+kind: ServiceSLO
+metadata:
+  name: checkout-api
+  service: cloud-run
+  project: demo
+slos:
+  - name: availability
+    objective: 99.9
+    window: 30d
+    sli:
+      requestBased:
+        good:
+          metric: run.googleapis.com/request_count
+          filter: resource.type="cloud_run_revision" AND metric.label.response_code_class="2xx"
+        total:
+          metric: run.googleapis.com/request_count
+    alerts:
+      - tier: fast
+        windows: [2m, 30m]
+        burnRate: 20
+  - name: latency
+    objective: 99
+    window: 30d
+    sli:
+      latency:
+        metric: run.googleapis.com/request_latencies
+        filter: resource.type="cloud_run_revision"
+        threshold: 500ms
+`
+
+func TestParseV2(t *testing.T) {
+	s, err := Parse([]byte(validSpecV2YAML))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("expected valid spec, got %v", err)
+	}
+	if s.SLOs[0].SLI.Type != "request-based" || s.SLOs[1].SLI.Type != "latency" || s.SLOs[1].SLI.Threshold != "500ms" {
+		t.Fatalf("unexpected slis %+v", s.SLOs)
+	}
+	if fast := s.SLOs[0].Alerting.Fast; fast == nil || fast.BurnRate != 20 || s.SLOs[0].Alerting.Slow != nil {
+		t.Fatalf("unexpected alerting %+v", s.SLOs[0].Alerting)
+	}
+	pos, ok := s.Position("slos[0].sli.good.filter")
+	if !ok || pos.Line != 15 || pos.Column != 11 {
+		t.Fatalf("unexpected position %+v (ok=%v)", pos, ok)
+	}
+	if deprecated := s.Deprecations(); len(deprecated) != 0 {
+		t.Fatalf("expected no deprecations, got %v", deprecated)
+	}
+}
+
+func TestParseV2RejectsAmbiguousSLI(t *testing.T) {
+	doc := strings.Replace(validSpecV2YAML, "      latency:\n", "      requestBased: {}\n      latency:\n", 1)
+	_, err := Parse([]byte(doc))
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("expected one error, got %v", err)
+	}
+	if errs[0].Path != "slos[1].sli" || errs[0].Line != 25 || !strings.Contains(errs[0].Message, "only one of") {
+		t.Fatalf("unexpected error %#v", errs[0])
+	}
+}
+
+func TestParseV2RejectsBadTier(t *testing.T) {
+	doc := strings.Replace(validSpecV2YAML, "tier: fast", "tier: medium", 1)
+	_, err := Parse([]byte(doc))
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("expected one error, got %v", err)
+	}
+	if errs[0].Path != "slos[0].alerts[0].tier" || errs[0].Line != 19 {
+		t.Fatalf("unexpected error %#v", errs[0])
+	}
+}
+
+func TestParseV2RejectsV1Fields(t *testing.T) {
+	doc := strings.Replace(validSpecV2YAML, "      latency:\n", "      type: latency\n      latency:\n", 1)
+	_, err := Parse([]byte(doc))
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || errs[0].Code != CodeUnknownField || errs[0].Path != "slos[1].sli.type" {
+		t.Fatalf("expected unknown field error, got %v", err)
+	}
+}
+
+func TestDeprecationsNameV1Fields(t *testing.T) {
+	s, err := Parse([]byte(validSpecYAML))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := s.Deprecations()
+	if len(got) != 2 {
+		t.Fatalf("expected 2 deprecations, got %v", got)
+	}
+	for _, problem := range got {
+		if problem.Code != CodeDeprecated || problem.Severity != SeverityWarning {
+			t.Fatalf("unexpected deprecation %+v", problem)
+		}
+	}
+	if got[1].Path != "slos[0].sli.type" || got[1].Line != 12 || !strings.Contains(got[1].Message, "sli.type, sli.good, sli.total") {
+		t.Fatalf("unexpected sli deprecation %+v", got[1])
+	}
+}
+
+func TestMarshalV2RoundTrip(t *testing.T) {
+	s, err := Parse([]byte(validSpecV2YAML))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := Marshal(s)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	again, err := Parse(data)
+	if err != nil {
+		t.Fatalf("reparse: %v\n%s", err, data)
+	}
+	if again.APIVersion != APIVersionV2 || again.SLOs[0].SLI.Type != "request-based" || again.SLOs[0].Alerting.Fast == nil {
+		t.Fatalf("round trip lost fields:\n%s", data)
+	}
+}
+
+func TestParseV2Maintenance(t *testing.T) {
+	doc := validSpecV2YAML + `maintenance:
+  - start: 2025-01-01T02:00:00Z
+    end: 2025-01-01T04:00:00Z
+    reason: database upgrade
+  - start: 2025-01-02T02:00:00Z
+    end: 2025-01-02T01:00:00Z
+`
+	s, err := Parse([]byte(doc))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.Maintenance) != 2 || s.Maintenance[0].Start != "2025-01-01T02:00:00Z" || s.Maintenance[0].Reason != "database upgrade" {
+		t.Fatalf("unexpected maintenance %+v", s.Maintenance)
+	}
+	errs := s.Check()
+	if len(errs) != 1 || errs[0].Path != "maintenance[1].end" || errs[0].Code != CodeInvalidMaintenance || errs[0].Line != 35 {
+		t.Fatalf("expected maintenance[1].end to end before start, got %+v", errs)
+	}
+
+	data, err := Marshal(s)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(data), "maintenance:\n  - start: \"2025-01-01T02:00:00Z\"") {
+		t.Fatalf("marshal lost maintenance:\n%s", data)
+	}
+}